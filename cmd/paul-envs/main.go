@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/peaberberian/paul-envs/internal/commands"
 	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
 	"github.com/peaberberian/paul-envs/internal/files"
 )
 
@@ -27,32 +30,90 @@ func main() {
 		os.Exit(1)
 	}
 
-	if len(os.Args) < 2 {
+	globals, remainingArgs, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		console.Error("Error: %v", err)
+		os.Exit(1)
+	}
+
+	console.SetOutputFormat(globals.output)
+
+	if len(remainingArgs) < 1 {
 		commands.Help(filestore, console)
 		os.Exit(0)
 	}
 
-	cmd := os.Args[1]
-	args := os.Args[2:]
+	cmd := remainingArgs[0]
+	args := remainingArgs[1:]
+	if console.IsStructuredOutput() && !supportsStructuredOutput(cmd) {
+		console.Error("Error: the '%s' command does not support the --output flag", cmd)
+		console.Error("Commands with a machine-readable output: list, status, version, build")
+		os.Exit(1)
+	}
+
+	// Engine: The container engine, only instantiated when needed
+	configuredEngine, err := filestore.GetGlobalConfigValue("ENGINE")
+	if err != nil {
+		console.Warn("Could not read the default container engine: %v", err)
+	}
+	engineLoader := engine.NewLoader(engine.Select(globals.engine, configuredEngine))
 
 	var cmdErr error
 	switch cmd {
 	case "create", "c", "--create", "-c":
 		cmdErr = commands.Create(args, filestore, console)
+	case "edit", "update":
+		cmdErr = commands.Edit(args, filestore, console)
 	case "list", "ls", "l", "--list", "-l":
-		cmdErr = commands.List(ctx, args, filestore, console)
+		cmdErr = commands.List(ctx, args, filestore, engineLoader, console)
+	case "status":
+		cmdErr = commands.Status(ctx, args, filestore, engineLoader, console)
 	case "build", "b", "--build", "-b":
-		cmdErr = commands.Build(ctx, args, filestore, console)
+		cmdErr = commands.Build(ctx, args, filestore, engineLoader, console)
+	case "export":
+		cmdErr = commands.Export(args, filestore, console)
+	case "import":
+		cmdErr = commands.Import(args, filestore, console)
+	case "rename":
+		cmdErr = commands.Rename(ctx, args, filestore, engineLoader, console)
+	case "clone":
+		cmdErr = commands.Clone(ctx, args, filestore, engineLoader, console)
+	case "save":
+		cmdErr = commands.Save(ctx, args, filestore, engineLoader, console)
+	case "load":
+		cmdErr = commands.Load(ctx, args, filestore, engineLoader, console)
+	case "backup":
+		cmdErr = commands.Backup(ctx, args, filestore, engineLoader, console)
+	case "restore":
+		cmdErr = commands.Restore(ctx, args, filestore, engineLoader, console)
+	case "cache":
+		cmdErr = commands.Cache(ctx, args, filestore, engineLoader, console)
 	case "run", "e", "--run", "-e":
-		cmdErr = commands.Run(ctx, args, filestore, console)
+		cmdErr = commands.Run(ctx, args, filestore, engineLoader, console)
 	case "remove", "rm", "r", "--remove", "-r":
-		cmdErr = commands.Remove(ctx, args, filestore, console)
+		cmdErr = commands.Remove(ctx, args, filestore, engineLoader, console)
+	case "exec":
+		cmdErr = commands.Exec(ctx, args, filestore, engineLoader, console)
+	case "up":
+		cmdErr = commands.Up(ctx, args, filestore, engineLoader, console)
+	case "down":
+		cmdErr = commands.Down(ctx, args, filestore, engineLoader, console)
+	case "stop":
+		cmdErr = commands.Stop(ctx, args, filestore, engineLoader, console)
+	case "kill":
+		cmdErr = commands.Kill(ctx, args, filestore, engineLoader, console)
 	case "version", "v", "--version", "-v":
-		cmdErr = commands.Version(ctx, console)
+		cmdErr = commands.Version(ctx, engineLoader, console)
 	case "clean", "x", "--clean", "-x":
-		cmdErr = commands.Clean(ctx, filestore, console)
+		cmdErr = commands.Clean(ctx, filestore, engineLoader, console)
 	case "interactive", "i", "--interactive", "-i":
-		cmdErr = commands.Interactive(ctx, filestore, console)
+		cmdErr = commands.Interactive(ctx, filestore, engineLoader, console)
+	case "config":
+		cmdErr = commands.Config(args, filestore, console)
+	case "preset":
+		cmdErr = commands.Preset(args, filestore, console)
+	case "upgrade-base":
+		cmdErr = commands.UpgradeBase(args, filestore, console)
 	case "help", "h", "--help", "-h":
 		commands.Help(filestore, console)
 	default:
@@ -62,6 +123,11 @@ func main() {
 	}
 
 	if cmdErr != nil {
+		// Forward the exit code of commands executed in a container
+		var exitCodeErr *engine.ExitCodeError
+		if errors.As(cmdErr, &exitCodeErr) {
+			os.Exit(exitCodeErr.ExitCode)
+		}
 		if errors.Is(cmdErr, context.Canceled) {
 			console.Error("\nOperation cancelled")
 			os.Exit(130)
@@ -70,3 +136,85 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// Flags which can be set for any command
+type globalFlags struct {
+	// Value of the `--engine` flag: the wanted container engine
+	engine string
+	// Value of the `--output` flag: the format commands output their result in
+	output console.OutputFormat
+}
+
+// Extract global flags from the given arguments and return the remaining
+// arguments.
+//
+// Global flags are only recognized before the command, or right after it
+// (e.g. `paul-envs status --output json`): the command's own arguments, which
+// may be forwarded to a program in a container (e.g. `paul-envs run myapp curl
+// --output page.html`), are left untouched starting from the first one which
+// is not a global flag.
+func parseGlobalFlags(args []string) (globalFlags, []string, error) {
+	flags := globalFlags{output: console.OutputText}
+	remaining := make([]string, 0, len(args))
+	foundCommand := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			remaining = append(remaining, args[i:]...)
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--output="); ok {
+			format, err := console.ParseOutputFormat(value)
+			if err != nil {
+				return globalFlags{}, nil, fmt.Errorf("invalid --output flag: %w", err)
+			}
+			flags.output = format
+			continue
+		} else if arg == "--output" {
+			if i+1 >= len(args) {
+				return globalFlags{}, nil, errors.New("the --output flag needs a value")
+			}
+			format, err := console.ParseOutputFormat(args[i+1])
+			if err != nil {
+				return globalFlags{}, nil, fmt.Errorf("invalid --output flag: %w", err)
+			}
+			flags.output = format
+			i++
+			continue
+		}
+		if value, ok := strings.CutPrefix(arg, "--engine="); ok {
+			flags.engine = value
+		} else if arg == "--engine" {
+			if i+1 >= len(args) {
+				return globalFlags{}, nil, errors.New("the --engine flag needs a value")
+			}
+			flags.engine = args[i+1]
+			i++
+		} else if !foundCommand {
+			foundCommand = true
+			remaining = append(remaining, arg)
+			continue
+		} else {
+			remaining = append(remaining, args[i:]...)
+			break
+		}
+		if err := engine.ValidateEngineName(flags.engine); err != nil {
+			return globalFlags{}, nil, fmt.Errorf("invalid --engine flag: %w", err)
+		}
+	}
+	return flags, remaining, nil
+}
+
+// Returns `true` if the given command can output its result in a
+// machine-readable format, as asked through the `--output` flag.
+func supportsStructuredOutput(cmd string) bool {
+	switch cmd {
+	case "list", "ls", "l", "--list", "-l",
+		"status",
+		"version", "v", "--version", "-v",
+		"build", "b", "--build", "-b":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+)
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		engine    string
+		output    console.OutputFormat
+		remaining []string
+	}{
+		{"before the command", []string{"--engine", "podman", "--output=json", "status"},
+			"podman", console.OutputJSON, []string{"status"}},
+		{"right after the command", []string{"status", "--output", "yaml", "--engine=docker", "myapp"},
+			"docker", console.OutputYAML, []string{"status", "myapp"}},
+		{"in a command run in a container", []string{"run", "myapp", "curl", "--output", "page.html", "https://example.com"},
+			"", console.OutputText, []string{"run", "myapp", "curl", "--output", "page.html", "https://example.com"}},
+		{"after the command's arguments", []string{"exec", "myapp", "--engine", "podman", "--", "ls"},
+			"", console.OutputText, []string{"exec", "myapp", "--engine", "podman", "--", "ls"}},
+		{"after --", []string{"--", "--output", "json"},
+			"", console.OutputText, []string{"--", "--output", "json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, remaining, err := parseGlobalFlags(tt.args)
+			if err != nil {
+				t.Fatalf("parseGlobalFlags() error = %v", err)
+			}
+			if flags.engine != tt.engine || flags.output != tt.output {
+				t.Errorf("parseGlobalFlags() flags = %+v, want engine %q and output %v", flags, tt.engine, tt.output)
+			}
+			if !reflect.DeepEqual(remaining, tt.remaining) {
+				t.Errorf("parseGlobalFlags() remaining = %v, want %v", remaining, tt.remaining)
+			}
+		})
+	}
+
+	if _, _, err := parseGlobalFlags([]string{"status", "--output", "xml"}); err == nil {
+		t.Error("expected an error for an invalid output format")
+	}
+}
@@ -1,17 +1,20 @@
 package args
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/manifest"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
@@ -36,8 +39,42 @@ func ParseAndPrompt(args []string, cons *console.Console, filestor *files.FileSt
 		return config.Config{}, err
 	}
 
-	// Build initial config
-	cfg, err := buildConfig(projectPath, parsed)
+	var projectManifest *manifest.Manifest
+	if !parsed.noManifest {
+		projectManifest, err = manifest.Load(projectPath)
+		if err != nil {
+			return config.Config{}, err
+		}
+		if projectManifest != nil {
+			cons.Info("Using the project manifest %s", filepath.Join(projectPath, manifest.Filename))
+		}
+	}
+
+	presets, err := loadPresets(parsed.presets, filestor)
+	if err != nil {
+		return config.Config{}, err
+	}
+	if len(presets) > 0 {
+		cons.Info("Using the preset(s): %s", strings.Join(parsed.presets, ", "))
+	}
+
+	globalConfig, err := filestor.ReadGlobalConfig()
+	if err != nil {
+		return config.Config{}, err
+	}
+	defaults := config.New("dev", config.ShellBash)
+	if err := ApplyUserDefaults(&defaults, globalConfig); err != nil {
+		return config.Config{}, fmt.Errorf("invalid default in %s: %w", filestor.GetGlobalConfigFilePath(), err)
+	}
+
+	// Build initial config. `given` only contains what has been explicitly
+	// asked for this project: the rest will be prompted for, with the user's
+	// defaults as default answers.
+	given, err := buildConfig(config.New("dev", config.ShellBash), projectPath, parsed, projectManifest, presets)
+	if err != nil {
+		return config.Config{}, err
+	}
+	cfg, err := buildConfig(defaults, projectPath, parsed, projectManifest, presets)
 	if err != nil {
 		return config.Config{}, err
 	}
@@ -49,7 +86,7 @@ func ParseAndPrompt(args []string, cons *console.Console, filestor *files.FileSt
 
 	// Prompt for missing values if interactive
 	if !noPrompt {
-		if err := promptMissing(cons, &cfg); err != nil {
+		if err := promptMissing(cons, &cfg, &given, parsed.toggles); err != nil {
 			return config.Config{}, err
 		}
 	}
@@ -64,30 +101,26 @@ func ParseAndPrompt(args []string, cons *console.Console, filestor *files.FileSt
 
 // parsedFlags holds raw flag values
 type parsedFlags struct {
-	noPrompt        bool
-	name            string
-	uid             string
-	gid             string
-	username        string
-	shell           string
-	nodeVersion     string
-	rustVersion     string
-	pythonVersion   string
-	goVersion       string
-	enableWasm      bool
-	enableSsh       bool
-	enableSudo      bool
-	gitName         string
-	gitEmail        string
-	installNeovim   bool
-	installStarship bool
-	installAtuin    bool
-	installMise     bool
-	installZellij   bool
-	installJujutsu  bool
-	packages        []string
-	ports           []string
-	volumes         []string
+	noPrompt      bool
+	noManifest    bool
+	presets       []string
+	name          string
+	uid           string
+	gid           string
+	username      string
+	shell         string
+	nodeVersion   string
+	rustVersion   string
+	pythonVersion string
+	goVersion     string
+	gitName       string
+	gitEmail      string
+	cache         string
+	packages      []string
+	ports         []string
+	volumes       []string
+	// Boolean options explicitly enabled or disabled, by name in `toggleFlags`
+	toggles map[string]bool
 }
 
 func parseFlags(args []string) (*parsedFlags, bool, error) {
@@ -97,6 +130,33 @@ func parseFlags(args []string) (*parsedFlags, bool, error) {
 	flagset := flag.NewFlagSet("create", flag.ContinueOnError)
 	flagset.BoolVar(&noPrompt, "no-prompt", false, "Non-interactive mode")
 	flagset.StringVar(&p.name, "name", "", "Project name")
+	flagset.BoolVar(&p.noManifest, "no-manifest", false, "Ignore the project's manifest")
+	defineConfigFlags(flagset, p)
+
+	// Parse repeatable flags manually
+	filtered := extractRepeatableFlags(args, map[string]*[]string{
+		"--port":    &p.ports,
+		"--volume":  &p.volumes,
+		"--package": &p.packages,
+		"--preset":  &p.presets,
+	})
+
+	if err := flagset.Parse(filtered); err != nil {
+		return nil, false, err
+	}
+	toggles, err := parseToggleFlags(flagset)
+	if err != nil {
+		return nil, false, err
+	}
+	p.toggles = toggles
+
+	return p, noPrompt, nil
+}
+
+// Define on `flagset` the flags describing a project's configuration, shared
+// by the `create` and `edit` commands, whose values will be set on `p`.
+// Boolean options are then read through `parseToggleFlags`.
+func defineConfigFlags(flagset *flag.FlagSet, p *parsedFlags) {
 	flagset.StringVar(&p.uid, "uid", "", "Container UID")
 	flagset.StringVar(&p.gid, "gid", "", "Container GID")
 	flagset.StringVar(&p.username, "username", "", "Container username")
@@ -105,72 +165,196 @@ func parseFlags(args []string) (*parsedFlags, bool, error) {
 	flagset.StringVar(&p.rustVersion, "rust", "", "Rust version")
 	flagset.StringVar(&p.pythonVersion, "python", "", "Python version")
 	flagset.StringVar(&p.goVersion, "go", "", "Go version")
-	flagset.BoolVar(&p.enableWasm, "enable-wasm", false, "Enable WebAssembly tools")
-	flagset.BoolVar(&p.enableWasm, "wasm", false, "Enable WebAssembly tools")
-	flagset.BoolVar(&p.enableSsh, "enable-ssh", false, "Enable SSH access")
-	flagset.BoolVar(&p.enableSsh, "ssh", false, "Enable SSH access")
-	flagset.BoolVar(&p.enableSudo, "enable-sudo", false, "Enable sudo access")
-	flagset.BoolVar(&p.enableSudo, "sudo", false, "Enable sudo access")
+	flagset.Bool("enable-wasm", false, "Enable WebAssembly tools")
+	flagset.Bool("wasm", false, "Enable WebAssembly tools")
+	flagset.Bool("enable-ssh", false, "Enable SSH access")
+	flagset.Bool("ssh", false, "Enable SSH access")
+	flagset.Bool("enable-sudo", false, "Enable sudo access")
+	flagset.Bool("sudo", false, "Enable sudo access")
 	flagset.StringVar(&p.gitName, "git-name", "", "Git user name")
 	flagset.StringVar(&p.gitEmail, "git-email", "", "Git user email")
-	flagset.BoolVar(&p.installNeovim, "neovim", false, "Install Neovim")
-	flagset.BoolVar(&p.installStarship, "starship", false, "Install Starship")
-	flagset.BoolVar(&p.installAtuin, "atuin", false, "Install Atuin")
-	flagset.BoolVar(&p.installMise, "mise", false, "Install Mise")
-	flagset.BoolVar(&p.installZellij, "zellij", false, "Install Zellij")
-	flagset.BoolVar(&p.installJujutsu, "jujutsu", false, "Install Jujutsu")
+	flagset.StringVar(&p.cache, "cache", "", "Package cache: shared, private or a cache group")
+	flagset.Bool("neovim", false, "Install Neovim")
+	flagset.Bool("starship", false, "Install Starship")
+	flagset.Bool("atuin", false, "Install Atuin")
+	flagset.Bool("mise", false, "Install Mise")
+	flagset.Bool("zellij", false, "Install Zellij")
+	flagset.Bool("jujutsu", false, "Install Jujutsu")
+	for _, toggle := range toggleFlags {
+		flagset.Bool("no-"+toggle.name, false, "Disable "+toggle.name)
+	}
+}
 
-	// Parse repeatable flags manually
+// Names of the boolean flags of `create` and `edit`, which can also be
+// disabled through a "no-" prefix (e.g. `--no-ssh`), with the configuration
+// field they control.
+var toggleFlags = []struct {
+	name  string
+	field func(*config.Config) *bool
+}{
+	{"wasm", func(c *config.Config) *bool { return &c.EnableWasm }},
+	{"ssh", func(c *config.Config) *bool { return &c.EnableSsh }},
+	{"sudo", func(c *config.Config) *bool { return &c.EnableSudo }},
+	{"neovim", func(c *config.Config) *bool { return &c.InstallNeovim }},
+	{"starship", func(c *config.Config) *bool { return &c.InstallStarship }},
+	{"atuin", func(c *config.Config) *bool { return &c.InstallAtuin }},
+	{"mise", func(c *config.Config) *bool { return &c.InstallMise }},
+	{"zellij", func(c *config.Config) *bool { return &c.InstallZellij }},
+	{"jujutsu", func(c *config.Config) *bool { return &c.InstallJujutsu }},
+}
+
+// Return the value of each boolean flag of `toggleFlags` explicitly enabled or
+// disabled in the already-parsed `flagset`, by name.
+func parseToggleFlags(flagset *flag.FlagSet) (map[string]bool, error) {
+	setFlags := make(map[string]bool)
+	flagset.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	toggles := make(map[string]bool)
+	for _, toggle := range toggleFlags {
+		// "wasm", "ssh" and "sudo" also have an "enable-" alias
+		isEnabled := setFlags[toggle.name] || setFlags["enable-"+toggle.name]
+		isDisabled := setFlags["no-"+toggle.name]
+		if isEnabled && isDisabled {
+			return nil, fmt.Errorf("both enabling and disabling %s", toggle.name)
+		}
+		if isEnabled || isDisabled {
+			toggles[toggle.name] = isEnabled
+		}
+	}
+	return toggles, nil
+}
+
+// Set on `cfg` the boolean options returned by `parseToggleFlags`.
+func applyToggleFlags(cfg *config.Config, toggles map[string]bool) {
+	for _, toggle := range toggleFlags {
+		if value, ok := toggles[toggle.name]; ok {
+			*toggle.field(cfg) = value
+		}
+	}
+}
+
+// Remove from `args` the repeatable flags whose name is a key of `repeatable`
+// (e.g. "--port"), appending their values to the corresponding slice, and
+// return the remaining arguments.
+func extractRepeatableFlags(args []string, repeatable map[string]*[]string) []string {
 	filtered := make([]string, 0, len(args))
 	for i := 0; i < len(args); i++ {
-		if args[i] == "--port" && i+1 < len(args) {
-			p.ports = append(p.ports, args[i+1])
-			i++
-		} else if args[i] == "--volume" && i+1 < len(args) {
-			p.volumes = append(p.volumes, args[i+1])
-			i++
-		} else if args[i] == "--package" && i+1 < len(args) {
-			p.packages = append(p.packages, args[i+1])
+		if values, ok := repeatable[args[i]]; ok && i+1 < len(args) {
+			*values = append(*values, args[i+1])
 			i++
 		} else {
 			filtered = append(filtered, args[i])
 		}
 	}
+	return filtered
+}
 
-	if err := flagset.Parse(filtered); err != nil {
-		return nil, false, err
+// Build the configuration of a new project from `cfg`, the flags given to
+// `create`, the wanted presets and, if not `nil`, the project's manifest.
+//
+// Flags take precedence over presets, which are layered on top of the manifest
+// in order.
+func buildConfig(
+	cfg config.Config,
+	projectPath string,
+	p *parsedFlags,
+	m *manifest.Manifest,
+	presets []*manifest.Manifest,
+) (config.Config, error) {
+	cfg.ProjectHostPath = projectPath
+	if cfg.Packages == nil {
+		cfg.Packages = []string{}
+	}
+	if cfg.Ports == nil {
+		cfg.Ports = []uint16{}
+	}
+	if m != nil {
+		m.Apply(&cfg)
+	}
+	for _, preset := range presets {
+		preset.Merge(&cfg)
 	}
 
-	return p, noPrompt, nil
-}
+	if err := applyValueFlags(&cfg, p); err != nil {
+		return config.Config{}, err
+	}
+	applyToggleFlags(&cfg, p.toggles)
+	if !cfg.EnableSsh {
+		cfg.SshKeyPath = ""
+	}
 
-func buildConfig(projectPath string, p *parsedFlags) (config.Config, error) {
-	cfg := config.New("dev", config.ShellBash)
-	cfg.ProjectHostPath = projectPath
+	// Packages
+	validPackages, invalidPackages := filterValidPackages(p.packages)
+	if len(invalidPackages) > 0 {
+		return config.Config{}, fmt.Errorf("invalid package list: %s", strings.Join(invalidPackages, " "))
+	}
+	if len(p.packages) > 0 {
+		cfg.Packages = validPackages
+	}
+
+	// Project name
+	name := p.name
+	if name == "" && m != nil && m.Name != nil {
+		name = *m.Name
+	}
+	if name == "" {
+		name = filepath.Base(projectPath)
+	} else {
+		err := utils.ValidateProjectName(name)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("invalid set project name '%s': %w", name, err)
+		}
+	}
+	projectName, err := utils.SanitizeProjectName(name)
+	if err != nil {
+		return config.Config{}, fmt.Errorf("did not succeed to sanitize project name '%s': %w", name, err)
+	}
+	cfg.ProjectName = projectName
+	cfg.ProjectDestPath = projectName
 
-	// Set values from flags with validation
+	// Ports and volumes
+	validPorts, invalidPorts := filterValidPorts(p.ports)
+	if len(invalidPorts) > 0 {
+		return config.Config{}, fmt.Errorf("invalid port list: %s", strings.Join(invalidPorts, " "))
+	}
+	if len(p.ports) > 0 {
+		cfg.Ports = validPorts
+	}
+
+	// TODO: sanitization?
+	if len(p.volumes) > 0 {
+		cfg.Volumes = p.volumes
+	}
+
+	return cfg, nil
+}
+
+// Set on `cfg` the values of the flags describing a project's configuration
+// which take a value (uid, shell, language versions...), after validating
+// them. Flags which have not been set are ignored.
+func applyValueFlags(cfg *config.Config, p *parsedFlags) error {
 	if p.uid != "" {
 		if err := utils.ValidateUIDGID(p.uid); err != nil {
-			return config.Config{}, fmt.Errorf("invalid UID '%s': %w", p.uid, err)
+			return fmt.Errorf("invalid UID '%s': %w", p.uid, err)
 		}
 		cfg.UID = p.uid
 	}
 	if p.gid != "" {
 		if err := utils.ValidateUIDGID(p.gid); err != nil {
-			return config.Config{}, fmt.Errorf("invalid GID '%s': %w", p.gid, err)
+			return fmt.Errorf("invalid GID '%s': %w", p.gid, err)
 		}
 		cfg.GID = p.gid
 	}
 	if p.username != "" {
 		if err := utils.ValidateUsername(p.username); err != nil {
-			return config.Config{}, fmt.Errorf("invalid username '%s': %w", p.gid, err)
+			return fmt.Errorf("invalid username '%s': %w", p.gid, err)
 		}
 		cfg.Username = p.username
 	}
 	if p.shell != "" {
 		shell, err := parseShell(p.shell)
 		if err != nil {
-			return config.Config{}, fmt.Errorf("invalid shell '%s': %w", p.gid, err)
+			return fmt.Errorf("invalid shell '%s': %w", p.gid, err)
 		}
 		cfg.Shell = shell
 	}
@@ -178,89 +362,78 @@ func buildConfig(projectPath string, p *parsedFlags) (config.Config, error) {
 	// Language versions
 	if p.nodeVersion != "" {
 		if err := utils.ValidateVersionArg(p.nodeVersion); err != nil {
-			return config.Config{}, fmt.Errorf("invalid node version '%s': %w", p.nodeVersion, err)
+			return fmt.Errorf("invalid node version '%s': %w", p.nodeVersion, err)
 		}
 		cfg.InstallNode = p.nodeVersion
 	}
 	if p.rustVersion != "" {
 		if err := utils.ValidateVersionArg(p.rustVersion); err != nil {
-			return config.Config{}, fmt.Errorf("invalid rust version '%s': %w", p.rustVersion, err)
+			return fmt.Errorf("invalid rust version '%s': %w", p.rustVersion, err)
 		}
 		cfg.InstallRust = p.rustVersion
 	}
 	if p.pythonVersion != "" {
 		if err := utils.ValidateVersionArg(p.pythonVersion); err != nil {
-			return config.Config{}, fmt.Errorf("invalid python version '%s': %w", p.pythonVersion, err)
+			return fmt.Errorf("invalid python version '%s': %w", p.pythonVersion, err)
 		}
 		cfg.InstallPython = p.pythonVersion
 	}
 	if p.goVersion != "" {
 		if err := utils.ValidateVersionArg(p.goVersion); err != nil {
-			return config.Config{}, fmt.Errorf("invalid go version '%s': %w", p.goVersion, err)
+			return fmt.Errorf("invalid go version '%s': %w", p.goVersion, err)
 		}
 		cfg.InstallGo = p.goVersion
 	}
 
-	cfg.EnableWasm = p.enableWasm
-	cfg.EnableSsh = p.enableSsh
-	cfg.EnableSudo = p.enableSudo
-
 	// Git config
 	if p.gitName != "" {
 		if err := utils.ValidateGitName(p.gitName); err != nil {
-			return config.Config{}, fmt.Errorf("invalid git name '%s': %w", p.gitName, err)
+			return fmt.Errorf("invalid git name '%s': %w", p.gitName, err)
 		}
 		cfg.GitName = p.gitName
 	}
 	if p.gitEmail != "" {
 		if err := utils.ValidateGitEmail(p.gitEmail); err != nil {
-			return config.Config{}, fmt.Errorf("invalid git e-mail '%s': %w", p.gitEmail, err)
+			return fmt.Errorf("invalid git e-mail '%s': %w", p.gitEmail, err)
 		}
 		cfg.GitEmail = p.gitEmail
 	}
 
-	// Packages
-	validPackages, invalidPackages := filterValidPackages(p.packages)
-	if len(invalidPackages) > 0 {
-		return config.Config{}, fmt.Errorf("invalid package list: %s", strings.Join(invalidPackages, " "))
+	// Package cache
+	if p.cache != "" {
+		if err := config.ValidateCache(p.cache); err != nil {
+			return err
+		}
+		cfg.Cache = p.cache
+		if p.cache == config.CacheShared {
+			cfg.Cache = ""
+		}
 	}
-	cfg.Packages = validPackages
-
-	// Tools
-	cfg.InstallNeovim = p.installNeovim
-	cfg.InstallStarship = p.installStarship
-	cfg.InstallAtuin = p.installAtuin
-	cfg.InstallMise = p.installMise
-	cfg.InstallZellij = p.installZellij
-	cfg.InstallJujutsu = p.installJujutsu
+	return nil
+}
 
-	// Project name
-	if p.name == "" {
-		p.name = filepath.Base(projectPath)
-	} else {
-		err := utils.ValidateProjectName(p.name)
+// Read and parse the presets with the given names, in the same order.
+func loadPresets(names []string, filestor *files.FileStore) ([]*manifest.Manifest, error) {
+	presets := make([]*manifest.Manifest, 0, len(names))
+	for _, name := range names {
+		// Preset names are part of a path: prevent them from escaping their directory
+		if err := utils.ValidateProjectName(name); err != nil {
+			return nil, fmt.Errorf("invalid preset name '%s': %w", name, err)
+		}
+		content, err := filestor.ReadPreset(name)
 		if err != nil {
-			return config.Config{}, fmt.Errorf("invalid set project name '%s': %w", p.name, err)
+			if errors.Is(err, files.ErrPresetNotFound) {
+				return nil, fmt.Errorf("unknown preset '%s'. Hint: Use 'paul-envs preset list' to see all presets", name)
+			}
+			return nil, err
 		}
+		preset, err := manifest.Parse(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset '%s' (%s): %w", name, filestor.GetPresetFilePath(name), err)
+		}
+		presets = append(presets, preset)
 	}
-	projectName, err := utils.SanitizeProjectName(p.name)
-	if err != nil {
-		return config.Config{}, fmt.Errorf("did not succeed to sanitize project name '%s': %w", p.name, err)
-	}
-	cfg.ProjectName = projectName
-	cfg.ProjectDestPath = projectName
-
-	// Ports and volumes
-	validPorts, invalidPorts := filterValidPorts(p.ports)
-	if len(invalidPorts) > 0 {
-		return config.Config{}, fmt.Errorf("invalid port list: %s", strings.Join(invalidPorts, " "))
-	}
-	cfg.Ports = validPorts
-
-	// TODO: sanitization?
-	cfg.Volumes = p.volumes
-
-	return cfg, nil
+	return presets, nil
 }
 
 func validateProjectName(name string, filestor *files.FileStore, cons *console.Console) error {
@@ -274,9 +447,13 @@ func validateProjectName(name string, filestor *files.FileStore, cons *console.C
 	return nil
 }
 
-func promptMissing(cons *console.Console, cfg *config.Config) error {
+// Prompt for the values not in `given`, the configuration explicitly given
+// for this project, nor in `toggles`, the boolean options explicitly enabled or
+// disabled by flags, and set them on `cfg`. The values already in `cfg` are
+// proposed as default answers.
+func promptMissing(cons *console.Console, cfg *config.Config, given *config.Config, toggles map[string]bool) error {
 	// Shell
-	if cfg.Shell == config.ShellBash {
+	if given.Shell == config.ShellBash {
 		cons.WriteLn("")
 		if err := promptShell(cons, cfg); err != nil {
 			return err
@@ -284,7 +461,7 @@ func promptMissing(cons *console.Console, cfg *config.Config) error {
 	}
 
 	// Languages
-	if !hasAnyLanguage(cfg) {
+	if !hasAnyLanguage(given) {
 		cons.WriteLn("")
 		if err := promptLanguages(cons, cfg); err != nil {
 			return err
@@ -306,7 +483,7 @@ func promptMissing(cons *console.Console, cfg *config.Config) error {
 	}
 
 	// Tools
-	if !hasAnyTool(cfg) {
+	if !hasAnyTool(given) && !hasAnyToolToggle(toggles) {
 		cons.WriteLn("")
 		if err := promptTools(cons, cfg); err != nil {
 			return err
@@ -314,7 +491,7 @@ func promptMissing(cons *console.Console, cfg *config.Config) error {
 	}
 
 	// Sudo
-	if !cfg.EnableSudo {
+	if _, toggled := toggles["sudo"]; !given.EnableSudo && !toggled {
 		cons.WriteLn("")
 		if err := promptSudo(cons, cfg); err != nil {
 			return err
@@ -322,7 +499,7 @@ func promptMissing(cons *console.Console, cfg *config.Config) error {
 	}
 
 	// SSH
-	if !cfg.EnableSsh {
+	if _, toggled := toggles["ssh"]; !given.EnableSsh && !toggled {
 		cons.WriteLn("")
 		if err := promptSSH(cons, cfg); err != nil {
 			return err
@@ -330,9 +507,9 @@ func promptMissing(cons *console.Console, cfg *config.Config) error {
 	}
 
 	// Packages
-	if len(cfg.Packages) == 0 {
+	if len(given.Packages) == 0 {
 		cons.WriteLn("")
-		packages, err := promptPackages(cons)
+		packages, err := promptPackages(cons, cfg.Packages)
 		if err != nil {
 			return err
 		}
@@ -340,9 +517,9 @@ func promptMissing(cons *console.Console, cfg *config.Config) error {
 	}
 
 	// Ports
-	if len(cfg.Ports) == 0 {
+	if len(given.Ports) == 0 {
 		cons.WriteLn("")
-		ports, err := promptPorts(cons)
+		ports, err := promptPorts(cons, cfg.Ports)
 		if err != nil {
 			return err
 		}
@@ -350,9 +527,9 @@ func promptMissing(cons *console.Console, cfg *config.Config) error {
 	}
 
 	// Volumes
-	if len(cfg.Volumes) == 0 {
+	if len(given.Volumes) == 0 {
 		cons.WriteLn("")
-		volumes, err := promptVolumes(cons)
+		volumes, err := promptVolumes(cons, cfg.Volumes)
 		if err != nil {
 			return err
 		}
@@ -440,6 +617,16 @@ func hasAnyTool(cfg *config.Config) bool {
 		cfg.InstallZellij || cfg.InstallJujutsu
 }
 
+// Returns true if a tool has explicitly been enabled or disabled by a flag.
+func hasAnyToolToggle(toggles map[string]bool) bool {
+	for _, tool := range []string{"neovim", "starship", "atuin", "mise", "zellij", "jujutsu"} {
+		if _, ok := toggles[tool]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func needsExactVersion(version string) bool {
 	return version != "" && version != config.VersionNone && version != config.VersionLatest
 }
@@ -448,14 +635,21 @@ func needsExactVersion(version string) bool {
 
 // TODO: Return Shell instead of filling Config itself?
 func promptShell(cons *console.Console, cfg *config.Config) error {
+	shells := []config.Shell{config.ShellBash, config.ShellZsh, config.ShellFish}
+	defaultChoice := "1"
 	for {
 		cons.Info("=== Shell Selection ===")
 		cons.WriteLn("Select shell:")
-		cons.WriteLn("  1) bash (default)")
-		cons.WriteLn("  2) zsh")
-		cons.WriteLn("  3) fish")
+		for i, shell := range shells {
+			if shell == cfg.Shell {
+				defaultChoice = strconv.Itoa(i + 1)
+				cons.WriteLn("  %d) %s (default)", i+1, shell)
+			} else {
+				cons.WriteLn("  %d) %s", i+1, shell)
+			}
+		}
 
-		choice, err := cons.AskString("Choice", "1")
+		choice, err := cons.AskString("Choice", defaultChoice)
 		if err != nil {
 			return fmt.Errorf("unable to prompt for shell choice: %w", err)
 		}
@@ -480,6 +674,34 @@ func promptShell(cons *console.Console, cfg *config.Config) error {
 
 // TODO: Return languages instead through a new type?
 func promptLanguages(cons *console.Console, cfg *config.Config) error {
+	// Languages already set (by the user's defaults) are selected by default,
+	// with the same version
+	defaultVersions := map[string]string{
+		"1": cfg.InstallNode,
+		"2": cfg.InstallRust,
+		"3": cfg.InstallPython,
+		"4": cfg.InstallGo,
+	}
+	var defaultChoices []string
+	for _, choice := range []string{"1", "2", "3", "4"} {
+		if version := defaultVersions[choice]; version != "" && version != config.VersionNone {
+			defaultChoices = append(defaultChoices, choice)
+		} else {
+			defaultVersions[choice] = config.VersionLatest
+		}
+	}
+	if cfg.EnableWasm {
+		defaultChoices = append(defaultChoices, "5")
+	}
+	if len(defaultChoices) == 0 {
+		defaultChoices = []string{"none"}
+	}
+	cfg.InstallNode = ""
+	cfg.InstallRust = ""
+	cfg.InstallPython = ""
+	cfg.InstallGo = ""
+	cfg.EnableWasm = false
+
 	for {
 		cons.Info("=== Language Runtimes ===")
 		cons.WriteLn("Which language runtimes do you need? (space-separated numbers, or Enter to skip)")
@@ -489,7 +711,7 @@ func promptLanguages(cons *console.Console, cfg *config.Config) error {
 		cons.WriteLn("  4) Go")
 		cons.WriteLn("  5) WebAssembly tools (Binaryen, Rust WASM target if Rust is enabled)")
 
-		choices, err := cons.AskString("Choice", "none")
+		choices, err := cons.AskString("Choice", strings.Join(defaultChoices, " "))
 		if err != nil {
 			return fmt.Errorf("unable to prompt for language choice: %w", err)
 		}
@@ -501,7 +723,7 @@ func promptLanguages(cons *console.Console, cfg *config.Config) error {
 		for choice := range selectedChoices {
 			switch choice {
 			case "1":
-				ver, err := cons.AskString("Node.js version (latest/none/X.Y.Z)", config.VersionLatest)
+				ver, err := cons.AskString("Node.js version (latest/none/X.Y.Z)", defaultVersions["1"])
 				if err != nil {
 					return fmt.Errorf("unable to prompt for Node.js version: %w", err)
 				}
@@ -513,7 +735,7 @@ func promptLanguages(cons *console.Console, cfg *config.Config) error {
 				}
 				cfg.InstallNode = ver
 			case "2":
-				ver, err := cons.AskString("Rust version (latest/none/X.Y.Z)", config.VersionLatest)
+				ver, err := cons.AskString("Rust version (latest/none/X.Y.Z)", defaultVersions["2"])
 				if err != nil {
 					return fmt.Errorf("unable to prompt for Rust version: %w", err)
 				}
@@ -525,7 +747,7 @@ func promptLanguages(cons *console.Console, cfg *config.Config) error {
 				}
 				cfg.InstallRust = ver
 			case "3":
-				ver, err := cons.AskString("Python version (latest/none/X.Y.Z)", config.VersionLatest)
+				ver, err := cons.AskString("Python version (latest/none/X.Y.Z)", defaultVersions["3"])
 				if err != nil {
 					return fmt.Errorf("unable to prompt for Python version: %w", err)
 				}
@@ -537,7 +759,7 @@ func promptLanguages(cons *console.Console, cfg *config.Config) error {
 				}
 				cfg.InstallPython = ver
 			case "4":
-				ver, err := cons.AskString("Go version (latest/none/X.Y.Z)", config.VersionLatest)
+				ver, err := cons.AskString("Go version (latest/none/X.Y.Z)", defaultVersions["4"])
 				if err != nil {
 					return fmt.Errorf("unable to prompt for Go version: %w", err)
 				}
@@ -576,6 +798,24 @@ func promptLanguages(cons *console.Console, cfg *config.Config) error {
 
 // TODO: Return tools instead through a new type?
 func promptTools(cons *console.Console, cfg *config.Config) error {
+	// Tools already enabled (by the user's defaults) are selected by default
+	var defaultChoices []string
+	for i, enabled := range []bool{cfg.InstallNeovim, cfg.InstallStarship, cfg.InstallAtuin,
+		cfg.InstallMise, cfg.InstallZellij, cfg.InstallJujutsu} {
+		if enabled {
+			defaultChoices = append(defaultChoices, strconv.Itoa(i+1))
+		}
+	}
+	if len(defaultChoices) == 0 {
+		defaultChoices = []string{"none"}
+	}
+	cfg.InstallNeovim = false
+	cfg.InstallStarship = false
+	cfg.InstallAtuin = false
+	cfg.InstallMise = false
+	cfg.InstallZellij = false
+	cfg.InstallJujutsu = false
+
 	for {
 		cons.Info("=== Development Tools ===")
 		cons.WriteLn("Some dev tools are not pulled from Ubuntu's repositories to get their latest version instead.")
@@ -587,7 +827,7 @@ func promptTools(cons *console.Console, cfg *config.Config) error {
 		cons.WriteLn("  5) Zellij (terminal multiplexer)")
 		cons.WriteLn("  6) Jujutsu (Git-compatible VCS)")
 
-		choices, err := cons.AskString("Choice", "none")
+		choices, err := cons.AskString("Choice", strings.Join(defaultChoices, " "))
 		if err != nil {
 			return fmt.Errorf("unable to prompt for tools choice: %w", err)
 		}
@@ -637,7 +877,7 @@ func promptTools(cons *console.Console, cfg *config.Config) error {
 // TODO: Return bool instead of filling Config itself?
 func promptSudo(cons *console.Console, cfg *config.Config) error {
 	cons.Info("=== Sudo Access ===")
-	val, err := cons.AskYesNo("Enable sudo access in container (password:\"dev\")?", false)
+	val, err := cons.AskYesNo("Enable sudo access in container (password:\"dev\")?", cfg.EnableSudo)
 	if err != nil {
 		return fmt.Errorf("unable to prompt for sudo choice: %w", err)
 	}
@@ -648,13 +888,15 @@ func promptSudo(cons *console.Console, cfg *config.Config) error {
 // TODO: Return string instead of filling Config itself?
 func promptSSH(cons *console.Console, cfg *config.Config) error {
 	cons.Info("=== SSH Access ===")
-	val, err := cons.AskYesNo("Enable ssh access to container?", false)
+	val, err := cons.AskYesNo("Enable ssh access to container?", cfg.EnableSsh)
 	if err != nil {
 		return fmt.Errorf("unable to prompt for SSH choice: %w", err)
 	}
 	cfg.EnableSsh = val
+	defaultKeyPath := cfg.SshKeyPath
+	cfg.SshKeyPath = ""
 	if val {
-		sshKeyPath, err := promptSSHKeys(cons)
+		sshKeyPath, err := promptSSHKeys(cons, defaultKeyPath)
 		if err != nil {
 			cons.Warn("Failed to configure SSH key: %v", err)
 		} else if sshKeyPath != "" {
@@ -664,14 +906,28 @@ func promptSSH(cons *console.Console, cfg *config.Config) error {
 	return nil
 }
 
-func promptSSHKeys(cons *console.Console) (string, error) {
+// Prompt for the SSH public key to mount, `defaultKeyPath` being proposed as
+// the default choice if not empty.
+func promptSSHKeys(cons *console.Console, defaultKeyPath string) (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("unable to obtain home dir: %w", err)
 	}
 
 	pubKeys, err := filepath.Glob(filepath.Join(homeDir, ".ssh", "*.pub"))
-	if err != nil || len(pubKeys) == 0 {
+	if err != nil {
+		pubKeys = nil
+	}
+	defaultChoice := ""
+	if defaultKeyPath != "" {
+		idx := slices.Index(pubKeys, defaultKeyPath)
+		if idx < 0 {
+			pubKeys = append([]string{defaultKeyPath}, pubKeys...)
+			idx = 0
+		}
+		defaultChoice = strconv.Itoa(idx + 1)
+	}
+	if len(pubKeys) == 0 {
 		cons.Warn("No SSH public keys found in ~/.ssh/")
 		return "", errors.New("no ssh public key found")
 	}
@@ -685,7 +941,7 @@ func promptSSHKeys(cons *console.Console) (string, error) {
 		cons.WriteLn("  %d) Custom path", len(pubKeys)+1)
 		cons.WriteLn("  %d) Skip (add manually later)", len(pubKeys)+2)
 
-		choice, err := cons.AskString("Choice", "")
+		choice, err := cons.AskString("Choice", defaultChoice)
 		if err != nil {
 			return "", fmt.Errorf("unable to prompt for SSH key choice: %w", err)
 		}
@@ -717,19 +973,27 @@ func promptSSHKeys(cons *console.Console) (string, error) {
 	}
 }
 
-func promptPackages(cons *console.Console) ([]string, error) {
+// Prompt for supplementary packages, `defaults` being the default answer.
+func promptPackages(cons *console.Console, defaults []string) ([]string, error) {
 	for {
 		cons.Info("=== Additional Packages ===")
 		cons.WriteLn("The following packages are already installed on top of an Ubuntu:24.04 image:")
 		cons.WriteLn("curl git build-essential")
 		cons.WriteLn("")
-		cons.WriteLn("Enter additional Ubuntu packages (space-separated, or Enter to skip):")
+		if len(defaults) == 0 {
+			cons.WriteLn("Enter additional Ubuntu packages (space-separated, or Enter to skip):")
+		} else {
+			cons.WriteLn("Enter additional Ubuntu packages (space-separated, Enter for the default ones or \"none\"):")
+		}
 		cons.WriteLn("Examples: ripgrep fzf htop")
 
-		input, err := cons.AskString("Packages", "")
+		input, err := cons.AskString("Packages", strings.Join(defaults, " "))
 		if err != nil {
 			return nil, fmt.Errorf("unable to prompt for packages: %w", err)
 		}
+		if input == "none" {
+			return []string{}, nil
+		}
 
 		packages := strings.Fields(input)
 		validPackages, invalidPackages := filterValidPackages(packages)
@@ -743,16 +1007,28 @@ func promptPackages(cons *console.Console) ([]string, error) {
 	}
 }
 
-func promptPorts(cons *console.Console) ([]uint16, error) {
+// Prompt for ports to expose, `defaults` being the default answer.
+func promptPorts(cons *console.Console, defaults []uint16) ([]uint16, error) {
+	defaultPorts := make([]string, 0, len(defaults))
+	for _, port := range defaults {
+		defaultPorts = append(defaultPorts, strconv.Itoa(int(port)))
+	}
 	for {
 		cons.Info("=== Port Forwarding ===")
-		cons.WriteLn("Enter supplementary container ports to expose (space-separated, or Enter to skip):")
+		if len(defaults) == 0 {
+			cons.WriteLn("Enter supplementary container ports to expose (space-separated, or Enter to skip):")
+		} else {
+			cons.WriteLn("Enter supplementary container ports to expose (space-separated, Enter for the default ones or \"none\"):")
+		}
 		cons.WriteLn("Examples: 3000 5432 8080")
 
-		input, err := cons.AskString("Ports", "")
+		input, err := cons.AskString("Ports", strings.Join(defaultPorts, " "))
 		if err != nil {
 			return nil, fmt.Errorf("unable to prompt for ports: %w", err)
 		}
+		if input == "none" {
+			return []uint16{}, nil
+		}
 
 		ports := strings.Fields(input)
 		validPorts, invalidPorts := filterValidPorts(ports)
@@ -766,8 +1042,24 @@ func promptPorts(cons *console.Console) ([]uint16, error) {
 	}
 }
 
-func promptVolumes(cons *console.Console) ([]string, error) {
+// Prompt for volumes to mount, proposing to keep the `defaults` ones first.
+func promptVolumes(cons *console.Console, defaults []string) ([]string, error) {
 	cons.Info("=== Credentials & Volumes ===")
+	var volumes []string
+	if len(defaults) > 0 {
+		cons.WriteLn("Default volumes:")
+		for _, volume := range defaults {
+			cons.WriteLn("  - %s", volume)
+		}
+		keep, err := cons.AskYesNo("Mount those default volumes?", true)
+		if err != nil {
+			return nil, fmt.Errorf("unable to prompt for default volumes: %w", err)
+		}
+		if keep {
+			volumes = append(volumes, defaults...)
+		}
+		cons.WriteLn("")
+	}
 	cons.WriteLn("Mount common credentials/configs? (space-separated numbers, or Enter to skip)")
 	cons.WriteLn("  1) SSH keys (~/.ssh)")
 	cons.WriteLn("  2) Git credentials (~/.git-credentials)")
@@ -784,7 +1076,6 @@ func promptVolumes(cons *console.Console) ([]string, error) {
 		return nil, fmt.Errorf("unable to obtain home dir: %w", err)
 	}
 
-	var volumes []string
 	for choice := range strings.FieldsSeq(choices) {
 		switch choice {
 		case "1":
@@ -0,0 +1,171 @@
+package args
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// A user-wide default value for the configuration of new projects.
+//
+// Those are set through the `config` command and stored in paul-envs' global
+// configuration file. `create` applies them before the project's manifest and
+// flags, and proposes them as the default answer of its prompts.
+type UserDefault struct {
+	// Name used in the `config` command, e.g. "default.shell"
+	Name string
+	// Name with which it is stored in the global configuration file
+	FileKey string
+	// Short description displayed by `config list`
+	Description string
+	// Set `value` on `cfg`, returning an error if it is invalid
+	apply func(cfg *config.Config, value string) error
+}
+
+// Returns an error if `value` cannot be set for this default.
+func (d UserDefault) Validate(value string) error {
+	var cfg config.Config
+	return d.apply(&cfg, value)
+}
+
+// All defaults which can be set, one per `create` flag describing a project's
+// configuration.
+var UserDefaults = []UserDefault{
+	stringDefault("uid", "Container UID", utils.ValidateUIDGID,
+		func(c *config.Config) *string { return &c.UID }),
+	stringDefault("gid", "Container GID", utils.ValidateUIDGID,
+		func(c *config.Config) *string { return &c.GID }),
+	stringDefault("username", "Container username", utils.ValidateUsername,
+		func(c *config.Config) *string { return &c.Username }),
+	newUserDefault("shell", "User shell: bash, zsh or fish", func(c *config.Config, value string) error {
+		shell, err := parseShell(value)
+		if err != nil {
+			return err
+		}
+		c.Shell = shell
+		return nil
+	}),
+	stringDefault("nodejs", "Node.js installation: none, latest or a version", utils.ValidateVersionArg,
+		func(c *config.Config) *string { return &c.InstallNode }),
+	stringDefault("rust", "Rust installation: none, latest or a version", utils.ValidateVersionArg,
+		func(c *config.Config) *string { return &c.InstallRust }),
+	stringDefault("python", "Python installation: none, latest or a version", utils.ValidateVersionArg,
+		func(c *config.Config) *string { return &c.InstallPython }),
+	stringDefault("go", "Go installation: none, latest or a version", utils.ValidateVersionArg,
+		func(c *config.Config) *string { return &c.InstallGo }),
+	boolDefault("wasm", "Add WebAssembly tools: true or false",
+		func(c *config.Config) *bool { return &c.EnableWasm }),
+	boolDefault("ssh", "Enable ssh access: true or false",
+		func(c *config.Config) *bool { return &c.EnableSsh }),
+	stringDefault("ssh-key", "Path to the SSH public key allowed to connect", validateNotEmpty,
+		func(c *config.Config) *string { return &c.SshKeyPath }),
+	boolDefault("sudo", "Enable sudo access: true or false",
+		func(c *config.Config) *bool { return &c.EnableSudo }),
+	stringDefault("git-name", "Git user.name", utils.ValidateGitName,
+		func(c *config.Config) *string { return &c.GitName }),
+	stringDefault("git-email", "Git user.email", utils.ValidateGitEmail,
+		func(c *config.Config) *string { return &c.GitEmail }),
+	newUserDefault("cache", "Package cache: shared, private or a cache group name", func(c *config.Config, value string) error {
+		if err := config.ValidateCache(value); err != nil {
+			return err
+		}
+		if value != config.CacheShared {
+			c.Cache = value
+		}
+		return nil
+	}),
+	boolDefault("neovim", "Install Neovim: true or false",
+		func(c *config.Config) *bool { return &c.InstallNeovim }),
+	boolDefault("starship", "Install Starship: true or false",
+		func(c *config.Config) *bool { return &c.InstallStarship }),
+	boolDefault("atuin", "Install Atuin: true or false",
+		func(c *config.Config) *bool { return &c.InstallAtuin }),
+	boolDefault("mise", "Install Mise: true or false",
+		func(c *config.Config) *bool { return &c.InstallMise }),
+	boolDefault("zellij", "Install Zellij: true or false",
+		func(c *config.Config) *bool { return &c.InstallZellij }),
+	boolDefault("jujutsu", "Install Jujutsu: true or false",
+		func(c *config.Config) *bool { return &c.InstallJujutsu }),
+	newUserDefault("packages", "Additional Ubuntu packages, space-separated", func(c *config.Config, value string) error {
+		packages, invalid := filterValidPackages(strings.Fields(value))
+		if len(invalid) > 0 {
+			return fmt.Errorf("invalid package list: %s", strings.Join(invalid, " "))
+		}
+		c.Packages = packages
+		return nil
+	}),
+	newUserDefault("ports", "Exposed container ports, space-separated", func(c *config.Config, value string) error {
+		ports, invalid := filterValidPorts(strings.Fields(value))
+		if len(invalid) > 0 {
+			return fmt.Errorf("invalid port list: %s", strings.Join(invalid, " "))
+		}
+		c.Ports = ports
+		return nil
+	}),
+	newUserDefault("volumes", "Mounted volumes (HOST:CONT[:ro]), comma-separated", func(c *config.Config, value string) error {
+		volumes := []string{}
+		for volume := range strings.SplitSeq(value, ",") {
+			if volume = strings.TrimSpace(volume); volume != "" {
+				volumes = append(volumes, volume)
+			}
+		}
+		c.Volumes = volumes
+		return nil
+	}),
+}
+
+// ApplyUserDefaults applies to `cfg` the defaults set in the given values of
+// the global configuration file.
+func ApplyUserDefaults(cfg *config.Config, globalConfig map[string]string) error {
+	for _, def := range UserDefaults {
+		value, ok := globalConfig[def.FileKey]
+		if !ok {
+			continue
+		}
+		if err := def.apply(cfg, value); err != nil {
+			return fmt.Errorf("invalid value for '%s': %w", def.Name, err)
+		}
+	}
+	return nil
+}
+
+// Create the `UserDefault` for the given `create` flag name (e.g. "git-name").
+func newUserDefault(flagName string, description string, apply func(*config.Config, string) error) UserDefault {
+	return UserDefault{
+		Name:        "default." + flagName,
+		FileKey:     "DEFAULT_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_")),
+		Description: description,
+		apply:       apply,
+	}
+}
+
+func stringDefault(flagName string, description string, validate func(string) error, field func(*config.Config) *string) UserDefault {
+	return newUserDefault(flagName, description, func(c *config.Config, value string) error {
+		if err := validate(value); err != nil {
+			return err
+		}
+		*field(c) = value
+		return nil
+	})
+}
+
+func boolDefault(flagName string, description string, field func(*config.Config) *bool) UserDefault {
+	return newUserDefault(flagName, description, func(c *config.Config, value string) error {
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("'%s' should be either true or false", value)
+		}
+		*field(c) = val
+		return nil
+	})
+}
+
+func validateNotEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("value cannot be empty")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package args
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// ParseEditFlags applies the flags given to the `edit` command to the current
+// configuration of a project, `cfg`, and returns the updated configuration.
+//
+// It accepts the same flags as `create` (except `--name` and `--no-prompt`),
+// only updating what has been explicitly set, plus:
+//   - `--remove-package`, `--remove-port` and `--remove-volume` to remove an
+//     entry, `--package`, `--port` and `--volume` adding one
+//   - `--force`, whose value is also returned, to not ask for confirmation
+func ParseEditFlags(args []string, cfg config.Config) (config.Config, bool, error) {
+	var force bool
+	p := &parsedFlags{}
+	var removedPackages, removedPorts, removedVolumes []string
+
+	flagset := flag.NewFlagSet("edit", flag.ContinueOnError)
+	flagset.BoolVar(&force, "force", false, "Do not ask for confirmation")
+	defineConfigFlags(flagset, p)
+
+	filtered := extractRepeatableFlags(args, map[string]*[]string{
+		"--port":           &p.ports,
+		"--volume":         &p.volumes,
+		"--package":        &p.packages,
+		"--remove-port":    &removedPorts,
+		"--remove-volume":  &removedVolumes,
+		"--remove-package": &removedPackages,
+	})
+	if err := flagset.Parse(filtered); err != nil {
+		return config.Config{}, false, err
+	}
+	if flagset.NArg() > 0 {
+		return config.Config{}, false, fmt.Errorf("unexpected argument '%s'", flagset.Arg(0))
+	}
+	toggles, err := parseToggleFlags(flagset)
+	if err != nil {
+		return config.Config{}, false, err
+	}
+
+	if err := applyValueFlags(&cfg, p); err != nil {
+		return config.Config{}, false, err
+	}
+	applyToggleFlags(&cfg, toggles)
+	if !cfg.EnableSsh {
+		cfg.SshKeyPath = ""
+	}
+
+	// Packages
+	validPackages, invalidPackages := filterValidPackages(p.packages)
+	if len(invalidPackages) > 0 {
+		return config.Config{}, false, fmt.Errorf("invalid package list: %s", strings.Join(invalidPackages, " "))
+	}
+	for _, pkg := range removedPackages {
+		idx := slices.Index(cfg.Packages, pkg)
+		if idx < 0 {
+			return config.Config{}, false, fmt.Errorf("cannot remove package '%s': it is not part of this project", pkg)
+		}
+		cfg.Packages = slices.Delete(slices.Clone(cfg.Packages), idx, idx+1)
+	}
+	for _, pkg := range validPackages {
+		if !slices.Contains(cfg.Packages, pkg) {
+			cfg.Packages = append(cfg.Packages, pkg)
+		}
+	}
+
+	// Ports
+	validPorts, invalidPorts := filterValidPorts(p.ports)
+	if len(invalidPorts) > 0 {
+		return config.Config{}, false, fmt.Errorf("invalid port list: %s", strings.Join(invalidPorts, " "))
+	}
+	for _, portStr := range removedPorts {
+		port, err := strconv.Atoi(portStr)
+		idx := slices.Index(cfg.Ports, uint16(port))
+		if err != nil || idx < 0 {
+			return config.Config{}, false, fmt.Errorf("cannot remove port '%s': it is not exposed by this project", portStr)
+		}
+		cfg.Ports = slices.Delete(slices.Clone(cfg.Ports), idx, idx+1)
+	}
+	for _, port := range validPorts {
+		if !slices.Contains(cfg.Ports, port) {
+			cfg.Ports = append(cfg.Ports, port)
+		}
+	}
+
+	// Volumes
+	for _, volume := range removedVolumes {
+		idx := slices.Index(cfg.Volumes, volume)
+		if idx < 0 {
+			return config.Config{}, false, fmt.Errorf("cannot remove volume '%s': it is not mounted by this project", volume)
+		}
+		cfg.Volumes = slices.Delete(slices.Clone(cfg.Volumes), idx, idx+1)
+	}
+	for _, volume := range p.volumes {
+		if !slices.Contains(cfg.Volumes, volume) {
+			cfg.Volumes = append(cfg.Volumes, volume)
+		}
+	}
+
+	return cfg, force, nil
+}
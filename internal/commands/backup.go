@@ -0,0 +1,290 @@
+package commands
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func Backup(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var outputPath string
+	var force bool
+	flagset := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flagset.StringVar(&outputPath, "o", "", "Path of the written backup")
+	flagset.BoolVar(&force, "force", false, "Replace an existing file without asking")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) > 1 {
+		return errors.New("usage: paul-envs backup <name> [-o <file>]")
+	}
+	project, err := getExistingProject(positionals, filestore, console, "back up")
+	if err != nil {
+		return err
+	}
+	name := project.ProjectName
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+	volume, err := findProjectVolume(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if volume == nil {
+		return fmt.Errorf("project '%s' has no local volume to back up\nHint: It is created the first time the project is run", name)
+	}
+	container, err := findRunningContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container != nil {
+		console.Warn("The container of project '%s' is running, its volume may be backed up in an inconsistent state.", name)
+	}
+	if outputPath == "" {
+		if outputPath, err = filestore.NewBackupFilePath(name); err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(outputPath); err == nil && !force {
+		confirm, err := console.AskYesNo(fmt.Sprintf("'%s' already exists. Replace it?", outputPath), false)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			return errors.New("backup aborted by user")
+		}
+	}
+
+	if err := backupVolume(ctx, name, *volume, outputPath, containerEngine, console); err != nil {
+		return err
+	}
+	console.WriteLn("Restore it with:")
+	console.WriteLn("  paul-envs restore %s %s", name, outputPath)
+	return nil
+}
+
+func Restore(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var force bool
+	flagset := flag.NewFlagSet("restore", flag.ContinueOnError)
+	flagset.BoolVar(&force, "force", false, "Replace the existing volume without asking")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 2 {
+		return errors.New("usage: paul-envs restore <name> <file> [--force]")
+	}
+	project, err := getExistingProject(positionals[:1], filestore, console, "restore")
+	if err != nil {
+		return err
+	}
+	name := project.ProjectName
+
+	backupFile, err := os.Open(positionals[1])
+	if err != nil {
+		return fmt.Errorf("could not open backup: %w", err)
+	}
+	defer backupFile.Close()
+	content, err := gzip.NewReader(backupFile)
+	if err != nil {
+		return fmt.Errorf("not a valid backup: %w", err)
+	}
+	defer content.Close()
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+	container, err := findRunningContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container != nil {
+		return fmt.Errorf("the container of project '%s' is running\nHint: Stop it first with 'paul-envs down %s'", name, name)
+	}
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the '%s' project: %w", name, err)
+	}
+	if !hasBeenBuilt {
+		return fmt.Errorf("the image of project '%s' is needed to restore its volume but it has not been built\nHint: Run 'paul-envs build %s' first", name, name)
+	}
+	existingVolume, err := findProjectVolume(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	var labels map[string]string
+	if existingVolume != nil {
+		if !force {
+			confirm, err := console.AskYesNo(
+				fmt.Sprintf("Replace the content of volume '%s' with the backup?", existingVolume.VolumeName), false)
+			if err != nil {
+				return err
+			}
+			if !confirm {
+				return errors.New("restore aborted by user")
+			}
+		}
+		labels = existingVolume.Labels
+	}
+
+	if err := replaceVolume(ctx, name, labels, content, containerEngine, console); err != nil {
+		return err
+	}
+	console.Success("Restored the local volume of project '%s' from '%s'", name, positionals[1])
+	return nil
+}
+
+// Write a gzip-compressed tar archive of the content of `volume`, the local
+// volume of project `projectName`, to `outputPath`.
+func backupVolume(
+	ctx context.Context,
+	projectName string,
+	volume engine.VolumeInfo,
+	outputPath string,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, projectName)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the '%s' project: %w", projectName, err)
+	}
+	if !hasBeenBuilt {
+		return fmt.Errorf("the image of project '%s' is needed to back up its volume but it has not been built", projectName)
+	}
+	console.Info("Backing up volume '%s'...", volume.VolumeName)
+	err = writeToFile(outputPath, func(file *os.File) error {
+		gzw := gzip.NewWriter(file)
+		if err := containerEngine.ExportVolume(ctx, volume.VolumeName, projectName, gzw); err != nil {
+			return err
+		}
+		return gzw.Close()
+	})
+	if err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	console.Success("Backed up volume '%s' to '%s'", volume.VolumeName, outputPath)
+	return nil
+}
+
+// Propose to back up the local volumes of the given projects before they are
+// removed, writing them in the backups directory.
+//
+// Returns an error if a wanted backup could not be performed, in which case
+// nothing should be removed.
+func offerVolumeBackups(
+	ctx context.Context,
+	projectNames []string,
+	filestore *files.FileStore,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	volumes := []engine.VolumeInfo{}
+	for _, name := range projectNames {
+		volume, err := findProjectVolume(ctx, containerEngine, name)
+		if err != nil {
+			return err
+		}
+		if volume == nil {
+			continue
+		}
+		hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get the status of the '%s' project: %w", name, err)
+		}
+		if !hasBeenBuilt {
+			console.Warn("Volume '%s' cannot be backed up: the image of project '%s' is needed and has not been built.", volume.VolumeName, name)
+			continue
+		}
+		volumes = append(volumes, *volume)
+	}
+	if len(volumes) == 0 {
+		return nil
+	}
+	prompt := fmt.Sprintf("Back up volume '%s' first?", volumes[0].VolumeName)
+	if len(volumes) > 1 {
+		prompt = fmt.Sprintf("Back up the local volumes of %d projects first?", len(volumes))
+	}
+	choice, err := console.AskYesNo(prompt, true)
+	if err != nil {
+		return err
+	}
+	if !choice {
+		return nil
+	}
+	for _, volume := range volumes {
+		outputPath, err := filestore.NewBackupFilePath(*volume.ProjectName)
+		if err != nil {
+			return err
+		}
+		if err := backupVolume(ctx, *volume.ProjectName, volume, outputPath, containerEngine, console); err != nil {
+			return fmt.Errorf("%w\nHint: Nothing has been removed", err)
+		}
+	}
+	return nil
+}
+
+// Replace the local volume of project `projectName` by a new one, with the
+// given labels, in which the tar archive read from `content` is extracted.
+//
+// That archive is first extracted in a temporary volume, so the previous
+// volume is only removed once it has been read successfully.
+//
+// The project's container has to be stopped and its image built.
+func replaceVolume(
+	ctx context.Context,
+	projectName string,
+	labels map[string]string,
+	content io.Reader,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	// Not linked to the project, so it is never mistaken for its local volume
+	tmpVolumeName := fmt.Sprintf("paulenv-%s-restoring", projectName)
+	if err := removeNamedVolume(ctx, tmpVolumeName, containerEngine, console); err != nil {
+		return err
+	}
+	console.Info("Extracting the archive in volume '%s'...", tmpVolumeName)
+	err := containerEngine.CreateVolume(ctx, tmpVolumeName, map[string]string{engine.LabelOwner: "true"})
+	if err == nil {
+		err = containerEngine.ImportVolume(ctx, tmpVolumeName, projectName, content)
+	}
+	if err != nil {
+		if err := removeNamedVolume(ctx, tmpVolumeName, containerEngine, console); err != nil {
+			console.Warn("Could not remove volume '%s': %s", tmpVolumeName, err)
+		}
+		return fmt.Errorf("failed to extract the archive: %w\nHint: The previous volume has been kept", err)
+	}
+
+	// Stopped containers still reference the previous volume
+	if err := removeContainer(ctx, projectName, containerEngine, console); err != nil {
+		return err
+	}
+	if err := removeVolume(ctx, projectName, containerEngine, console); err != nil {
+		return err
+	}
+	volumeName := fmt.Sprintf("paulenv-%s-local", projectName)
+	console.Info("Restoring volume '%s'...", volumeName)
+	err = containerEngine.CreateVolume(ctx, volumeName, renameLabels(labels, projectName, projectName))
+	if err == nil {
+		err = containerEngine.CopyVolume(ctx, tmpVolumeName, volumeName, projectName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore volume '%s': %w\nHint: The restored data is still in volume '%s'", volumeName, err, tmpVolumeName)
+	}
+	if err := removeNamedVolume(ctx, tmpVolumeName, containerEngine, console); err != nil {
+		console.Warn("Could not remove volume '%s': %s", tmpVolumeName, err)
+	}
+	return nil
+}
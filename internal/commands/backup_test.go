@@ -0,0 +1,95 @@
+package commands_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+)
+
+func TestBackupRestore(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "app")
+	env.build(t, "app")
+	backupPath := filepath.Join(t.TempDir(), "app.tar.gz")
+
+	err := commands.Backup(env.ctx, []string{"app", "-o", backupPath}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "no local volume") {
+		t.Fatalf("expected an error without any volume, got %v", err)
+	}
+	if err := commands.Up(env.ctx, []string{"app"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	env.fakeEngine.VolumeData["paulenv-app-local"] = []byte("shell history")
+	if err := commands.Backup(env.ctx, []string{"app", "-o", backupPath}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	env.fakeEngine.VolumeData["paulenv-app-local"] = []byte("lost")
+	err = commands.Restore(env.ctx, []string{"app", backupPath}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "running") {
+		t.Fatalf("expected an error while the container is running, got %v", err)
+	}
+	if err := commands.Down(env.ctx, []string{"app"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	err = commands.Restore(env.ctx, []string{"app", backupPath}, env.filestore, env.engineLoader, env.console("n"))
+	if err == nil || env.fakeEngine.VolumeData["paulenv-app-local"] == nil {
+		t.Fatalf("the volume should be kept when declined, got %v", err)
+	}
+
+	// The previous volume is kept if the backup cannot be extracted
+	env.fakeEngine.FailOn("ImportVolume", errors.New("corrupted archive"))
+	err = commands.Restore(env.ctx, []string{"app", backupPath}, env.filestore, env.engineLoader, env.console("y"))
+	if err == nil || string(env.fakeEngine.VolumeData["paulenv-app-local"]) != "lost" {
+		t.Fatalf("the volume should be kept when the backup cannot be extracted, got %v", err)
+	}
+	env.fakeEngine.FailOn("ImportVolume", nil)
+
+	err = commands.Restore(env.ctx, []string{"app", backupPath}, env.filestore, env.engineLoader, env.console("y"))
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if data := string(env.fakeEngine.VolumeData["paulenv-app-local"]); data != "shell history" {
+		t.Errorf("the volume should have been restored, got %q", data)
+	}
+	volumes := 0
+	for _, volume := range env.fakeEngine.Volumes {
+		if volume.ProjectName != nil && *volume.ProjectName == "app" {
+			volumes++
+		}
+	}
+	if volumes != 1 {
+		t.Errorf("expected a single local volume, got %+v", env.fakeEngine.Volumes)
+	}
+	for _, volume := range env.fakeEngine.Volumes {
+		if strings.HasSuffix(volume.VolumeName, "-restoring") {
+			t.Errorf("the temporary volume should have been removed, got %+v", env.fakeEngine.Volumes)
+		}
+	}
+}
+
+func TestClean_BacksUpVolumes(t *testing.T) {
+	env := newTestEnv(t)
+	for _, name := range []string{"first", "second"} {
+		env.createProject(t, name)
+		env.build(t, name)
+		if err := commands.Up(env.ctx, []string{name}, env.filestore, env.engineLoader, env.console()); err != nil {
+			t.Fatalf("Up() error = %v", err)
+		}
+	}
+
+	err := commands.Clean(env.ctx, env.filestore, env.engineLoader, env.console("n", "n", "y", "y", "n"))
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	backups, err := filepath.Glob(filepath.Join(env.filestore.GetBackupsDir(), "*.tar.gz"))
+	if err != nil || len(backups) != 2 {
+		t.Errorf("the volumes of both projects should have been backed up, got %v (%v)", backups, err)
+	}
+	if len(env.fakeEngine.Volumes) != 0 {
+		t.Errorf("volumes should have been removed, got %+v", env.fakeEngine.Volumes)
+	}
+}
@@ -5,14 +5,15 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/engine"
 	"github.com/peaberberian/paul-envs/internal/files"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
-func Build(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
-	containerEngine, err := engine.New(ctx)
+func Build(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	containerEngine, err := engineLoader.Get(ctx)
 	if err != nil {
 		return err
 	}
@@ -32,6 +33,13 @@ func Build(ctx context.Context, args []string, filestore *files.FileStore, conso
 	if !status.IsValid() {
 		return fmt.Errorf("cannot build: %s\nPlease re-create this project.", status)
 	}
+	warnAboutOutdatedProject(name, status, console)
+
+	keptBaseFiles, err := filestore.RefreshBaseFiles()
+	if err != nil {
+		return fmt.Errorf("failed to prepare the base Dockerfile: %w", err)
+	}
+	warnAboutKeptBaseFiles(keptBaseFiles, console)
 
 	console.Info("Preparing dotfiles...")
 	tmpDotfilesDir, err := filestore.CreateProjectDotfilesDir(ctx, name)
@@ -41,9 +49,8 @@ func Build(ctx context.Context, args []string, filestore *files.FileStore, conso
 	}
 	defer filestore.RemoveProjectDotfilesDir(name)
 
-	console.Info("Ensuring that the shared cache volume is created...")
-	if err := containerEngine.CreateVolume(ctx, "paulenv-shared-cache"); err != nil {
-		return fmt.Errorf("Failed to create shared volume: %w.", err)
+	if err := ensureCacheVolume(ctx, name, filestore, containerEngine, console); err != nil {
+		return err
 	}
 
 	console.Info("Building project '%s'...", name)
@@ -64,9 +71,43 @@ func Build(ctx context.Context, args []string, filestore *files.FileStore, conso
 		return err
 	}
 	console.Success("Built project '%s'", name)
+	if console.IsStructuredOutput() {
+		return writeBuildOutput(ctx, project, containerEngine, engineLoader, console)
+	}
+	return nil
+}
+
+// Create the volume storing the package caches of project `projectName`, which
+// is not created by compose, if it does not exist yet.
+func ensureCacheVolume(ctx context.Context, projectName string, filestore *files.FileStore, containerEngine engine.ContainerEngine, console *console.Console) error {
+	cache, err := filestore.GetProjectCache(projectName)
+	if err != nil {
+		return err
+	}
+	cacheVolume := config.CacheVolumeName(projectName, cache)
+	console.Info("Ensuring that the cache volume '%s' is created...", cacheVolume)
+	if err := containerEngine.CreateVolume(ctx, cacheVolume, engine.CacheVolumeLabels(projectName, cache)); err != nil {
+		return fmt.Errorf("Failed to create cache volume: %w.", err)
+	}
 	return nil
 }
 
+// Write the output of the `build` command in a machine-readable format.
+func writeBuildOutput(ctx context.Context, project files.ProjectEntry, containerEngine engine.ContainerEngine, engineLoader *engine.Loader, console *console.Console) error {
+	output := buildOutput{SchemaVersion: outputSchemaVersion()}
+	imageInfo, err := containerEngine.GetImageInfo(ctx, project.ProjectName)
+	if err != nil {
+		console.Warn("Could not obtain image info for project '%s': %s", project.ProjectName, err)
+	}
+	output.Project = newProjectOutput(project, imageInfo)
+	if info, err := containerEngine.Info(ctx); err != nil {
+		console.Warn("Could not obtain container engine information: %s", err)
+	} else {
+		output.Engine = newEngineOutput(info, engineLoader.Selection())
+	}
+	return console.WriteData(output)
+}
+
 func getProjectName(args []string, filestore *files.FileStore, console *console.Console, action string) (string, error) {
 	if len(args) > 0 {
 		return args[0], nil
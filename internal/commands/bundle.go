@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/peaberberian/paul-envs/internal/args"
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Export(args []string, filestore *files.FileStore, console *console.Console) error {
+	var outputPath string
+	var withDotfiles bool
+	var force bool
+	flagset := flag.NewFlagSet("export", flag.ContinueOnError)
+	flagset.StringVar(&outputPath, "o", "", "Path of the written bundle")
+	flagset.BoolVar(&withDotfiles, "dotfiles", false, "Include paul-envs' dotfiles in the bundle")
+	flagset.BoolVar(&force, "force", false, "Replace an existing file without asking")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) > 1 {
+		return errors.New("usage: paul-envs export <name> [-o <file>] [--dotfiles]")
+	}
+	project, err := getExistingProject(positionals, filestore, console, "export")
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		outputPath = project.ProjectName + ".tar.gz"
+	}
+	if _, err := os.Stat(outputPath); err == nil && !force {
+		confirm, err := console.AskYesNo(fmt.Sprintf("'%s' already exists. Replace it?", outputPath), false)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			return errors.New("export aborted by user")
+		}
+	}
+
+	bundle, err := filestore.ExportProject(project.ProjectName, withDotfiles)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := bundle.Encode(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write bundle: %w", err)
+	}
+	console.Success("Exported project '%s' to '%s'", project.ProjectName, outputPath)
+	if withDotfiles {
+		console.WriteLn("  Including %d dotfile(s)", len(bundle.Dotfiles))
+	}
+	if hostVolumes := bundle.HostVolumes(); len(hostVolumes) > 0 {
+		console.Warn("Those volumes are mounted from paths of this machine, which may not exist on another one:")
+		for _, volume := range hostVolumes {
+			console.Warn("  - %s", volume)
+		}
+	}
+	console.WriteLn("Import it on another machine with:")
+	console.WriteLn("  paul-envs import %s <path>", filepath.Base(outputPath))
+	return nil
+}
+
+func Import(cmdArgs []string, filestore *files.FileStore, console *console.Console) error {
+	// The user's defaults also apply to imported projects
+	defaults := config.New("dev", config.ShellBash)
+	globalConfig, err := filestore.ReadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	if err := args.ApplyUserDefaults(&defaults, globalConfig); err != nil {
+		return fmt.Errorf("invalid default in %s: %w", filestore.GetGlobalConfigFilePath(), err)
+	}
+
+	var name, sshKeyPath string
+	var withDotfiles bool
+	flagset := flag.NewFlagSet("import", flag.ContinueOnError)
+	flagset.StringVar(&name, "name", "", "Name of the new project")
+	flagset.StringVar(&defaults.UID, "uid", defaults.UID, "Container UID")
+	flagset.StringVar(&defaults.GID, "gid", defaults.GID, "Container GID")
+	flagset.StringVar(&defaults.GitName, "git-name", defaults.GitName, "Git user name")
+	flagset.StringVar(&defaults.GitEmail, "git-email", defaults.GitEmail, "Git user email")
+	flagset.StringVar(&sshKeyPath, "ssh-key", "", "Path to the SSH public key allowed to connect")
+	flagset.BoolVar(&withDotfiles, "dotfiles", false, "Add the bundle's dotfiles to paul-envs' dotfiles")
+	positionals, err := parseInterspersedFlags(flagset, cmdArgs)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 2 {
+		return errors.New("usage: paul-envs import <bundle> <path> [--name <name>] [--ssh-key <path>] [--git-name <name>] [--git-email <email>] [--dotfiles]")
+	}
+	if err := utils.ValidateUIDGID(defaults.UID); err != nil {
+		return fmt.Errorf("invalid UID: %w", err)
+	}
+	if err := utils.ValidateUIDGID(defaults.GID); err != nil {
+		return fmt.Errorf("invalid GID: %w", err)
+	}
+	if err := utils.ValidateGitName(defaults.GitName); err != nil {
+		return fmt.Errorf("invalid git name: %w", err)
+	}
+	if defaults.GitEmail != "" {
+		if err := utils.ValidateGitEmail(defaults.GitEmail); err != nil {
+			return fmt.Errorf("invalid git e-mail: %w", err)
+		}
+	}
+
+	bundleFile, err := os.Open(positionals[0])
+	if err != nil {
+		return fmt.Errorf("could not open bundle: %w", err)
+	}
+	defer bundleFile.Close()
+	bundle, err := files.ReadBundle(bundleFile)
+	if err != nil {
+		return err
+	}
+
+	projectPath, err := filepath.Abs(positionals[1])
+	if err != nil {
+		return fmt.Errorf("invalid path '%s': %w", positionals[1], err)
+	}
+	if name == "" {
+		name, err = utils.SanitizeProjectName(filepath.Base(projectPath))
+		if err != nil {
+			return fmt.Errorf("did not succeed to sanitize project name '%s': %w\nHint: Set one with '--name'", filepath.Base(projectPath), err)
+		}
+	} else if err := utils.ValidateProjectName(name); err != nil {
+		return fmt.Errorf("invalid project name '%s': %w", name, err)
+	}
+	if filestore.DoesProjectExist(name) {
+		return fmt.Errorf("project '%s' already exists\nHint: Choose another name with '--name'", name)
+	}
+	if _, err := os.Stat(projectPath); os.IsNotExist(err) {
+		console.Warn("Warning: Path %s does not exist", projectPath)
+	}
+
+	if bundle.UsesSSHKey() {
+		if sshKeyPath == "" {
+			sshKeyPath = findDefaultSSHKey()
+		}
+		if sshKeyPath == "" {
+			console.Warn("No SSH public key found in ~/.ssh/: its mount has been commented out in the compose file.")
+		} else if sshKeyPath, err = filepath.Abs(sshKeyPath); err != nil {
+			return fmt.Errorf("invalid SSH key path: %w", err)
+		}
+	}
+
+	err = filestore.ImportProject(bundle, name, files.HostBinding{
+		ProjectPath: projectPath,
+		UID:         defaults.UID,
+		GID:         defaults.GID,
+		SSHKeyPath:  sshKeyPath,
+		GitName:     defaults.GitName,
+		GitEmail:    defaults.GitEmail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create project files: %w", err)
+	}
+	console.Success("Imported project '%s' (exported as '%s', Dockerfile version %s)",
+		name, bundle.ProjectName, bundle.DockerfileVersion())
+	console.WriteLn("  - %s", filestore.GetProjectEnvFilePath(name))
+	console.WriteLn("  - %s", filestore.GetProjectComposeFilePath(name))
+	if hostVolumes := bundle.HostVolumes(); len(hostVolumes) > 0 {
+		console.Warn("Those volumes are mounted from paths of the machine it has been exported from:")
+		for _, volume := range hostVolumes {
+			console.Warn("  - %s", volume)
+		}
+		console.WriteLn("Hint: Remove the ones which do not exist here with 'paul-envs edit %s --remove-volume <volume>'", name)
+	}
+
+	if len(bundle.Dotfiles) > 0 {
+		if withDotfiles {
+			skipped, err := filestore.ImportDotfiles(bundle)
+			if err != nil {
+				return fmt.Errorf("failed to import dotfiles: %w", err)
+			}
+			console.Success("Imported %d dotfile(s)", len(bundle.Dotfiles)-len(skipped))
+			for _, path := range skipped {
+				console.Warn("Kept your existing dotfile '%s'", path)
+			}
+		} else {
+			console.Info("This bundle contains %d dotfile(s), add them to yours with '--dotfiles'", len(bundle.Dotfiles))
+		}
+	}
+	console.WriteLn("")
+	console.WriteLn("Next steps:")
+	console.WriteLn("  1. Build the environment:")
+	console.WriteLn("     paul-envs build %s", name)
+	console.WriteLn("  2. Run the environment:")
+	console.WriteLn("     paul-envs run %s", name)
+	return nil
+}
+
+// Returns the path to the SSH public key in `~/.ssh/` which should be mounted
+// by default, or an empty string if there is none.
+func findDefaultSSHKey() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	pubKeys, err := filepath.Glob(filepath.Join(homeDir, ".ssh", "*.pub"))
+	if err != nil || len(pubKeys) == 0 {
+		return ""
+	}
+	for _, preferred := range []string{"id_ed25519.pub", "id_ecdsa.pub", "id_rsa.pub"} {
+		if idx := slices.IndexFunc(pubKeys, func(key string) bool { return filepath.Base(key) == preferred }); idx >= 0 {
+			return pubKeys[idx]
+		}
+	}
+	return pubKeys[0]
+}
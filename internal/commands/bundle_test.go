@@ -0,0 +1,104 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestExportImport(t *testing.T) {
+	env := newTestEnv(t)
+	projectPath := t.TempDir()
+	err := commands.Create([]string{projectPath, "--no-prompt", "--name", "shared",
+		"--uid", "1234", "--gid", "1234", "--enable-ssh", "--rust", "latest", "--port", "3000",
+		"--git-name", "Alice", "--git-email", "alice@example.com", "--volume", "/home/alice/notes:/home/dev/notes"},
+		env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// Mount an SSH key, as the prompt would have done
+	composePath := env.filestore.GetProjectComposeFilePath("shared")
+	compose, err := os.ReadFile(composePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compose = []byte(strings.Replace(string(compose), "# - ~/.ssh/id_ed25519.pub", "- /home/alice/.ssh/id.pub", 1))
+	if err := os.WriteFile(composePath, compose, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dotfilesDir, err := env.filestore.InitGlobalDotfilesDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dotfilesDir, ".bashrc"), []byte("alias ll='ls -l'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bundlePath := filepath.Join(t.TempDir(), "env.tar.gz")
+	err = commands.Export([]string{"shared", "-o", bundlePath, "--dotfiles"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !strings.Contains(env.out.String(), "/home/alice/notes:/home/dev/notes") {
+		t.Errorf("export should warn about volumes mounted from host paths, got:\n%s", env.out.String())
+	}
+	bundleFile, err := os.Open(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bundleFile.Close()
+	bundle, err := files.ReadBundle(bundleFile)
+	if err != nil {
+		t.Fatalf("ReadBundle() error = %v", err)
+	}
+	for _, hostValue := range []string{projectPath, "1234", "/home/alice/.ssh", "Alice", "alice@example.com"} {
+		if strings.Contains(string(bundle.Files.Env)+string(bundle.Files.Compose), hostValue) {
+			t.Errorf("exported files should not contain the host-specific value %q", hostValue)
+		}
+	}
+
+	// Import on another machine
+	other := newTestEnv(t)
+	if err := commands.Config([]string{"set", "default.git-name", "Bob"}, other.filestore, other.console()); err != nil {
+		t.Fatalf("Config() error = %v", err)
+	}
+	importPath := t.TempDir()
+	sshKeyPath := filepath.Join(t.TempDir(), "bob.pub")
+	err = commands.Import([]string{bundlePath, importPath, "--name", "imported", "--uid", "1001", "--gid", "1002",
+		"--ssh-key", sshKeyPath, "--git-email", "bob@example.com", "--dotfiles"}, other.filestore, other.console())
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	cfg, err := other.filestore.LoadProjectConfig("imported")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if cfg.ProjectName != "imported" || cfg.ProjectHostPath != importPath || cfg.UID != "1001" || cfg.GID != "1002" {
+		t.Errorf("imported project should be bound to this machine, got %+v", cfg)
+	}
+	if cfg.GitName != "Bob" || cfg.GitEmail != "bob@example.com" {
+		t.Errorf("imported project should use this user's git identity, got %+v", cfg)
+	}
+	if cfg.SshKeyPath != sshKeyPath || cfg.InstallRust != "latest" || len(cfg.Ports) != 1 {
+		t.Errorf("imported project should keep the exported configuration, got %+v", cfg)
+	}
+	if status, err := other.filestore.ValidateProjectLock("imported"); !status.IsValid() {
+		t.Errorf("imported project should have a valid project.lock, got %s (%v)", status, err)
+	}
+	otherDotfilesDir, err := other.filestore.InitGlobalDotfilesDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(filepath.Join(otherDotfilesDir, ".bashrc")); err != nil || !strings.Contains(string(content), "alias ll") {
+		t.Errorf("dotfiles should have been imported, got %q (err: %v)", content, err)
+	}
+
+	err = commands.Import([]string{bundlePath, importPath, "--name", "imported"}, other.filestore, other.console())
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error when importing on an existing project, got %v", err)
+	}
+}
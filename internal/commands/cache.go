@@ -0,0 +1,450 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Name of the volume mounted as `~/.container-cache` in the containers of all
+// projects which have neither a private cache nor a cache group.
+var sharedCacheVolume = config.CacheVolumeName("", config.CacheShared)
+
+// A cache volume the `cache` command acts on.
+type cacheTarget struct {
+	// Name of the volume
+	volume string
+	// How it is described in messages, e.g. "shared cache"
+	description string
+	// Projects using that volume whose image is built, sorted by name. Their
+	// images are needed to access it.
+	projects []string
+}
+
+// A directory of a cache volume, used by a given tool.
+type cacheDir struct {
+	// Name by which it is selected through `--only`
+	Name string
+	// Path relative to the root of the cache volume
+	Path string
+}
+
+// Directories of a cache volume, as configured in the base Dockerfile.
+var cacheDirs = []cacheDir{
+	{Name: "npm", Path: ".npm"},
+	{Name: "yarn", Path: ".yarn"},
+	{Name: "pip", Path: "pip"},
+	{Name: "go", Path: "go/mod"},
+	{Name: "xdg", Path: "cache"},
+}
+
+// Writes the size in kB of each of the given directories of the volume (when
+// they exist), then of the whole volume as ".".
+const cacheDuScript = `cd "$0" || exit 1
+for dir in "$@"; do
+  if [ -d "$dir" ]; then du -sk -- "$dir"; fi
+done
+du -sk .`
+
+// Removes the content of the given directories of the volume which has not
+// been accessed for the number of minutes given as first argument, or all
+// their content if that number is 0.
+const cachePruneScript = `cd "$0" || exit 1
+minutes="$1"
+shift
+for dir in "$@"; do
+  [ -d "$dir" ] || continue
+  if [ "$minutes" -gt 0 ]; then
+    find "$dir" -mindepth 1 -type f -amin "+$minutes" -delete
+    find "$dir" -mindepth 1 -type d -empty -delete
+  else
+    find "$dir" -mindepth 1 -delete
+  fi
+done`
+
+// Copies the initial cache of the image in the volume without replacing
+// existing files, then marks it as initialized for the entrypoint.
+const cacheSeedScript = `cp -an "$INITIAL_CACHE_DIR/." "$0/" && touch "$0/.initialized"`
+
+func Cache(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	if len(args) == 0 {
+		return errors.New("no cache action given. Use one of: du, prune, reset")
+	}
+	switch args[0] {
+	case "du":
+		return cacheDu(ctx, args[1:], filestore, engineLoader, console)
+	case "prune":
+		return cachePrune(ctx, args[1:], filestore, engineLoader, console)
+	case "reset":
+		return cacheReset(ctx, args[1:], filestore, engineLoader, console)
+	default:
+		return fmt.Errorf("unknown cache action '%s'. Use one of: du, prune, reset", args[0])
+	}
+}
+
+// Display the size of each directory of a cache volume.
+func cacheDu(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var projectName string
+	flagset := flag.NewFlagSet("cache du", flag.ContinueOnError)
+	flagset.StringVar(&projectName, "project", "", "Use the cache of that project instead of the shared cache")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 0 {
+		return errors.New("usage: paul-envs cache du [--project <name>]")
+	}
+	containerEngine, target, err := prepareCacheAccess(ctx, projectName, filestore, engineLoader)
+	if err != nil {
+		return err
+	}
+	sizes, err := getCacheSizes(ctx, containerEngine, target)
+	if err != nil {
+		return err
+	}
+	console.WriteLn("Content of the %s ('%s'):", target.description, target.volume)
+	var known int64
+	for _, dir := range cacheDirs {
+		size, exists := sizes[dir.Path]
+		if !exists {
+			console.WriteLn("  %-6s ~/.container-cache/%-8s (none)", dir.Name, dir.Path)
+			continue
+		}
+		known += size
+		console.WriteLn("  %-6s ~/.container-cache/%-8s %s", dir.Name, dir.Path, formatSize(size))
+	}
+	if other := sizes["."] - known; other > 0 {
+		console.WriteLn("  %-6s (other files)%14s %s", "other", "", formatSize(other))
+	}
+	console.WriteLn("  Total: %s", formatSize(sizes["."]))
+	return nil
+}
+
+// Remove the content of a cache volume, optionally only for some tools and
+// only what has not been accessed for some time.
+func cachePrune(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var olderThan, only, projectName string
+	var force bool
+	flagset := flag.NewFlagSet("cache prune", flag.ContinueOnError)
+	flagset.StringVar(&projectName, "project", "", "Use the cache of that project instead of the shared cache")
+	flagset.StringVar(&olderThan, "older-than", "", "Only remove files not accessed for that long (e.g. 30d, 12h)")
+	flagset.StringVar(&only, "only", "", "Comma-separated cache directories to prune (npm, yarn, pip, go, xdg)")
+	flagset.BoolVar(&force, "force", false, "Do not ask for confirmation when pruning everything")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 0 {
+		return errors.New("usage: paul-envs cache prune [--project <name>] [--older-than <age>] [--only <dirs>] [--force]")
+	}
+	var age time.Duration
+	if olderThan != "" {
+		if age, err = parseAge(olderThan); err != nil {
+			return fmt.Errorf("invalid --older-than value '%s': %w", olderThan, err)
+		}
+	}
+	dirs := cacheDirs
+	if only != "" {
+		if dirs, err = selectCacheDirs(only); err != nil {
+			return err
+		}
+	}
+
+	containerEngine, target, err := prepareCacheAccess(ctx, projectName, filestore, engineLoader)
+	if err != nil {
+		return err
+	}
+	// Without any filter, this removes all that the cached tools rely on
+	if olderThan == "" && only == "" {
+		if err := checkCacheNotInUse(ctx, containerEngine, target); err != nil {
+			return err
+		}
+		if !force {
+			confirm, err := console.AskYesNo(fmt.Sprintf("Remove the whole content of the cache directories of the %s?", target.description), false)
+			if err != nil {
+				return err
+			}
+			if !confirm {
+				return errors.New("cache prune aborted by user\nHint: Use '--older-than' or '--only' to only prune part of it")
+			}
+		}
+	}
+	before, err := getCacheSizes(ctx, containerEngine, target)
+	if err != nil {
+		return err
+	}
+	dirPaths := []string{}
+	dirNames := []string{}
+	for _, dir := range dirs {
+		dirPaths = append(dirPaths, dir.Path)
+		dirNames = append(dirNames, dir.Name)
+	}
+	if age > 0 {
+		console.Info("Pruning files not accessed for %s from the %s cache...", formatDuration(age), strings.Join(dirNames, ", "))
+	} else {
+		console.Info("Pruning the %s cache...", strings.Join(dirNames, ", "))
+	}
+	scriptArgs := append([]string{"sh", "-c", cachePruneScript, engine.HelperVolumePath,
+		strconv.Itoa(int(age.Minutes()))}, dirPaths...)
+	if err := containerEngine.RunInVolume(ctx, target.volume, target.projects[0], &bytes.Buffer{}, scriptArgs...); err != nil {
+		return err
+	}
+	after, err := getCacheSizes(ctx, containerEngine, target)
+	if err != nil {
+		return err
+	}
+	console.Success("Pruned the %s, freeing %s (now %s)", target.description, formatSize(max(before["."]-after["."], 0)), formatSize(after["."]))
+	return nil
+}
+
+// Empty a cache volume then fill it again with the initial cache of the built
+// images using it.
+func cacheReset(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var force bool
+	var projectName string
+	flagset := flag.NewFlagSet("cache reset", flag.ContinueOnError)
+	flagset.StringVar(&projectName, "project", "", "Use the cache of that project instead of the shared cache")
+	flagset.BoolVar(&force, "force", false, "Do not ask for confirmation")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 0 {
+		return errors.New("usage: paul-envs cache reset [--project <name>] [--force]")
+	}
+
+	containerEngine, target, err := prepareCacheAccess(ctx, projectName, filestore, engineLoader)
+	if err != nil {
+		return err
+	}
+	if err := checkCacheNotInUse(ctx, containerEngine, target); err != nil {
+		return err
+	}
+	if !force {
+		confirm, err := console.AskYesNo(fmt.Sprintf("Remove everything from the %s?", target.description), false)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			return errors.New("cache reset aborted by user")
+		}
+	}
+
+	console.Info("Emptying the %s...", target.description)
+	err = containerEngine.RunInVolume(ctx, target.volume, target.projects[0], &bytes.Buffer{},
+		"find", engine.HelperVolumePath, "-mindepth", "1", "-delete")
+	if err != nil {
+		return err
+	}
+	for _, projectName := range target.projects {
+		console.Info("Adding the initial cache of image 'paulenv:%s'...", projectName)
+		err := containerEngine.RunInVolume(ctx, target.volume, projectName, &bytes.Buffer{},
+			"sh", "-c", cacheSeedScript, engine.HelperVolumePath)
+		if err != nil {
+			return err
+		}
+	}
+	console.Success("Reset the %s", target.description)
+	return nil
+}
+
+// Obtain the container engine and the cache volume to act on: the one of
+// project `projectName` or, if empty, the shared cache.
+func prepareCacheAccess(ctx context.Context, projectName string, filestore *files.FileStore, engineLoader *engine.Loader) (engine.ContainerEngine, *cacheTarget, error) {
+	target := &cacheTarget{volume: sharedCacheVolume, description: "shared cache"}
+	if projectName != "" {
+		if err := utils.ValidateProjectName(projectName); err != nil {
+			return nil, nil, err
+		}
+		if !filestore.DoesProjectExist(projectName) {
+			return nil, nil, fmt.Errorf("project '%s' not found\nHint: Use 'paul-envs list' to see available projects", projectName)
+		}
+		cache, err := filestore.GetProjectCache(projectName)
+		if err != nil {
+			return nil, nil, err
+		}
+		target.volume = config.CacheVolumeName(projectName, cache)
+		switch cache {
+		case "", config.CacheShared:
+		case config.CachePrivate:
+			target.description = fmt.Sprintf("private cache of project '%s'", projectName)
+		default:
+			target.description = fmt.Sprintf("cache of group '%s'", cache)
+		}
+	}
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	volume, err := findVolume(ctx, containerEngine, target.volume)
+	if err != nil {
+		return nil, nil, err
+	}
+	if volume == nil {
+		return nil, nil, fmt.Errorf("the %s volume '%s' does not exist\nHint: It is created when building a project using it", target.description, target.volume)
+	}
+	images, err := containerEngine.ListImages(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot list current images: %w", err)
+	}
+	for _, image := range images {
+		if image.ProjectName == nil {
+			continue
+		}
+		// Images whose project has been removed have been built with the shared cache
+		volume, err := filestore.GetProjectCacheVolume(*image.ProjectName)
+		if err != nil {
+			volume = sharedCacheVolume
+		}
+		if volume == target.volume {
+			target.projects = append(target.projects, *image.ProjectName)
+		}
+	}
+	if len(target.projects) == 0 {
+		return nil, nil, fmt.Errorf("the image of a project using the %s is needed to access it but none has been built\nHint: Build one with 'paul-envs build <name>'", target.description)
+	}
+	slices.Sort(target.projects)
+	target.projects = slices.Compact(target.projects)
+	return containerEngine, target, nil
+}
+
+// Returns an error if the container of a project using the given cache volume
+// is running.
+func checkCacheNotInUse(ctx context.Context, containerEngine engine.ContainerEngine, target *cacheTarget) error {
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list current containers: %w", err)
+	}
+	for _, container := range containers {
+		if container.ProjectName != nil && slices.Contains(target.projects, *container.ProjectName) && isContainerRunning(container) {
+			return fmt.Errorf("the container of project '%s' is running and uses the %s\nHint: Stop it first with 'paul-envs down %s'",
+				*container.ProjectName, target.description, *container.ProjectName)
+		}
+	}
+	return nil
+}
+
+// Find the volume named `volumeName`, returning `nil` if it does not exist.
+func findVolume(ctx context.Context, containerEngine engine.ContainerEngine, volumeName string) (*engine.VolumeInfo, error) {
+	volumes, err := containerEngine.ListVolumes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list current volumes: %w", err)
+	}
+	for _, volume := range volumes {
+		if volume.VolumeName == volumeName {
+			return &volume, nil
+		}
+	}
+	return nil, nil
+}
+
+// Returns the names of the projects storing their package caches in the
+// volume `volumeName`.
+func findCacheVolumeUsers(volumeName string, filestore *files.FileStore) ([]string, error) {
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return nil, fmt.Errorf("could not list all projects: %w", err)
+	}
+	users := []string{}
+	for _, entry := range entries {
+		volume, err := filestore.GetProjectCacheVolume(entry.ProjectName)
+		if err == nil && volume == volumeName {
+			users = append(users, entry.ProjectName)
+		}
+	}
+	return users, nil
+}
+
+// Returns the size in bytes of the existing directories of a cache volume,
+// keyed by their path, as well as its total size under the "." key.
+func getCacheSizes(ctx context.Context, containerEngine engine.ContainerEngine, target *cacheTarget) (map[string]int64, error) {
+	args := []string{"sh", "-c", cacheDuScript, engine.HelperVolumePath}
+	for _, dir := range cacheDirs {
+		args = append(args, dir.Path)
+	}
+	var out bytes.Buffer
+	if err := containerEngine.RunInVolume(ctx, target.volume, target.projects[0], &out, args...); err != nil {
+		return nil, fmt.Errorf("cannot compute the size of the %s: %w", target.description, err)
+	}
+	return parseDuOutput(out.Bytes())
+}
+
+// Parse the output of `du -sk`, returning sizes in bytes keyed by path.
+func parseDuOutput(output []byte) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		sizeStr, dirPath, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok {
+			continue
+		}
+		size, err := strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected du output '%s'", scanner.Text())
+		}
+		sizes[path.Clean(dirPath)] = size * 1024
+	}
+	if _, ok := sizes["."]; !ok {
+		return nil, errors.New("unexpected du output: no total size")
+	}
+	return sizes, nil
+}
+
+// Returns the cache directories named in the given comma-separated list.
+func selectCacheDirs(list string) ([]cacheDir, error) {
+	dirs := []cacheDir{}
+	for name := range strings.SplitSeq(list, ",") {
+		name = strings.TrimSpace(name)
+		idx := slices.IndexFunc(cacheDirs, func(d cacheDir) bool { return d.Name == name })
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown cache directory '%s'. Use one of: npm, yarn, pip, go, xdg", name)
+		}
+		if !slices.Contains(dirs, cacheDirs[idx]) {
+			dirs = append(dirs, cacheDirs[idx])
+		}
+	}
+	return dirs, nil
+}
+
+// Parse an age such as "30d", "2w" or "12h". Units understood by
+// `time.ParseDuration` are also accepted.
+func parseAge(value string) (time.Duration, error) {
+	var age time.Duration
+	if numStr, ok := strings.CutSuffix(value, "d"); ok {
+		num, err := strconv.ParseUint(numStr, 10, 16)
+		if err != nil {
+			return 0, err
+		}
+		age = time.Duration(num) * 24 * time.Hour
+	} else if numStr, ok := strings.CutSuffix(value, "w"); ok {
+		num, err := strconv.ParseUint(numStr, 10, 16)
+		if err != nil {
+			return 0, err
+		}
+		age = time.Duration(num) * 7 * 24 * time.Hour
+	} else {
+		var err error
+		if age, err = time.ParseDuration(value); err != nil {
+			return 0, err
+		}
+	}
+	if age < time.Minute {
+		return 0, errors.New("must be at least a minute")
+	}
+	return age, nil
+}
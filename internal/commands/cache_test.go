@@ -0,0 +1,144 @@
+package commands_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestCache(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Cache(env.ctx, []string{"du"}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected an error without any shared cache, got %v", err)
+	}
+	env.createProject(t, "web")
+	env.createProject(t, "api")
+	env.build(t, "web")
+	env.build(t, "api")
+	env.fakeEngine.RunInVolumeOutput = "2000\t.npm\n1000\tgo/mod\n4000\t.\n"
+
+	env.out.Reset()
+	if err := commands.Cache(env.ctx, []string{"du"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Cache(du) error = %v", err)
+	}
+	for _, expected := range []string{"2.0 MB", "1.0 MB", "4.1 MB", "(none)"} {
+		if !strings.Contains(env.out.String(), expected) {
+			t.Errorf("expected %q in du output, got:\n%s", expected, env.out.String())
+		}
+	}
+
+	err = commands.Cache(env.ctx, []string{"prune", "--only", "npm,go", "--older-than", "30d"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Cache(prune) error = %v", err)
+	}
+	prune := env.fakeEngine.CallsTo("RunInVolume")[2]
+	if prune.Target != "paulenv-shared-cache" || !slices.Equal(prune.Args[len(prune.Args)-3:], []string{"43200", ".npm", "go/mod"}) {
+		t.Errorf("unexpected prune call: %+v", prune)
+	}
+	for _, args := range [][]string{{"prune", "--only", "maven"}, {"prune", "--older-than", "soon"}} {
+		if err := commands.Cache(env.ctx, args, env.filestore, env.engineLoader, env.console()); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+
+	env.fakeEngine.StartFakeContainer("web")
+	for _, args := range [][]string{{"reset", "--force"}, {"prune", "--force"}} {
+		err = commands.Cache(env.ctx, args, env.filestore, env.engineLoader, env.console())
+		if err == nil || !strings.Contains(err.Error(), "running") {
+			t.Fatalf("expected an error for %v while a container is running, got %v", args, err)
+		}
+	}
+	if err := commands.Stop(env.ctx, []string{"web"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	calls := len(env.fakeEngine.CallsTo("RunInVolume"))
+	if err := commands.Cache(env.ctx, []string{"prune"}, env.filestore, env.engineLoader, env.console("n")); err == nil {
+		t.Errorf("pruning the whole cache should be cancellable")
+	}
+	if len(env.fakeEngine.CallsTo("RunInVolume")) != calls {
+		t.Errorf("nothing should have been pruned when declined")
+	}
+	if err := commands.Cache(env.ctx, []string{"prune", "--force"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Cache(prune) error = %v", err)
+	}
+
+	calls = len(env.fakeEngine.CallsTo("RunInVolume"))
+	if err := commands.Cache(env.ctx, []string{"reset"}, env.filestore, env.engineLoader, env.console("y")); err != nil {
+		t.Fatalf("Cache(reset) error = %v", err)
+	}
+	resets := env.fakeEngine.CallsTo("RunInVolume")[calls:]
+	if len(resets) != 3 || resets[0].Args[1] != "find" || resets[1].Args[0] != "api" || resets[2].Args[0] != "web" {
+		t.Errorf("expected the cache to be emptied then seeded from each image, got %+v", resets)
+	}
+}
+
+func TestCache_PrivateAndGroups(t *testing.T) {
+	env := newTestEnv(t)
+	for _, project := range []struct{ name, cache string }{
+		{"secret", "private"}, {"client-a", "clients"}, {"client-b", "clients"}, {"public", "shared"},
+	} {
+		env.createProject(t, project.name)
+		if err := commands.Edit([]string{project.name, "--cache", project.cache}, env.filestore, env.console()); err != nil {
+			t.Fatalf("Edit() error = %v", err)
+		}
+		env.build(t, project.name)
+	}
+	volumes := []string{}
+	for _, call := range env.fakeEngine.CallsTo("CreateVolume") {
+		volumes = append(volumes, call.Target)
+	}
+	expected := []string{"paulenv-private-secret-cache", "paulenv-group-clients-cache", "paulenv-group-clients-cache", "paulenv-shared-cache"}
+	if !slices.Equal(volumes, expected) {
+		t.Errorf("expected each project to create its cache volume, got %v", volumes)
+	}
+	for _, volume := range env.fakeEngine.Volumes {
+		if volume.VolumeName == "paulenv-private-secret-cache" && (volume.ProjectName != nil ||
+			volume.Labels[engine.LabelCacheKind] != "private" || volume.Labels[engine.LabelCacheOwner] != "secret") {
+			t.Errorf("the private cache should be labeled as such, got %+v", volume)
+		}
+	}
+	content, err := env.filestore.ReadProjectFiles("secret")
+	if err != nil || !strings.Contains(string(content.Compose), "name: paulenv-private-secret-cache\n") {
+		t.Errorf("the compose file should mount the private cache, got %v:\n%s", err, content.Compose)
+	}
+
+	env.fakeEngine.RunInVolumeOutput = "1000\t.\n"
+	err = commands.Cache(env.ctx, []string{"du", "--project", "client-b"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Cache(du) error = %v", err)
+	}
+	du := env.fakeEngine.CallsTo("RunInVolume")[0]
+	if du.Target != "paulenv-group-clients-cache" || du.Args[0] != "client-a" {
+		t.Errorf("expected the group's cache to be accessed through one of its images, got %+v", du)
+	}
+
+	if err := commands.Rename(env.ctx, []string{"secret", "hidden"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("CopyVolume")) != 1 || !hasVolume(env, "paulenv-private-hidden-cache") || hasVolume(env, "paulenv-private-secret-cache") {
+		t.Errorf("the private cache should have been migrated, got %+v", env.fakeEngine.Volumes)
+	}
+
+	for _, name := range []string{"hidden", "client-a"} {
+		if err := commands.Remove(env.ctx, []string{name}, env.filestore, env.engineLoader, env.console("y")); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+	}
+	if hasVolume(env, "paulenv-private-hidden-cache") || !hasVolume(env, "paulenv-group-clients-cache") {
+		t.Errorf("only the private cache should have been removed, got %+v", env.fakeEngine.Volumes)
+	}
+	if err := commands.Remove(env.ctx, []string{"client-b"}, env.filestore, env.engineLoader, env.console("y")); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if hasVolume(env, "paulenv-group-clients-cache") || !hasVolume(env, "paulenv-shared-cache") {
+		t.Errorf("the cache of a group should be removed with its last project, got %+v", env.fakeEngine.Volumes)
+	}
+}
+
+func hasVolume(env *testEnv, name string) bool {
+	return slices.ContainsFunc(env.fakeEngine.Volumes, func(v engine.VolumeInfo) bool { return v.VolumeName == name })
+}
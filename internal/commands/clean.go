@@ -9,7 +9,7 @@ import (
 	"github.com/peaberberian/paul-envs/internal/files"
 )
 
-func Clean(ctx context.Context, filestore *files.FileStore, console *console.Console) error {
+func Clean(ctx context.Context, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
 	console.Info("\n1. Projects' configuration")
 	console.WriteLn("This will clean-up the container configurations you created with the 'create' command.")
 	choice, err := console.AskYesNo("Remove projects configuration files?", true)
@@ -38,7 +38,7 @@ func Clean(ctx context.Context, filestore *files.FileStore, console *console.Con
 		}
 	}
 
-	containerEngine, err := engine.New(ctx)
+	containerEngine, err := engineLoader.Get(ctx)
 	if err != nil {
 		return err
 	}
@@ -51,6 +51,9 @@ func Clean(ctx context.Context, filestore *files.FileStore, console *console.Con
 	} else if !choice {
 		console.WriteLn("\nSkipping container removal")
 	} else {
+		if err := offerAllVolumeBackups(ctx, filestore, containerEngine, console); err != nil {
+			return err
+		}
 		if err := removeContainers(ctx, containerEngine, console); err != nil {
 			return err
 		}
@@ -80,6 +83,22 @@ func Clean(ctx context.Context, filestore *files.FileStore, console *console.Con
 	return nil
 }
 
+// Propose to back up the local volumes of all projects, as listed by the
+// container engine since project files may already have been removed.
+func offerAllVolumeBackups(ctx context.Context, filestore *files.FileStore, containerEngine engine.ContainerEngine, console *console.Console) error {
+	volumes, err := containerEngine.ListVolumes(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list current volumes: %w", err)
+	}
+	projectNames := []string{}
+	for _, volume := range volumes {
+		if volume.ProjectName != nil {
+			projectNames = append(projectNames, *volume.ProjectName)
+		}
+	}
+	return offerVolumeBackups(ctx, projectNames, filestore, containerEngine, console)
+}
+
 func removeContainers(ctx context.Context, containerEngine engine.ContainerEngine, console *console.Console) error {
 	console.WriteLn("\nStopping and removing containers...")
 
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Clone(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var name string
+	var reuseImage bool
+	flagset := flag.NewFlagSet("clone", flag.ContinueOnError)
+	flagset.StringVar(&name, "name", "", "Name of the new project")
+	flagset.BoolVar(&reuseImage, "reuse-image", false, "Reuse the image already built for the existing project")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 2 {
+		return errors.New("usage: paul-envs clone <existing> <new-path> [--name <new>] [--reuse-image]")
+	}
+
+	source, err := getExistingProject(positionals[:1], filestore, console, "clone")
+	if err != nil {
+		return err
+	}
+	targetPath, err := filepath.Abs(positionals[1])
+	if err != nil {
+		return fmt.Errorf("invalid path '%s': %w", positionals[1], err)
+	}
+	if name == "" {
+		name, err = utils.SanitizeProjectName(filepath.Base(targetPath))
+		if err != nil {
+			return fmt.Errorf("did not succeed to sanitize project name '%s': %w\nHint: Set one with '--name'", filepath.Base(targetPath), err)
+		}
+	} else if err := utils.ValidateProjectName(name); err != nil {
+		return fmt.Errorf("invalid project name '%s': %w", name, err)
+	}
+	if filestore.DoesProjectExist(name) {
+		return fmt.Errorf("project '%s' already exists\nHint: Choose another name with '--name'", name)
+	}
+	if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+		console.Warn("Warning: Path %s does not exist", targetPath)
+	}
+
+	var containerEngine engine.ContainerEngine
+	var engineInfo engine.EngineInfo
+	if reuseImage {
+		containerEngine, err = engineLoader.Get(ctx)
+		if err != nil {
+			return err
+		}
+		engineInfo, err = containerEngine.Info(ctx)
+		if err != nil {
+			return fmt.Errorf("impossible to get container engine version: %w", err)
+		}
+		if err := checkImageReusable(ctx, source.ProjectName, containerEngine, engineInfo, filestore); err != nil {
+			return fmt.Errorf("cannot reuse the image of project '%s': %w\nHint: Run 'paul-envs build %s' first or clone without '--reuse-image'",
+				source.ProjectName, err, source.ProjectName)
+		}
+	}
+
+	if content, err := filestore.ReadProjectFiles(source.ProjectName); err == nil && files.IsLegacyProjectFiles(content) {
+		console.Info("Project '%s' was created by an older version of paul-envs: the files of its clone will be re-generated from its configuration", source.ProjectName)
+	}
+	if err := filestore.CloneProjectFiles(source.ProjectName, name, targetPath); err != nil {
+		return fmt.Errorf("failed to create project files: %w", err)
+	}
+	console.Success("Cloned project '%s' into project '%s'", source.ProjectName, name)
+	console.WriteLn("  - %s", filestore.GetProjectEnvFilePath(name))
+	console.WriteLn("  - %s", filestore.GetProjectComposeFilePath(name))
+
+	if reuseImage {
+		console.Info("Creating the image of project '%s' from the one of project '%s'...", name, source.ProjectName)
+		if err := containerEngine.CopyImage(ctx, source.ProjectName, name); err != nil {
+			return fmt.Errorf("project '%s' has been created but its image could not be created: %w\nHint: Run 'paul-envs build %s' instead", name, err, name)
+		}
+		if err := ensureCacheVolume(ctx, name, filestore, containerEngine, console); err != nil {
+			return err
+		}
+		if err := filestore.RefreshBuildInfoFile(name, engineInfo.Name, engineInfo.Version); err != nil {
+			console.Warn("Could not refresh 'project.buildinfo' file for this project: %s", err)
+		}
+		console.Success("Project '%s' is ready, run it with 'paul-envs run %s'", name, name)
+		return nil
+	}
+	console.WriteLn("")
+	console.WriteLn("Next steps:")
+	console.WriteLn("  1. Build the environment:")
+	console.WriteLn("     paul-envs build %s", name)
+	console.WriteLn("  2. Run the environment:")
+	console.WriteLn("     paul-envs run %s", name)
+	return nil
+}
+
+// Returns an error if the image of the given project cannot be reused as is
+// by a clone, either because it is not built or because it is outdated.
+//
+// Images built from the files of an older version of paul-envs are never
+// reused: their base files would be upgraded alongside, without the image.
+func checkImageReusable(ctx context.Context, projectName string, containerEngine engine.ContainerEngine, engineInfo engine.EngineInfo, filestore *files.FileStore) error {
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, projectName)
+	if err != nil {
+		return fmt.Errorf("failed to get its status: %w", err)
+	}
+	if !hasBeenBuilt {
+		return errors.New("it has not been built yet")
+	}
+	lockStatus, err := filestore.ValidateProjectLock(projectName)
+	if err != nil || lockStatus != files.ProjectLockValid {
+		return fmt.Errorf("it needs to be re-built: %s", lockStatus)
+	}
+	content, err := filestore.ReadProjectFiles(projectName)
+	if err != nil {
+		return fmt.Errorf("failed to read its files: %w", err)
+	}
+	if files.IsLegacyProjectFiles(content) {
+		return errors.New("it needs to be re-built: created by an older version of paul-envs")
+	}
+	buildInfo, err := filestore.ReadBuildInfo(projectName)
+	if err != nil {
+		return fmt.Errorf("could not get the information from its last build: %w", err)
+	}
+	if !buildInfo.HasBaseFilesHashes() {
+		return errors.New("it needs to be re-built: built by an older version of paul-envs")
+	}
+	needsRebuild, reason, err := filestore.NeedsRebuild(projectName, buildInfo, engineInfo.Name)
+	if err != nil {
+		return fmt.Errorf("could not check its last build: %w", err)
+	}
+	if needsRebuild {
+		return fmt.Errorf("it needs to be re-built: %s", reason)
+	}
+	return nil
+}
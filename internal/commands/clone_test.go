@@ -0,0 +1,155 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestClone(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "main",
+		"--rust", "latest", "--port", "8080"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	worktree := filepath.Join(t.TempDir(), "feature-x")
+	if err := commands.Clone(env.ctx, []string{"main", worktree}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Clone() error = %v", err)
+	}
+
+	cfg, err := env.filestore.LoadProjectConfig("feature-x")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if cfg.ProjectName != "feature-x" || cfg.ProjectHostPath != worktree {
+		t.Errorf("clone should be named after and mount its own path, got name %q and path %q",
+			cfg.ProjectName, cfg.ProjectHostPath)
+	}
+	if cfg.InstallRust != "latest" || len(cfg.Ports) != 1 || cfg.Ports[0] != 8080 {
+		t.Errorf("clone should keep the configuration of its source, got %+v", cfg)
+	}
+	content, err := env.filestore.ReadProjectFiles("feature-x")
+	if err != nil {
+		t.Fatalf("ReadProjectFiles() error = %v", err)
+	}
+	for _, expected := range []string{"image: paulenv:feature-x", "name: paulenv-feature-x-local"} {
+		if !strings.Contains(string(content.Compose), expected) {
+			t.Errorf("cloned compose file should contain %q", expected)
+		}
+	}
+	if strings.Contains(string(content.Compose), "paulenv-main-local") {
+		t.Errorf("cloned compose file should not use the local volume of its source")
+	}
+	if len(env.fakeEngine.CallsTo("CopyImage")) != 0 {
+		t.Errorf("the image should not be reused without '--reuse-image'")
+	}
+
+	err = commands.Clone(env.ctx, []string{"main", worktree}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error when cloning on an existing project, got %v", err)
+	}
+	err = commands.Clone(env.ctx, []string{"unknown", t.TempDir()}, env.filestore, env.engineLoader, env.console())
+	if err == nil {
+		t.Errorf("expected an error when cloning an unknown project")
+	}
+}
+
+func TestClone_ReuseImage(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "main")
+	args := []string{"main", t.TempDir(), "--name", "other", "--reuse-image"}
+
+	err := commands.Clone(env.ctx, args, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "not been built") {
+		t.Fatalf("expected an error when reusing an image not built, got %v", err)
+	}
+	if env.filestore.DoesProjectExist("other") {
+		t.Fatalf("no project should be created when the image cannot be reused")
+	}
+
+	env.build(t, "main")
+	if err := commands.Clone(env.ctx, args, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Clone() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("BuildImage")) != 1 {
+		t.Errorf("the clone should not be built")
+	}
+	built, err := env.fakeEngine.HasBeenBuilt(env.ctx, "other")
+	if err != nil || !built {
+		t.Fatalf("the clone should have an image, got %v (err: %v)", built, err)
+	}
+	image, _ := env.fakeEngine.GetImageInfo(env.ctx, "other")
+	if image.Labels[engine.LabelProject] != "other" || image.Labels[engine.LabelProjectID] != "other" {
+		t.Errorf("the image of the clone should be labeled with its own project, got %v", image.Labels)
+	}
+
+	buildInfo, err := env.filestore.ReadBuildInfo("other")
+	if err != nil {
+		t.Fatalf("ReadBuildInfo() error = %v", err)
+	}
+	needsRebuild, reason, err := env.filestore.NeedsRebuild("other", buildInfo, "fake")
+	if err != nil || needsRebuild {
+		t.Errorf("the clone should not need a rebuild, got %v (%s, err: %v)", needsRebuild, reason, err)
+	}
+}
+
+func TestClone_ReuseImageBuiltByOlderVersion(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "main")
+	env.build(t, "main")
+	project, err := env.filestore.GetProject("main")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	projectDir := filepath.Dir(project.EnvFilePath)
+	args := []string{"main", t.TempDir(), "--name", "other", "--reuse-image"}
+
+	// Version 1.0.0 did not record the hashes of the base files
+	buildInfoPath := filepath.Join(projectDir, "project.buildinfo")
+	buildInfo, err := os.ReadFile(buildInfoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var oldBuildInfo []string
+	for line := range strings.Lines(string(buildInfo)) {
+		if !strings.HasPrefix(line, "BUILD_DOCKERFILE=") && !strings.HasPrefix(line, "BUILD_ENTRYPOINT=") &&
+			!strings.HasPrefix(line, "BUILD_DOTFILES=") {
+			oldBuildInfo = append(oldBuildInfo, line)
+		}
+	}
+	if err := os.WriteFile(buildInfoPath, []byte(strings.Join(oldBuildInfo, "")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = commands.Clone(env.ctx, args, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "older version") {
+		t.Fatalf("expected an error when reusing an image built by an older version, got %v", err)
+	}
+	if env.filestore.DoesProjectExist("other") {
+		t.Fatalf("no project should be created when the image cannot be reused")
+	}
+	err = commands.Save(env.ctx, []string{"main", "-o", filepath.Join(t.TempDir(), "main.tar")},
+		env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "older version") {
+		t.Errorf("expected an error when saving an image built by an older version, got %v", err)
+	}
+
+	if err := os.WriteFile(buildInfoPath, buildInfo, 0644); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(projectDir, "project.lock")
+	if err := os.WriteFile(lockPath, []byte("VERSION=1.0.0\nDOCKERFILE_VERSION=1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = commands.Clone(env.ctx, args, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "re-built") {
+		t.Fatalf("expected an error when reusing an image built for older base files, got %v", err)
+	}
+	if env.filestore.DoesProjectExist("other") {
+		t.Fatalf("no project should be created when the image cannot be reused")
+	}
+}
@@ -0,0 +1,622 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/manifest"
+)
+
+// Everything needed to call commands in isolation: a `FileStore` writing in
+// temporary directories and a `FakeEngine`.
+type testEnv struct {
+	ctx          context.Context
+	filestore    *files.FileStore
+	fakeEngine   *engine.FakeEngine
+	engineLoader *engine.Loader
+	out          *bytes.Buffer
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	t.Setenv("SUDO_USER", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	filestore, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	fakeEngine := engine.NewFakeEngine()
+	return &testEnv{
+		ctx:          context.Background(),
+		filestore:    filestore,
+		fakeEngine:   fakeEngine,
+		engineLoader: engine.NewLoaderWith(fakeEngine),
+		out:          &bytes.Buffer{},
+	}
+}
+
+// Create a `Console` which will answer prompts with the given `input` lines.
+func (e *testEnv) console(input ...string) *console.Console {
+	var rd strings.Reader
+	if len(input) > 0 {
+		rd.Reset(strings.Join(input, "\n") + "\n")
+	}
+	return console.New(e.ctx, &rd, e.out, e.out)
+}
+
+// Create the project `name`, mounting a temporary directory, without any
+// prompt.
+func (e *testEnv) createProject(t *testing.T, name string) {
+	t.Helper()
+	err := commands.Create([]string{t.TempDir(), "--no-prompt", "--name", name}, e.filestore, e.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !e.filestore.DoesProjectExist(name) {
+		t.Fatalf("project '%s' should exist after its creation", name)
+	}
+}
+
+// Create the project `name` as version 1.0.0 of paul-envs did, with the
+// resources of a previous build and run, which were not labeled at the time.
+func (e *testEnv) createLegacyProject(t *testing.T, name string) {
+	t.Helper()
+	e.createProject(t, name)
+	project, err := e.filestore.GetProject(name)
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	legacyFiles := map[string]string{".env": project.EnvFilePath, "compose.yaml": project.ComposeFilePath}
+	for fixture, path := range legacyFiles {
+		content, err := os.ReadFile(filepath.Join("..", "files", "testdata", "legacy-1.0.0", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, bytes.ReplaceAll(content, []byte("legacy"), []byte(name)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lockPath := filepath.Join(filepath.Dir(project.EnvFilePath), "project.lock")
+	if err := os.WriteFile(lockPath, []byte("VERSION=1.0.0\nDOCKERFILE_VERSION=1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e.fakeEngine.Images = append(e.fakeEngine.Images, engine.ImageInfo{ImageName: "paulenv:" + name})
+	e.fakeEngine.Volumes = append(e.fakeEngine.Volumes, engine.VolumeInfo{
+		VolumeId:   "paulenv-" + name + "-local",
+		VolumeName: "paulenv-" + name + "-local",
+	})
+	e.fakeEngine.Networks = append(e.fakeEngine.Networks, engine.NetworkInfo{
+		NetworkId:   name + "-network",
+		NetworkName: "paulenv-" + name + "_default",
+	})
+}
+
+func (e *testEnv) build(t *testing.T, name string) {
+	t.Helper()
+	if err := commands.Build(e.ctx, []string{name}, e.filestore, e.engineLoader, e.console()); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+}
+
+func TestFlow_CreateBuildRunRemoveClean(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.createProject(t, "other")
+
+	env.build(t, "myapp")
+	builds := env.fakeEngine.CallsTo("BuildImage")
+	if len(builds) != 1 || builds[0].Target != "myapp" {
+		t.Fatalf("expected a single build of myapp, got %+v", builds)
+	}
+	volumes := env.fakeEngine.CallsTo("CreateVolume")
+	if len(volumes) != 1 || volumes[0].Target != "paulenv-shared-cache" {
+		t.Errorf("expected the shared cache volume to be created, got %+v", volumes)
+	}
+
+	err := commands.Run(env.ctx, []string{"myapp", "echo", "hello"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	ups := env.fakeEngine.CallsTo("UpContainer")
+	if len(ups) != 1 || ups[0].Target != "myapp" {
+		t.Fatalf("unexpected up calls: %+v", ups)
+	}
+	joins := env.fakeEngine.CallsTo("JoinContainer")
+	if len(joins) != 1 || strings.Join(joins[0].Args, " ") != "echo hello" {
+		t.Fatalf("expected to join the started container, got %+v", joins)
+	}
+	if len(env.fakeEngine.CallsTo("BuildImage")) != 1 {
+		t.Errorf("an up-to-date project should not be re-built before running")
+	}
+	if len(env.fakeEngine.Volumes) != 2 {
+		t.Errorf("running should have created the local volume, got %+v", env.fakeEngine.Volumes)
+	}
+	if len(env.fakeEngine.CallsTo("DownContainer")) != 1 || len(env.fakeEngine.Containers) != 0 || len(env.fakeEngine.Networks) != 0 {
+		t.Errorf("the container should have been stopped when its only session exited, got %+v and %+v",
+			env.fakeEngine.Containers, env.fakeEngine.Networks)
+	}
+
+	// Accept the removal and the backup of its local volume
+	err = commands.Remove(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console("y", "y"))
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if backups, _ := os.ReadDir(env.filestore.GetBackupsDir()); len(backups) != 1 {
+		t.Errorf("the local volume should have been backed up, got %v", backups)
+	}
+	if env.filestore.DoesProjectExist("myapp") {
+		t.Error("removed project should not exist anymore")
+	}
+	if !env.filestore.DoesProjectExist("other") {
+		t.Error("other projects should not be removed")
+	}
+	if len(env.fakeEngine.Containers) != 0 || len(env.fakeEngine.Images) != 0 || len(env.fakeEngine.Networks) != 0 {
+		t.Errorf("project's container, image and network should be removed, got %+v, %+v and %+v",
+			env.fakeEngine.Containers, env.fakeEngine.Images, env.fakeEngine.Networks)
+	}
+	if len(env.fakeEngine.Volumes) != 1 || env.fakeEngine.Volumes[0].VolumeName != "paulenv-shared-cache" {
+		t.Errorf("only the shared cache volume should be left, got %+v", env.fakeEngine.Volumes)
+	}
+
+	env.build(t, "other")
+	// Accept all removals, including the build cache
+	err = commands.Clean(env.ctx, env.filestore, env.engineLoader, env.console("y", "y", "y", "y"))
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if env.filestore.DoesProjectExist("other") {
+		t.Error("clean should remove all projects")
+	}
+	if len(env.fakeEngine.Images) != 0 || len(env.fakeEngine.Volumes) != 0 {
+		t.Errorf("clean should remove all images and volumes, got %+v and %+v",
+			env.fakeEngine.Images, env.fakeEngine.Volumes)
+	}
+	if len(env.fakeEngine.CallsTo("PruneBuildCache")) != 1 {
+		t.Error("clean should have pruned the build cache")
+	}
+}
+
+func TestBuild_Failure(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	buildErr := errors.New("Build failed: exit status 1")
+	env.fakeEngine.FailOn("BuildImage", buildErr)
+
+	err := commands.Build(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if !errors.Is(err, buildErr) {
+		t.Fatalf("expected the build error, got %v", err)
+	}
+	if len(env.fakeEngine.Images) != 0 {
+		t.Errorf("no image should exist after a failed build, got %+v", env.fakeEngine.Images)
+	}
+
+	// Running should now propose to build first, which is refused here
+	err = commands.Run(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console("n"))
+	if err == nil || !strings.Contains(err.Error(), "paul-envs build myapp") {
+		t.Errorf("expected a hint to build first, got %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 0 {
+		t.Error("the container should not have been started")
+	}
+
+	// Accepting to build still fails
+	err = commands.Run(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console("y"))
+	if !errors.Is(err, buildErr) {
+		t.Errorf("expected the build error, got %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 0 {
+		t.Error("the container should not have been started")
+	}
+}
+
+func TestBuild_PermissionDenied(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.fakeEngine.FailOn("CreateVolume", errors.New("permission denied. Please run with elevated privileges"))
+
+	err := commands.Build(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected a permission error, got %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("BuildImage")) != 0 {
+		t.Error("the image should not have been built")
+	}
+}
+
+func TestBuild_UnknownProject(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Build(env.ctx, []string{"unknown"}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("BuildImage")) != 0 {
+		t.Error("nothing should have been built")
+	}
+}
+
+func TestRun_BuildsFirstWhenNeeded(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+
+	err := commands.Run(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console("y"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("BuildImage")) != 1 {
+		t.Error("the project should have been built first")
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 1 || len(env.fakeEngine.CallsTo("JoinContainer")) != 1 {
+		t.Error("the container should have been started and joined")
+	}
+}
+
+func TestRun_JoinsExistingContainer(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	container := env.fakeEngine.StartFakeContainer("myapp")
+
+	err := commands.Run(env.ctx, []string{"myapp", "ls"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	joins := env.fakeEngine.CallsTo("JoinContainer")
+	if len(joins) != 1 || joins[0].Target != container.ContainerId || strings.Join(joins[0].Args, " ") != "ls" {
+		t.Errorf("expected to join the running container, got %+v", joins)
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 0 {
+		t.Error("no new container should have been started")
+	}
+}
+
+func TestRemove_Declined(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+
+	err := commands.Remove(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console("n"))
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if !env.filestore.DoesProjectExist("myapp") {
+		t.Error("project should still exist")
+	}
+	if len(env.fakeEngine.Images) != 1 {
+		t.Error("image should still exist")
+	}
+}
+
+func TestRemove_LegacyProject(t *testing.T) {
+	env := newTestEnv(t)
+	env.createLegacyProject(t, "legacy")
+	unrelated := engine.VolumeInfo{VolumeId: "unrelated-local", VolumeName: "unrelated-local"}
+	env.fakeEngine.Volumes = append(env.fakeEngine.Volumes, unrelated)
+
+	// Confirm the removal, decline the volume backup
+	err := commands.Remove(env.ctx, []string{"legacy"}, env.filestore, env.engineLoader, env.console("y", "n"))
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if len(env.fakeEngine.Images) != 0 || len(env.fakeEngine.Networks) != 0 {
+		t.Errorf("unlabeled resources named after the project should be removed, got %+v and %+v",
+			env.fakeEngine.Images, env.fakeEngine.Networks)
+	}
+	if len(env.fakeEngine.Volumes) != 1 || env.fakeEngine.Volumes[0].VolumeName != unrelated.VolumeName {
+		t.Errorf("only the unrelated volume should be left, got %+v", env.fakeEngine.Volumes)
+	}
+}
+
+func TestRemove_PermissionDenied(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	env.fakeEngine.StartFakeContainer("myapp")
+	env.fakeEngine.FailOn("RemoveContainer", errors.New("permission denied. Please run with elevated privileges"))
+
+	err := commands.Remove(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console("y"))
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected a permission error, got %v", err)
+	}
+	if !env.filestore.DoesProjectExist("myapp") {
+		t.Error("project files should be kept when its container could not be removed")
+	}
+	if len(env.fakeEngine.CallsTo("RemoveImage")) != 0 {
+		t.Error("the image should not have been removed")
+	}
+}
+
+func TestClean_KeepsEverythingWhenDeclined(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+
+	err := commands.Clean(env.ctx, env.filestore, env.engineLoader, env.console("n", "n", "n", "n"))
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if !env.filestore.DoesProjectExist("myapp") {
+		t.Error("project should still exist")
+	}
+	if len(env.fakeEngine.Images) != 1 || len(env.fakeEngine.Volumes) != 1 {
+		t.Error("images and volumes should be kept")
+	}
+	if len(env.fakeEngine.CallsTo("PruneBuildCache")) != 0 {
+		t.Error("build cache should be kept")
+	}
+}
+
+func TestClean_ContinuesOnRemovalFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	env.fakeEngine.FailOn("RemoveImage", errors.New("image is being used"))
+
+	err := commands.Clean(env.ctx, env.filestore, env.engineLoader, env.console("y", "y", "y", "n"))
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if len(env.fakeEngine.Images) != 1 {
+		t.Error("the image should not have been removed")
+	}
+	if len(env.fakeEngine.Volumes) != 0 {
+		t.Error("volumes should still have been removed")
+	}
+	if !strings.Contains(env.out.String(), "image is being used") {
+		t.Errorf("expected a warning on the removal failure, got:\n%s", env.out.String())
+	}
+}
+
+func TestList(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "built")
+	env.createProject(t, "notbuilt")
+	env.build(t, "built")
+	env.out.Reset()
+
+	err := commands.List(env.ctx, []string{}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	output := env.out.String()
+	for _, expected := range []string{"built", "notbuilt", "paulenv:built", "Never", "Total: 2 projects"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in list output:\n%s", expected, output)
+		}
+	}
+
+	env.out.Reset()
+	err = commands.List(env.ctx, []string{"--names"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if env.out.String() != "built\nnotbuilt\n" {
+		t.Errorf("unexpected names output: %q", env.out.String())
+	}
+}
+
+func TestClean_IgnoresUnlabeledResources(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	unrelatedName := "paulenv-not-ours"
+	env.fakeEngine.Volumes = append(env.fakeEngine.Volumes, engine.VolumeInfo{
+		VolumeId:   unrelatedName,
+		VolumeName: unrelatedName,
+	})
+	env.fakeEngine.Networks = append(env.fakeEngine.Networks, engine.NetworkInfo{
+		NetworkId:   "unrelated",
+		NetworkName: unrelatedName,
+		Labels:      map[string]string{engine.LabelProject: "myapp"},
+	})
+
+	err := commands.Clean(env.ctx, env.filestore, env.engineLoader, env.console("y", "y", "y", "n"))
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if len(env.fakeEngine.Images) != 0 {
+		t.Errorf("the project's image should have been removed, got %+v", env.fakeEngine.Images)
+	}
+	if len(env.fakeEngine.Volumes) != 1 || env.fakeEngine.Volumes[0].VolumeName != unrelatedName {
+		t.Errorf("only the unrelated volume should be left, got %+v", env.fakeEngine.Volumes)
+	}
+	if len(env.fakeEngine.Networks) != 1 {
+		t.Errorf("the unrelated network should be left, got %+v", env.fakeEngine.Networks)
+	}
+}
+
+func TestCreate_LoadRoundTrip(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "myapp", "--shell", "zsh",
+		"--nodejs", "20.10.0", "--mise", "--neovim", "--enable-ssh", "--enable-sudo",
+		"--git-name", "John Doe", "--git-email", "john@example.com",
+		"--package", "ripgrep", "--port", "3000", "--volume", "/tmp:/tmp:ro"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg, err := env.filestore.LoadProjectConfig("myapp")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	rendered, err := files.RenderProjectFiles(files.NewProjectTemplateData(&cfg))
+	if err != nil {
+		t.Fatalf("RenderProjectFiles() error = %v", err)
+	}
+	current, err := env.filestore.ReadProjectFiles("myapp")
+	if err != nil {
+		t.Fatalf("ReadProjectFiles() error = %v", err)
+	}
+	if !bytes.Equal(rendered.Env, current.Env) || !bytes.Equal(rendered.Compose, current.Compose) {
+		t.Errorf("re-rendering a loaded configuration should give back the created files")
+	}
+}
+
+func TestCreate_SanitizesDirectoryName(t *testing.T) {
+	env := newTestEnv(t)
+	for dirName, want := range map[string]string{"MyApp": "myapp", "my.app": "my-app"} {
+		projectPath := filepath.Join(t.TempDir(), dirName)
+		if err := os.Mkdir(projectPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := commands.Create([]string{projectPath, "--no-prompt"}, env.filestore, env.console()); err != nil {
+			t.Fatalf("Create() on directory '%s' error = %v", dirName, err)
+		}
+		if !env.filestore.DoesProjectExist(want) {
+			t.Errorf("directory '%s' should give project '%s'", dirName, want)
+		}
+	}
+}
+
+func TestCreate_Manifest(t *testing.T) {
+	env := newTestEnv(t)
+	projectDir := t.TempDir()
+	manifestContent := "name = \"from-manifest\"\nports = [3000]\n\n[languages]\nnodejs = \"latest\"\n\n[tools]\nneovim = true\n"
+	if err := os.WriteFile(filepath.Join(projectDir, manifest.Filename), []byte(manifestContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags take precedence over the manifest
+	err := commands.Create([]string{projectDir, "--no-prompt", "--port", "8080", "--mise"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg, err := env.filestore.LoadProjectConfig("from-manifest")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if cfg.InstallNode != "latest" || !cfg.InstallNeovim || !cfg.InstallMise {
+		t.Errorf("manifest and flags should both be applied, got %+v", cfg)
+	}
+	if len(cfg.Ports) != 1 || cfg.Ports[0] != 8080 {
+		t.Errorf("--port should replace the manifest's ports, got %v", cfg.Ports)
+	}
+
+	err = commands.Create([]string{projectDir, "--no-prompt", "--name", "disabled", "--no-neovim"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg, err = env.filestore.LoadProjectConfig("disabled")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if cfg.InstallNeovim || cfg.InstallNode != "latest" {
+		t.Errorf("--no-neovim should disable the manifest's neovim, got %+v", cfg)
+	}
+
+	err = commands.Create([]string{projectDir, "--no-prompt", "--name", "both", "--neovim", "--no-neovim"}, env.filestore, env.console())
+	if err == nil {
+		t.Errorf("enabling and disabling a tool at the same time should fail")
+	}
+
+	err = commands.Create([]string{projectDir, "--no-prompt", "--no-manifest", "--name", "ignored"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg, err = env.filestore.LoadProjectConfig("ignored")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if cfg.InstallNeovim || cfg.InstallNode == "latest" {
+		t.Errorf("--no-manifest should ignore the manifest, got %+v", cfg)
+	}
+}
+
+func TestCreate_InvalidManifest(t *testing.T) {
+	env := newTestEnv(t)
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, manifest.Filename), []byte("\nports = [99999]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := commands.Create([]string{projectDir, "--no-prompt", "--name", "myapp"}, env.filestore, env.console())
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error pointing to line 2, got %v", err)
+	}
+	if env.filestore.DoesProjectExist("myapp") {
+		t.Errorf("no project should be created from an invalid manifest")
+	}
+}
+
+func TestCreate_UserDefaults(t *testing.T) {
+	env := newTestEnv(t)
+	defaults := [][]string{
+		{"default.shell", "zsh"},
+		{"default.nodejs", "20.10.0"},
+		{"default.mise", "true"},
+		{"default.sudo", "true"},
+		{"default.ssh", "true"},
+		{"default.ssh-key", "/keys/id.pub"},
+		{"default.git-name", "John Doe"},
+		{"default.packages", "ripgrep fzf"},
+		{"default.ports", "3000"},
+		{"default.volumes", "/a:/b:ro, /c:/d"},
+	}
+	for _, def := range defaults {
+		if err := commands.Config(append([]string{"set"}, def...), env.filestore, env.console()); err != nil {
+			t.Fatalf("Config(set %v) error = %v", def, err)
+		}
+	}
+	if err := commands.Config([]string{"set", "default.ports", "99999"}, env.filestore, env.console()); err == nil {
+		t.Errorf("setting an invalid default should fail")
+	}
+
+	checkDefaults := func(cfg config.Config) {
+		t.Helper()
+		if cfg.Shell != config.ShellZsh || cfg.InstallNode != "20.10.0" || !cfg.InstallMise ||
+			!cfg.EnableSudo || !cfg.EnableSsh || cfg.SshKeyPath != "/keys/id.pub" || cfg.GitName != "John Doe" {
+			t.Errorf("defaults should be applied, got %+v", cfg)
+		}
+		if !slices.Equal(cfg.Packages, []string{"ripgrep", "fzf"}) || !slices.Equal(cfg.Ports, []uint16{3000}) ||
+			!slices.Equal(cfg.Volumes, []string{"/a:/b:ro", "/c:/d"}) {
+			t.Errorf("default lists should be applied, got %v %v %v", cfg.Packages, cfg.Ports, cfg.Volumes)
+		}
+	}
+
+	err := commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "noprompt"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg, err := env.filestore.LoadProjectConfig("noprompt")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	checkDefaults(cfg)
+
+	// Defaults are the default answers of prompts: just press Enter everywhere
+	input := iotest.OneByteReader(strings.NewReader(strings.Repeat("\n", 20)))
+	cons := console.New(env.ctx, input, env.out, env.out)
+	if err := commands.Create([]string{t.TempDir(), "--name", "prompted"}, env.filestore, cons); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg, err = env.filestore.LoadProjectConfig("prompted")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	checkDefaults(cfg)
+
+	err = commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "overridden", "--shell", "fish", "--port", "8080",
+		"--no-sudo", "--no-ssh"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg, err = env.filestore.LoadProjectConfig("overridden")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if cfg.Shell != config.ShellFish || !slices.Equal(cfg.Ports, []uint16{8080}) || cfg.InstallNode != "20.10.0" ||
+		cfg.EnableSudo || cfg.EnableSsh || !cfg.InstallMise {
+		t.Errorf("flags should take precedence over defaults, got %+v", cfg)
+	}
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/args"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// A key that can be set in paul-envs' global configuration
+type globalConfigKey struct {
+	// Name used in the `config` command, e.g. "engine"
+	name string
+	// Name with which it is stored in the global configuration file
+	fileKey string
+	// Short description displayed by `config list`
+	description string
+	// Returns an error if the given value cannot be set for this key
+	validate func(string) error
+}
+
+var globalConfigKeys = append([]globalConfigKey{
+	{
+		name:        "engine",
+		fileKey:     "ENGINE",
+		description: "Default container engine (" + strings.Join(engine.SupportedEngines, ", ") + " or " + engine.AutoEngineName + ")",
+		validate:    engine.ValidateEngineName,
+	},
+}, userDefaultKeys()...)
+
+// Keys for the default configuration of new projects, e.g. "default.shell".
+func userDefaultKeys() []globalConfigKey {
+	keys := make([]globalConfigKey, 0, len(args.UserDefaults))
+	for _, def := range args.UserDefaults {
+		keys = append(keys, globalConfigKey{
+			name:        def.Name,
+			fileKey:     def.FileKey,
+			description: def.Description,
+			validate:    def.Validate,
+		})
+	}
+	return keys
+}
+
+func Config(args []string, filestore *files.FileStore, console *console.Console) error {
+	if len(args) == 0 {
+		return errors.New("no config action given. Use one of: get, set, unset, list")
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		values, err := filestore.ReadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		width := 0
+		for _, key := range globalConfigKeys {
+			width = max(width, len(key.name))
+		}
+		for _, key := range globalConfigKeys {
+			if value, ok := values[key.fileKey]; ok {
+				console.WriteLn("%-*s = %s", width, key.name, value)
+			} else {
+				console.WriteLn("%-*s (not set) %s", width, key.name, key.description)
+			}
+		}
+		console.WriteLn("")
+		console.WriteLn("Stored in: %s", filestore.GetGlobalConfigFilePath())
+		return nil
+	case "get":
+		if len(args) != 2 {
+			return errors.New("usage: paul-envs config get <key>")
+		}
+		key, err := getGlobalConfigKey(args[1])
+		if err != nil {
+			return err
+		}
+		value, err := filestore.GetGlobalConfigValue(key.fileKey)
+		if err != nil {
+			return err
+		}
+		console.WriteLn("%s", value)
+		return nil
+	case "set":
+		if len(args) != 3 {
+			return errors.New("usage: paul-envs config set <key> <value>")
+		}
+		key, err := getGlobalConfigKey(args[1])
+		if err != nil {
+			return err
+		}
+		if err := key.validate(args[2]); err != nil {
+			return err
+		}
+		if err := filestore.SetGlobalConfigValue(key.fileKey, args[2]); err != nil {
+			return err
+		}
+		console.Success("Set '%s' to '%s'", key.name, args[2])
+		return nil
+	case "unset":
+		if len(args) != 2 {
+			return errors.New("usage: paul-envs config unset <key>")
+		}
+		key, err := getGlobalConfigKey(args[1])
+		if err != nil {
+			return err
+		}
+		if err := filestore.UnsetGlobalConfigValue(key.fileKey); err != nil {
+			return err
+		}
+		console.Success("Unset '%s'", key.name)
+		return nil
+	default:
+		return fmt.Errorf("unknown config action '%s'. Use one of: get, set, unset, list", args[0])
+	}
+}
+
+func getGlobalConfigKey(name string) (globalConfigKey, error) {
+	names := make([]string, 0, len(globalConfigKeys))
+	for _, key := range globalConfigKeys {
+		if key.name == name {
+			return key, nil
+		}
+		names = append(names, key.name)
+	}
+	return globalConfigKey{}, fmt.Errorf("unknown config key '%s'. Must be one of: %s", name, strings.Join(names, ", "))
+}
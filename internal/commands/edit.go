@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/args"
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Matches the version header of a project's file, e.g.
+// "# Compose File Version: 1.1.0"
+var fileVersionHeaderRegexp = regexp.MustCompile(`^# .+ Version: \S+$`)
+
+// A project's file whose content differs from what paul-envs would generate
+// from its configuration, e.g. because it was manually edited.
+type editedFile struct {
+	path string
+	// Lines of that file which would be lost if it was re-generated
+	lostLines []string
+}
+
+func Edit(argsList []string, filestore *files.FileStore, console *console.Console) error {
+	var nameArgs []string
+	if len(argsList) > 0 && !strings.HasPrefix(argsList[0], "-") {
+		nameArgs, argsList = argsList[:1], argsList[1:]
+	}
+	project, err := getExistingProject(nameArgs, filestore, console, "edit")
+	if err != nil {
+		return err
+	}
+	name := project.ProjectName
+
+	current, err := filestore.ReadProjectFiles(name)
+	if err != nil {
+		return fmt.Errorf("failed to read files of project '%s': %w", name, err)
+	}
+	cfg, err := filestore.LoadProjectConfig(name)
+	if err != nil {
+		return err
+	}
+	newCfg, force, err := args.ParseEditFlags(argsList, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Manual edits which cannot be expressed as configuration (comments,
+	// supplementary compose keys...) would be lost by re-generating those files
+	regenerated, err := files.RenderProjectFiles(files.NewProjectTemplateData(&cfg))
+	if err != nil {
+		return err
+	}
+	updated, err := files.RenderProjectFiles(files.NewProjectTemplateData(&newCfg))
+	if err != nil {
+		return err
+	}
+	// Files written for older base files are re-generated even if unchanged,
+	// to obtain what was added since
+	lockStatus, _ := filestore.ValidateProjectLock(name)
+	isOutdated := lockStatus == files.ProjectLockOutdated || files.IsLegacyProjectFiles(current)
+	if !isOutdated && bytes.Equal(updated.Env, regenerated.Env) && bytes.Equal(updated.Compose, regenerated.Compose) {
+		console.Info("The configuration of project '%s' is unchanged.", name)
+		return nil
+	}
+
+	edited := findEditedFiles(current, regenerated, project)
+	var backedUpFiles []string
+	if len(edited) > 0 {
+		console.Warn("Some files of project '%s' have been manually edited in a way that cannot be kept:", name)
+		for _, file := range edited {
+			console.WriteLn("  %s:", file.path)
+			for _, line := range file.lostLines {
+				console.WriteLn("    - %s", line)
+			}
+			backedUpFiles = append(backedUpFiles, file.path)
+		}
+		if !force {
+			confirm, err := console.AskYesNo("Re-generate them anyway? Their current version will be kept with a '.bak' extension", false)
+			if err != nil {
+				return err
+			}
+			if !confirm {
+				return fmt.Errorf("edition of project '%s' aborted by user", name)
+			}
+		}
+	}
+
+	if err := filestore.UpdateProjectFiles(name, updated, backedUpFiles); err != nil {
+		return fmt.Errorf("failed to update project files: %w", err)
+	}
+	console.Success("Updated project '%s'", name)
+	for _, path := range backedUpFiles {
+		console.WriteLn("Previous version of %s kept as %s.bak", path, path)
+	}
+	warnAboutMise(&newCfg, console)
+	if cfg.Cache == config.CachePrivate && newCfg.Cache != config.CachePrivate {
+		console.WriteLn("Its private cache, volume '%s', is not used anymore. It will be removed with the project.",
+			config.CacheVolumeName(name, config.CachePrivate))
+	}
+	console.WriteLn("Those changes will only be applied once the project is re-built:")
+	console.WriteLn("  paul-envs build %s", name)
+	return nil
+}
+
+// Compare the current files of a project to the ones paul-envs would generate
+// from its configuration, returning those which have lines that would be lost.
+//
+// Files only missing lines, e.g. because they were generated before a new
+// option was added, are not returned.
+func findEditedFiles(current files.ProjectFilesContent, regenerated files.ProjectFilesContent, project files.ProjectEntry) []editedFile {
+	var edited []editedFile
+	if lostLines := missingLines(current.Env, regenerated.Env); len(lostLines) > 0 {
+		edited = append(edited, editedFile{path: project.EnvFilePath, lostLines: lostLines})
+	}
+	if lostLines := missingLines(current.Compose, regenerated.Compose); len(lostLines) > 0 {
+		edited = append(edited, editedFile{path: project.ComposeFilePath, lostLines: lostLines})
+	}
+	return edited
+}
+
+// Returns the non-empty lines of `content` which are not in `other`.
+//
+// Version headers (e.g. "# Compose File Version: 1.0.0") are written by
+// paul-envs and are never considered missing.
+func missingLines(content []byte, other []byte) []string {
+	otherLines := strings.Split(string(other), "\n")
+	var missing []string
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if fileVersionHeaderRegexp.MatchString(line) {
+			continue
+		}
+		if strings.TrimSpace(line) != "" && !slices.Contains(otherLines, line) {
+			missing = append(missing, strings.TrimSpace(line))
+		}
+	}
+	return missing
+}
+
+// Warn if the files of project `name` were written for an older version of the
+// base files, explaining how to update them.
+func warnAboutOutdatedProject(name string, lockStatus files.ProjectLockStatus, console *console.Console) {
+	if lockStatus == files.ProjectLockOutdated {
+		console.Warn("The files of project '%s' were written by an older version of paul-envs.", name)
+		console.WriteLn("Hint: Run 'paul-envs edit %s' to update them", name)
+	}
+}
+
+// Warn if exact language versions are wanted without mise, which is needed to
+// install them.
+func warnAboutMise(cfg *config.Config, console *console.Console) {
+	if cfg.InstallMise {
+		return
+	}
+	for _, version := range []string{cfg.InstallNode, cfg.InstallRust, cfg.InstallPython, cfg.InstallGo} {
+		if version != "" && version != config.VersionNone && version != config.VersionLatest {
+			console.Warn("Exact language versions require Mise to be installed (--mise). Without it, Ubuntu's default packages will be used instead.")
+			return
+		}
+	}
+}
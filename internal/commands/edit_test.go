@@ -0,0 +1,139 @@
+package commands_test
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestEdit(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "myapp",
+		"--nodejs", "latest", "--port", "3000", "--package", "ripgrep", "--neovim"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	env.out.Reset()
+
+	err = commands.Edit([]string{"myapp", "--nodejs", "22.0.0", "--mise", "--no-neovim", "--ssh",
+		"--port", "8080", "--remove-port", "3000", "--package", "jq"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	if !strings.Contains(env.out.String(), "paul-envs build myapp") {
+		t.Errorf("expected to be told to rebuild, got:\n%s", env.out.String())
+	}
+
+	cfg, err := env.filestore.LoadProjectConfig("myapp")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if cfg.InstallNode != "22.0.0" || !cfg.InstallMise || cfg.InstallNeovim || !cfg.EnableSsh {
+		t.Errorf("flags not applied: %+v", cfg)
+	}
+	if !slices.Equal(cfg.Ports, []uint16{8080}) {
+		t.Errorf("unexpected ports %v", cfg.Ports)
+	}
+	if !slices.Equal(cfg.Packages, []string{"ripgrep", "jq"}) {
+		t.Errorf("unexpected packages %v", cfg.Packages)
+	}
+
+	env.out.Reset()
+	if err := commands.Edit([]string{"myapp", "--mise"}, env.filestore, env.console()); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	if !strings.Contains(env.out.String(), "unchanged") {
+		t.Errorf("expected no change to be reported, got:\n%s", env.out.String())
+	}
+}
+
+func TestEdit_LegacyProject(t *testing.T) {
+	env := newTestEnv(t)
+	env.createLegacyProject(t, "legacy")
+	if status, _ := env.filestore.ValidateProjectLock("legacy"); status != files.ProjectLockOutdated {
+		t.Fatalf("expected files of version 1.0.0 to be outdated, got %s", status)
+	}
+
+	// Without any flag, files are re-generated for the current version
+	if err := commands.Edit([]string{"legacy"}, env.filestore, env.console()); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	content, err := env.filestore.ReadProjectFiles("legacy")
+	if err != nil {
+		t.Fatalf("ReadProjectFiles() error = %v", err)
+	}
+	if files.IsLegacyProjectFiles(content) || !strings.Contains(string(content.Compose), "PAULENV_KEEP_ALIVE") {
+		t.Errorf("compose file should have been re-generated:\n%s", content.Compose)
+	}
+	if status, _ := env.filestore.ValidateProjectLock("legacy"); status != files.ProjectLockValid {
+		t.Errorf("the re-generated files should be up to date, got %s", status)
+	}
+	cfg, err := env.filestore.LoadProjectConfig("legacy")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if !slices.Equal(cfg.Ports, []uint16{8080}) || !slices.Equal(cfg.Packages, []string{"ripgrep"}) {
+		t.Errorf("configuration should be kept, got %+v", cfg)
+	}
+}
+
+func TestEdit_ManualEdits(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	composePath := env.filestore.GetProjectComposeFilePath("myapp")
+	content, err := os.ReadFile(composePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := append(content, []byte("# my own comment\n")...)
+	if err := os.WriteFile(composePath, edited, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Declined: nothing is written
+	err = commands.Edit([]string{"myapp", "--zellij"}, env.filestore, env.console("n"))
+	if err == nil {
+		t.Fatal("expected the edition to be aborted")
+	}
+	if !strings.Contains(env.out.String(), "# my own comment") {
+		t.Errorf("expected the lost lines to be displayed, got:\n%s", env.out.String())
+	}
+	if current, _ := os.ReadFile(composePath); string(current) != string(edited) {
+		t.Error("the compose file should not have been updated")
+	}
+
+	// Accepted: the previous version is backed up
+	if err := commands.Edit([]string{"myapp", "--zellij"}, env.filestore, env.console("y")); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	backup, err := os.ReadFile(composePath + ".bak")
+	if err != nil || string(backup) != string(edited) {
+		t.Errorf("expected the edited compose file to be backed up, got %v", err)
+	}
+	cfg, err := env.filestore.LoadProjectConfig("myapp")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if !cfg.InstallZellij {
+		t.Error("expected zellij to be enabled")
+	}
+}
+
+func TestEdit_InvalidFlags(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	for _, args := range [][]string{
+		{"myapp", "--neovim", "--no-neovim"},
+		{"myapp", "--remove-package", "unknown"},
+		{"myapp", "--nodejs", "not a version"},
+		{"myapp", "--name", "other"},
+	} {
+		if err := commands.Edit(args, env.filestore, env.console()); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
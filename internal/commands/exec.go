@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Returns `true` if the given file is a terminal.
+func isTerminal(file *os.File) bool {
+	stat, err := file.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+func Exec(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	// Keep the standard output for the command's own output
+	console = console.WithErrOutput()
+
+	opts := engine.ExecOptions{}
+	flagset := flag.NewFlagSet("exec", flag.ContinueOnError)
+	flagset.StringVar(&opts.Workdir, "workdir", "", "Working directory of the command in the container")
+	flagset.StringVar(&opts.User, "user", "", "User running the command (e.g. root)")
+	flagset.Func("env", "Environment variable to set, as KEY=VALUE (can be repeated)", func(value string) error {
+		if key, _, ok := strings.Cut(value, "="); !ok || key == "" {
+			return fmt.Errorf("invalid environment variable '%s', expected KEY=VALUE", value)
+		}
+		opts.Env = append(opts.Env, value)
+		return nil
+	})
+
+	// Everything after "--" is the command, which may contain its own flags
+	flagArgs := args
+	var cmdArgs []string
+	if separatorIdx := slices.Index(args, "--"); separatorIdx >= 0 {
+		flagArgs = args[:separatorIdx]
+		cmdArgs = args[separatorIdx+1:]
+	}
+	positionals, err := parseInterspersedFlags(flagset, flagArgs)
+	if err != nil {
+		return err
+	}
+	if len(positionals) == 0 {
+		return errors.New("no project name given to 'exec'\nUsage: paul-envs exec <name> [options] -- <command> [args...]")
+	}
+	name := positionals[0]
+	opts.Args = append(positionals[1:], cmdArgs...)
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("no command given to 'exec'\nUsage: paul-envs exec %s [options] -- <command> [args...]", name)
+	}
+	opts.Tty = isTerminal(os.Stdin) && isTerminal(os.Stdout)
+
+	project, err := getExistingProject(positionals[:1], filestore, console, "exec")
+	if err != nil {
+		return err
+	}
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, project.ProjectName)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the '%s' project: %w", project.ProjectName, err)
+	}
+	if !hasBeenBuilt {
+		return fmt.Errorf("the '%s' project has not been built yet\nHint: Run 'paul-envs build %s' first", project.ProjectName, project.ProjectName)
+	}
+
+	return withProjectSession(ctx, project, containerEngine, filestore, console, func(container engine.ContainerInfo) error {
+		return containerEngine.ExecContainer(ctx, container, opts)
+	})
+}
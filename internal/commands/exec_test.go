@@ -0,0 +1,79 @@
+package commands_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestExec(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+
+	args := []string{"myapp", "--workdir", "/tmp", "--env", "A=1", "--env", "B=x=y", "--user", "root", "--", "ls", "-la", "--", "dir"}
+	err := commands.Exec(env.ctx, args, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if len(env.fakeEngine.Execs) != 1 {
+		t.Fatalf("expected a single command to be executed, got %+v", env.fakeEngine.Execs)
+	}
+	opts := env.fakeEngine.Execs[0]
+	if strings.Join(opts.Args, " ") != "ls -la -- dir" {
+		t.Errorf("unexpected command: %q", opts.Args)
+	}
+	if opts.Workdir != "/tmp" || opts.User != "root" || !slices.Equal(opts.Env, []string{"A=1", "B=x=y"}) {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts.Tty {
+		t.Error("no TTY should be allocated when not running in a terminal")
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 1 || len(env.fakeEngine.CallsTo("DownContainer")) != 1 {
+		t.Error("the container should have been started for that command, then stopped")
+	}
+}
+
+func TestExec_ExitCode(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	env.fakeEngine.ExecExitCode = 3
+
+	err := commands.Exec(env.ctx, []string{"myapp", "--", "false"}, env.filestore, env.engineLoader, env.console())
+	var exitCodeErr *engine.ExitCodeError
+	if !errors.As(err, &exitCodeErr) || exitCodeErr.ExitCode != 3 {
+		t.Fatalf("expected the command's exit code to be forwarded, got %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("DownContainer")) != 1 {
+		t.Error("the container should be stopped even if the command failed")
+	}
+}
+
+func TestExec_InvalidArguments(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+
+	tests := map[string][]string{
+		"no project":  {},
+		"no command":  {"myapp", "--"},
+		"invalid env": {"myapp", "--env", "NOVALUE", "--", "ls"},
+		"unknown":     {"unknown", "--", "ls"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := commands.Exec(env.ctx, args, env.filestore, env.engineLoader, env.console())
+			if err == nil {
+				t.Errorf("expected an error for arguments %q", args)
+			}
+		})
+	}
+	if len(env.fakeEngine.CallsTo("ExecContainer")) != 0 {
+		t.Error("nothing should have been executed")
+	}
+}
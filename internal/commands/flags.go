@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"flag"
+)
+
+// Parse `args` with the given `flagset`, allowing flags to be placed before or
+// after positional arguments, which are returned.
+//
+// Arguments placed after a "--" argument are all considered positional.
+func parseInterspersedFlags(flagset *flag.FlagSet, args []string) ([]string, error) {
+	var positionals []string
+	for {
+		if err := flagset.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flagset.Args()
+		if len(rest) == 0 {
+			return positionals, nil
+		}
+		// Parsing stopped on a "--" argument, which `flag` removes
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positionals, rest...), nil
+		}
+		positionals = append(positionals, rest[0])
+		args = rest[1:]
+	}
+}
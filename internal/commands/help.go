@@ -11,18 +11,64 @@ func Help(filestore *files.FileStore, console *console.Console) {
 
 Usage:
   paul-envs create <path> [options]
+  paul-envs edit <name> [options]
+  paul-envs rename <name> <new-name>
+  paul-envs export <name> [-o <file>] [--dotfiles]
+  paul-envs import <bundle> <path> [options]
+  paul-envs save <name> [-o <file>] [--no-volume]
+  paul-envs load <file> [--force]
+  paul-envs clone <name> <new-path> [--name <new-name>] [--reuse-image]
   paul-envs list
+  paul-envs status [name]
   paul-envs build <name>
   paul-envs run <name> [commands]
+  paul-envs exec <name> [options] -- <command> [args...]
+  paul-envs up <name>
+  paul-envs down <name>
   paul-envs remove <name>
+  paul-envs backup <name> [-o <file>]
+  paul-envs restore <name> <file> [--force]
+  paul-envs stop <name> [--timeout SECONDS] | --all
+  paul-envs kill <name> | --all
   paul-envs version
   paul-envs help
   paul-envs interactive
   paul-envs clean
+  paul-envs config <get|set|unset|list> [key] [value]
+  paul-envs preset <save|list|show|remove> [name] [--from <project>]
+  paul-envs cache <du|prune|reset> [options]
+  paul-envs upgrade-base [--force]
+
+Global options:
+  --engine ENGINE          Container engine to use: docker|docker-api|podman|auto
+                           By order of precedence, it is the value of this flag,
+                           then of the PAUL_ENVS_ENGINE environment variable,
+                           then the one set through 'paul-envs config set engine'.
+                           Auto-detected if none is set (docker first, then podman).
+  --output FORMAT          Output format of list, status, version and build:
+                           text (default), json or yaml. Documents are written to
+                           stdout, all other messages to stderr.
+  Global options are placed before the command or right after it, not after
+  the command's own arguments (e.g. 'paul-envs status --output json myapp').
+
+Options for exec:
+  --workdir DIR            Working directory of the command in the container
+  --env KEY=VALUE          Set an environment variable (can be repeated)
+  --user USER              Run the command as another user (e.g. root)
+  A TTY is only allocated if both the standard input and output are terminals.
+  The exit code of the command is forwarded as paul-envs' own exit code.
+
+Options for stop and kill:
+  --all                    Stop (or kill) the running containers of all projects
+  --timeout SECONDS        Only for stop: time let to the container to exit by itself
+                           before killing it (default: 10)
 
 Options for create (all optional):
   --no-prompt              Non-interactive mode (uses defaults)
   --name NAME              Name of this project (default: directory name)
+  --no-manifest            Ignore the project's .paul-envs.toml manifest
+  --preset NAME            Apply a saved preset (can be repeated, each one being
+                           layered on top of the previous ones)
   --uid UID                Container UID (default: current user - or 1000 on windows)
   --gid GID                Container GID (default: current group - or 1000 on windows)
   --username NAME          Container username (default: dev)
@@ -55,6 +101,11 @@ Options for create (all optional):
                            (prompted if not specified)
   --git-name NAME          Git user.name (optional)
   --git-email EMAIL        Git user.email (optional)
+  --cache MODE             Volume storing package caches (npm, pip, go...):
+                             'shared' - shared by all projects (default)
+                             'private' - only used by this project
+                             any other name - only shared by the projects of
+                             that cache group
   --neovim                 Install Neovim (text editor)
                            (prompted if no tool specified)
   --starship               Install Starship (prompt)
@@ -70,6 +121,128 @@ Options for create (all optional):
   --package PKG_NAME       Additional Ubuntu package (prompted if not specified, can be repeated)
   --port PORT              Expose container port (prompted if not specified, can be repeated)
   --volume HOST:CONT[:ro]  Mount volume (prompted if not specified, can be repeated)
+  --no-wasm, --no-ssh, --no-sudo, --no-neovim, --no-starship, --no-atuin,
+  --no-mise, --no-zellij, --no-jujutsu
+                           Disable the corresponding option, even if enabled by
+                           the manifest, a preset or your defaults
+  If the project's directory contains a .paul-envs.toml manifest, its values are
+  used unless overridden by flags.
+  Your own defaults can also be set with 'paul-envs config set default.<flag>'
+  (e.g. default.shell, default.git-name, default.packages). They are overridden
+  by the manifest and flags, and proposed as default answers when prompting.
+  See 'paul-envs config list' for all of them.
+
+Options for edit (alias: update):
+  Same as create (except --name and --no-prompt), only the given ones being
+  updated. --package, --port and --volume add an entry. Also:
+  --remove-package PKG     Remove an Ubuntu package (can be repeated)
+  --remove-port PORT       Stop exposing a container port (can be repeated)
+  --remove-volume VOLUME   Stop mounting a volume (can be repeated)
+  --force                  Re-generate manually edited files without asking
+  Manual edits that cannot be kept (e.g. comments) are listed before asking for
+  confirmation, and a copy of those files is kept with a ".bak" extension.
+  Files written by an older version of paul-envs are re-generated even
+  without any option.
+  The project has to be re-built for those changes to be applied.
+
+Options for rename:
+  Moves the project's directory, creates its image and local volume under the
+  new name (copying the data of that volume) then removes the previous ones.
+  Its container has to be stopped first.
+
+Options for export:
+  -o FILE                  Path of the written bundle (default: <name>.tar.gz)
+  --dotfiles               Also include paul-envs' dotfiles
+  --force                  Replace an existing file without asking
+  The project's path, UID/GID, SSH public key and git identity are not
+  exported: they are set again when importing it. Volumes mounted from
+  absolute host paths are kept, and listed as they may not exist elsewhere.
+
+Options for import:
+  --name NAME              Name of the new project (default: the path's
+                           directory name)
+  --uid UID, --gid GID     Container UID/GID (default: your default.uid and
+                           default.gid, or the current user's)
+  --git-name NAME          Git user.name (default: your default.git-name)
+  --git-email EMAIL        Git user.email (default: your default.git-email)
+  --ssh-key PATH           SSH public key to mount, if the exported project
+                           mounted one (default: the first one in ~/.ssh/)
+  --dotfiles               Add the bundle's dotfiles to yours, never replacing
+                           existing ones
+  Bundles created for an incompatible Dockerfile version are refused.
+
+Options for save:
+  -o FILE                  Path of the written archive
+                           (default: paulenv-<name>.tar)
+  --no-volume              Only save the image, not the project's local volume
+  --force                  Replace an existing file without asking
+  The image has to be built and up-to-date.
+
+Options for load:
+  --force                  Replace the project's existing local volume without
+                           asking
+  The project the image was saved for has to exist (e.g. imported from a
+  bundle). If its configuration is the same, it is then considered as built.
+
+Options for backup:
+  -o FILE                  Path of the written .tar.gz backup (default: a new
+                           file in the backups directory)
+  --force                  Replace an existing file without asking
+  Backs up the project's local volume (shell history, tools' data...). The
+  backups directory, next to paul-envs' data directory, is also where 'remove'
+  and 'clean' write the backups they offer to make before removing volumes.
+
+Options for restore:
+  --force                  Replace the project's existing local volume without
+                           asking
+  The project's container has to be stopped and its image built.
+
+Options for clone:
+  --name NAME              Name of the new project (default: the new path's
+                           directory name)
+  --reuse-image            Create the new project's image from the existing
+                           project's one instead of building it. That image
+                           has to be built and up-to-date, images built by
+                           older versions of paul-envs are never reused.
+  The configuration is copied as is, with only the project's name, mounted
+  path and local volume being changed. Useful for several checkouts or git
+  worktrees of the same repository.
+
+Options for preset:
+  save <name> --from <project>
+                           Save the configuration of an existing project as a
+                           preset. Only what is enabled is saved, so presets can
+                           be layered. --force replaces an existing one.
+  list [--names]           List saved presets
+  show <name>              Display the content of a preset
+  remove <name>            Remove a preset
+  Presets are stored in the config directory, in the same format than a
+  .paul-envs.toml manifest. They take precedence over the project's manifest,
+  while flags take precedence over presets.
+
+Options for cache:
+  du                       Display the size of each directory of the cache
+                           shared by all projects (npm, yarn, pip, go, xdg)
+  prune [--older-than AGE] [--only DIRS] [--force]
+                           Remove the content of the shared cache, optionally
+                           only files not accessed for AGE (e.g. 30d, 2w, 12h)
+                           and only for some comma-separated directories
+                           (e.g. npm,pip). Without those, confirmation is asked
+                           (unless --force) and no container using it can be
+                           running.
+  reset [--force]          Empty the shared cache, then fill it again with the
+                           initial cache of each built image using it. No
+                           container using it can be running.
+  --project NAME           Act on the cache used by that project instead, e.g.
+                           its private cache or the one of its cache group
+  The image of a built project using that cache is used to access it.
+
+Options for upgrade-base:
+  --force                  Replace the base files without asking
+  Displays the differences between the base Dockerfile and entrypoint.sh files
+  and the ones of this version, then replaces them. Modified files are first
+  copied next to them with an '.old' extension. Outdated files which were not
+  modified are already replaced automatically.
 
 Windows/Git Bash Notes:
   - UID/GID default to 1000 on Windows (Docker Desktop requirement)
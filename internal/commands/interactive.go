@@ -5,10 +5,11 @@ import (
 	"strings"
 
 	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
 	"github.com/peaberberian/paul-envs/internal/files"
 )
 
-func Interactive(ctx context.Context, fs *files.FileStore, c *console.Console) error {
+func Interactive(ctx context.Context, fs *files.FileStore, engineLoader *engine.Loader, c *console.Console) error {
 	for {
 		c.WriteLn("")
 		c.Info("Available commands:")
@@ -38,17 +39,17 @@ func Interactive(ctx context.Context, fs *files.FileStore, c *console.Console) e
 			}
 			cmdErr = Create([]string{path}, fs, c)
 		case "2", "list", "ls":
-			cmdErr = List(ctx, []string{}, fs, c)
+			cmdErr = List(ctx, []string{}, fs, engineLoader, c)
 		case "3", "build":
-			cmdErr = Build(ctx, []string{}, fs, c)
+			cmdErr = Build(ctx, []string{}, fs, engineLoader, c)
 		case "4", "run":
-			cmdErr = Run(ctx, []string{}, fs, c)
+			cmdErr = Run(ctx, []string{}, fs, engineLoader, c)
 		case "5", "remove", "rm":
-			cmdErr = Remove(ctx, []string{}, fs, c)
+			cmdErr = Remove(ctx, []string{}, fs, engineLoader, c)
 		case "6", "version":
-			cmdErr = Version(ctx, c)
+			cmdErr = Version(ctx, engineLoader, c)
 		case "7", "clean":
-			cmdErr = Clean(ctx, fs, c)
+			cmdErr = Clean(ctx, fs, engineLoader, c)
 		case "8", "exit", "quit", "q":
 			c.Success("Goodbye!")
 			return nil
@@ -10,7 +10,7 @@ import (
 	"github.com/peaberberian/paul-envs/internal/files"
 )
 
-func List(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+func List(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
 	nameOnly := false
 	flagset := flag.NewFlagSet("list", flag.ContinueOnError)
 	flagset.BoolVar(&nameOnly, "names", false, "Only display names")
@@ -23,11 +23,15 @@ func List(ctx context.Context, args []string, filestore *files.FileStore, consol
 		return fmt.Errorf("could not list all projects: %w", err)
 	}
 
-	containerEngine, err := engine.New(ctx)
+	containerEngine, err := engineLoader.Get(ctx)
 	if err != nil {
 		console.Warn("Could not instantiate container engine: %w", err)
 	}
 
+	if console.IsStructuredOutput() {
+		return writeListOutput(ctx, entries, containerEngine, engineLoader, console)
+	}
+
 	var lastImageInfoWarning error = nil
 	if len(entries) == 0 {
 		console.WriteLn("  (no project found)")
@@ -61,6 +65,35 @@ func List(ctx context.Context, args []string, filestore *files.FileStore, consol
 	return nil
 }
 
+// Write the output of the `list` command in a machine-readable format.
+//
+// `containerEngine` may be `nil` if it could not be instantiated.
+func writeListOutput(ctx context.Context, entries []files.ProjectEntry, containerEngine engine.ContainerEngine, engineLoader *engine.Loader, console *console.Console) error {
+	output := listOutput{
+		SchemaVersion: outputSchemaVersion(),
+		Projects:      make([]projectOutput, 0, len(entries)),
+	}
+	if containerEngine != nil {
+		if info, err := containerEngine.Info(ctx); err != nil {
+			console.Warn("Could not obtain container engine information: %s", err)
+		} else {
+			output.Engine = newEngineOutput(info, engineLoader.Selection())
+		}
+	}
+	for _, entry := range entries {
+		var imageInfo *engine.ImageInfo
+		if containerEngine != nil {
+			var err error
+			imageInfo, err = containerEngine.GetImageInfo(ctx, entry.ProjectName)
+			if err != nil {
+				console.Warn("Could not obtain image info for project '%s': %s", entry.ProjectName, err)
+			}
+		}
+		output.Projects = append(output.Projects, newProjectOutput(entry, imageInfo))
+	}
+	return console.WriteData(output)
+}
+
 func printProjectInfo(projectEntry files.ProjectEntry, imageInfo *engine.ImageInfo, console *console.Console) bool {
 	console.Info("%s", projectEntry.ProjectName)
 	console.WriteLn("  Mounted project   : %s", projectEntry.ProjectPath)
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"time"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Documents written by commands when a machine-readable output format (e.g.
+// `--output json`) is wanted.
+//
+// Their format is versioned through `versions.OutputSchemaVersion`, which is
+// part of each document as `schemaVersion`: fields may be added in a new minor
+// version, but a removal or change of meaning needs a new major.
+
+// Output of the `list` command.
+type listOutput struct {
+	SchemaVersion string `json:"schemaVersion"`
+	// `nil` if the container engine could not be reached
+	Engine   *engineOutput   `json:"engine"`
+	Projects []projectOutput `json:"projects"`
+}
+
+// Output of the `status` command.
+type statusOutput struct {
+	SchemaVersion string                `json:"schemaVersion"`
+	Engine        *engineOutput         `json:"engine"`
+	Projects      []projectStatusOutput `json:"projects"`
+}
+
+// Output of the `version` command.
+type versionOutput struct {
+	SchemaVersion string        `json:"schemaVersion"`
+	Version       string        `json:"version"`
+	Engine        *engineOutput `json:"engine"`
+}
+
+// Output of the `build` command, written once the build succeeded.
+type buildOutput struct {
+	SchemaVersion string        `json:"schemaVersion"`
+	Project       projectOutput `json:"project"`
+	Engine        *engineOutput `json:"engine"`
+}
+
+// Information on the container engine used.
+type engineOutput struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Where the choice of that engine comes from: "auto", "config", "env"
+	// or "flag"
+	Selection string `json:"selection"`
+}
+
+// Static information on a project.
+type projectOutput struct {
+	Name            string `json:"name"`
+	ProjectPath     string `json:"projectPath"`
+	EnvFilePath     string `json:"envFilePath"`
+	ComposeFilePath string `json:"composeFilePath"`
+	// `nil` if unknown
+	Image *imageOutput `json:"image"`
+}
+
+type imageOutput struct {
+	Name string `json:"name"`
+	// `nil` if it has never been built
+	BuiltAt *time.Time `json:"builtAt"`
+}
+
+// Information on a project and its current state.
+type projectStatusOutput struct {
+	projectOutput
+	Build       buildStateOutput  `json:"build"`
+	ProjectLock projectLockOutput `json:"projectLock"`
+	// `nil` if the project has no container
+	Container *containerOutput `json:"container"`
+	// `nil` if unknown
+	Ports []string `json:"ports"`
+	// Size in bytes of the project's local volume, `nil` if unknown
+	LocalVolumeSize *int64 `json:"localVolumeSize"`
+}
+
+type buildStateOutput struct {
+	Built        bool `json:"built"`
+	NeedsRebuild bool `json:"needsRebuild"`
+	// Set if `NeedsRebuild` is `true`
+	RebuildReason *string `json:"rebuildReason"`
+	// Set if we could not check whether a rebuild is needed
+	Error *string `json:"error"`
+}
+
+type projectLockOutput struct {
+	Valid  bool    `json:"valid"`
+	Status string  `json:"status"`
+	Error  *string `json:"error"`
+}
+
+type containerOutput struct {
+	Id        string     `json:"id"`
+	Name      *string    `json:"name"`
+	State     string     `json:"state"`
+	Running   bool       `json:"running"`
+	StartedAt *time.Time `json:"startedAt"`
+	// Number of interactive sessions attached to it, `nil` if unknown or if
+	// it is not running
+	Sessions  *int `json:"sessions"`
+	KeptAlive bool `json:"keptAlive"`
+}
+
+func newEngineOutput(info engine.EngineInfo, selection engine.Selection) *engineOutput {
+	var selectionStr string
+	switch selection.Source {
+	case engine.SelectionConfig:
+		selectionStr = "config"
+	case engine.SelectionEnv:
+		selectionStr = "env"
+	case engine.SelectionFlag:
+		selectionStr = "flag"
+	default:
+		selectionStr = "auto"
+	}
+	return &engineOutput{Name: info.Name, Version: info.Version, Selection: selectionStr}
+}
+
+func newProjectOutput(project files.ProjectEntry, imageInfo *engine.ImageInfo) projectOutput {
+	output := projectOutput{
+		Name:            project.ProjectName,
+		ProjectPath:     project.ProjectPath,
+		EnvFilePath:     project.EnvFilePath,
+		ComposeFilePath: project.ComposeFilePath,
+	}
+	if imageInfo != nil {
+		output.Image = &imageOutput{Name: imageInfo.ImageName, BuiltAt: imageInfo.BuiltAt}
+	}
+	return output
+}
+
+func newProjectStatusOutput(status projectStatus, imageInfo *engine.ImageInfo) projectStatusOutput {
+	output := projectStatusOutput{
+		projectOutput: newProjectOutput(status.project, imageInfo),
+		Build: buildStateOutput{
+			Built:        status.built,
+			NeedsRebuild: status.needsRebuild,
+			Error:        errorString(status.rebuildErr),
+		},
+		ProjectLock: projectLockOutput{
+			Valid:  status.lockStatus.IsValid(),
+			Status: status.lockStatus.String(),
+			Error:  errorString(status.lockErr),
+		},
+		Ports: status.ports,
+	}
+	if status.needsRebuild {
+		reason := status.rebuildReason.String()
+		output.Build.RebuildReason = &reason
+	}
+	if status.container != nil {
+		output.Container = &containerOutput{
+			Id:        status.container.ContainerId,
+			Name:      status.container.ContainerName,
+			State:     status.container.State,
+			Running:   isContainerRunning(*status.container),
+			StartedAt: status.container.StartedAt,
+			KeptAlive: status.keptAlive,
+		}
+		if status.sessionCount >= 0 {
+			output.Container.Sessions = &status.sessionCount
+		}
+	}
+	if status.localVolumeSize >= 0 {
+		output.LocalVolumeSize = &status.localVolumeSize
+	}
+	return output
+}
+
+// Returns the message of the given error, or `nil` if there's no error.
+func errorString(err error) *string {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	return &message
+}
+
+func outputSchemaVersion() string {
+	return versions.OutputSchemaVersion.ToString()
+}
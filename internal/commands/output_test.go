@@ -0,0 +1,136 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/console"
+)
+
+// Create a `Console` outputting JSON documents to the returned buffer, human
+// messages still being written to `e.out`.
+func (e *testEnv) jsonConsole() (*console.Console, *bytes.Buffer) {
+	stdout := &bytes.Buffer{}
+	c := console.New(e.ctx, strings.NewReader(""), stdout, e.out)
+	c.SetOutputFormat(console.OutputJSON)
+	return c, stdout
+}
+
+func TestList_JSON(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.createProject(t, "other")
+	env.build(t, "myapp")
+
+	c, stdout := env.jsonConsole()
+	if err := commands.List(env.ctx, nil, env.filestore, env.engineLoader, c); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var output struct {
+		SchemaVersion string `json:"schemaVersion"`
+		Engine        struct {
+			Name      string `json:"name"`
+			Selection string `json:"selection"`
+		} `json:"engine"`
+		Projects []struct {
+			Name        string `json:"name"`
+			ProjectPath string `json:"projectPath"`
+			Image       *struct {
+				Name    string  `json:"name"`
+				BuiltAt *string `json:"builtAt"`
+			} `json:"image"`
+		} `json:"projects"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout.String())
+	}
+	if output.SchemaVersion != "1.0.0" {
+		t.Errorf("unexpected schema version %q", output.SchemaVersion)
+	}
+	if output.Engine.Name != "fake" || output.Engine.Selection != "auto" {
+		t.Errorf("unexpected engine information: %+v", output.Engine)
+	}
+	if len(output.Projects) != 2 || output.Projects[0].Name != "myapp" || output.Projects[1].Name != "other" {
+		t.Fatalf("unexpected projects: %+v", output.Projects)
+	}
+	if output.Projects[0].ProjectPath == "" || output.Projects[0].Image == nil || output.Projects[0].Image.BuiltAt == nil {
+		t.Errorf("expected the path and image of the built project, got %+v", output.Projects[0])
+	}
+	if image := output.Projects[1].Image; image != nil && image.BuiltAt != nil {
+		t.Errorf("a project never built should have no build date, got %+v", image)
+	}
+}
+
+func TestStatus_JSON(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	env.fakeEngine.StartFakeContainer("myapp")
+	env.fakeEngine.VolumeSizes["paulenv-myapp-local"] = 1000
+
+	c, stdout := env.jsonConsole()
+	if err := commands.Status(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, c); err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	var output struct {
+		Projects []struct {
+			Name  string `json:"name"`
+			Build struct {
+				Built        bool `json:"built"`
+				NeedsRebuild bool `json:"needsRebuild"`
+			} `json:"build"`
+			ProjectLock struct {
+				Valid bool `json:"valid"`
+			} `json:"projectLock"`
+			Container *struct {
+				Running  bool `json:"running"`
+				Sessions *int `json:"sessions"`
+			} `json:"container"`
+			LocalVolumeSize *int64 `json:"localVolumeSize"`
+		} `json:"projects"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout.String())
+	}
+	if len(output.Projects) != 1 {
+		t.Fatalf("expected a single project, got %+v", output.Projects)
+	}
+	project := output.Projects[0]
+	if project.Name != "myapp" || !project.Build.Built || project.Build.NeedsRebuild || !project.ProjectLock.Valid {
+		t.Errorf("unexpected project status: %+v", project)
+	}
+	if project.Container == nil || !project.Container.Running || project.Container.Sessions == nil || *project.Container.Sessions != 1 {
+		t.Errorf("unexpected container status: %+v", project.Container)
+	}
+	if project.LocalVolumeSize == nil || *project.LocalVolumeSize != 1000 {
+		t.Errorf("unexpected local volume size: %v", project.LocalVolumeSize)
+	}
+}
+
+func TestBuild_JSON(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.out.Reset()
+
+	c, stdout := env.jsonConsole()
+	if err := commands.Build(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, c); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	var output struct {
+		Project struct {
+			Name string `json:"name"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout.String())
+	}
+	if output.Project.Name != "myapp" {
+		t.Errorf("unexpected project in output: %+v", output.Project)
+	}
+	if !strings.Contains(env.out.String(), "Built project 'myapp'") {
+		t.Errorf("human messages should be written to the error output, got %q", env.out.String())
+	}
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/manifest"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Preset(args []string, filestore *files.FileStore, console *console.Console) error {
+	if len(args) == 0 {
+		return errors.New("no preset action given. Use one of: save, list, show, remove")
+	}
+
+	switch args[0] {
+	case "save":
+		return savePreset(args[1:], filestore, console)
+	case "list", "ls":
+		namesOnly := len(args) == 2 && args[1] == "--names"
+		if len(args) != 1 && !namesOnly {
+			return errors.New("usage: paul-envs preset list [--names]")
+		}
+		names, err := filestore.GetAllPresets()
+		if err != nil {
+			return err
+		}
+		if namesOnly {
+			for _, name := range names {
+				console.WriteLn("%s", name)
+			}
+			return nil
+		}
+		if len(names) == 0 {
+			console.WriteLn("No preset saved.")
+			console.WriteLn("Hint: Save one with 'paul-envs preset save <name> --from <project>'")
+			return nil
+		}
+		for _, name := range names {
+			console.WriteLn("%s", name)
+		}
+		console.WriteLn("")
+		console.WriteLn("Stored in: %s", filestore.GetPresetsDir())
+		return nil
+	case "show":
+		if len(args) != 2 {
+			return errors.New("usage: paul-envs preset show <name>")
+		}
+		if err := utils.ValidateProjectName(args[1]); err != nil {
+			return fmt.Errorf("invalid preset name: %w", err)
+		}
+		content, err := filestore.ReadPreset(args[1])
+		if err != nil {
+			return err
+		}
+		console.WriteLn("# %s", filestore.GetPresetFilePath(args[1]))
+		console.WriteLn("%s", content)
+		return nil
+	case "remove", "rm":
+		if len(args) != 2 {
+			return errors.New("usage: paul-envs preset remove <name>")
+		}
+		if err := utils.ValidateProjectName(args[1]); err != nil {
+			return fmt.Errorf("invalid preset name: %w", err)
+		}
+		if err := filestore.RemovePreset(args[1]); err != nil {
+			return err
+		}
+		console.Success("Removed preset '%s'", args[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown preset action '%s'. Use one of: save, list, show, remove", args[0])
+	}
+}
+
+// Save the configuration of an existing project as a preset.
+func savePreset(args []string, filestore *files.FileStore, console *console.Console) error {
+	var from string
+	var force bool
+	flagset := flag.NewFlagSet("preset save", flag.ContinueOnError)
+	flagset.StringVar(&from, "from", "", "Project whose configuration is saved")
+	flagset.BoolVar(&force, "force", false, "Replace an existing preset without asking")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 || from == "" {
+		return errors.New("usage: paul-envs preset save <name> --from <project>")
+	}
+	name := positionals[0]
+	if err := utils.ValidateProjectName(name); err != nil {
+		return fmt.Errorf("invalid preset name: %w", err)
+	}
+
+	project, err := getExistingProject([]string{from}, filestore, console, "save as a preset")
+	if err != nil {
+		return err
+	}
+	cfg, err := filestore.LoadProjectConfig(project.ProjectName)
+	if err != nil {
+		return err
+	}
+
+	if filestore.DoesPresetExist(name) && !force {
+		confirm, err := console.AskYesNo(fmt.Sprintf("Preset '%s' already exists. Replace it?", name), false)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			return fmt.Errorf("saving of preset '%s' aborted by user", name)
+		}
+	}
+
+	content := fmt.Appendf(nil, "# paul-envs preset, saved from project '%s'.\n"+
+		"# Same format than a project's %s manifest.\n", project.ProjectName, manifest.Filename)
+	content = append(content, manifest.FromConfig(&cfg).Encode()...)
+	if err := filestore.WritePreset(name, content); err != nil {
+		return err
+	}
+	console.Success("Saved the configuration of project '%s' as preset '%s'", project.ProjectName, name)
+	console.WriteLn("Use it with:")
+	console.WriteLn("  paul-envs create <path> --preset %s", name)
+	return nil
+}
@@ -0,0 +1,84 @@
+package commands_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+)
+
+func TestPreset_SaveAndLayer(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "front",
+		"--nodejs", "latest", "--neovim", "--starship", "--package", "jq"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	err = commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "wasm",
+		"--rust", "latest", "--enable-wasm", "--package", "binaryen"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, preset := range [][]string{{"frontend", "front"}, {"rust-wasm", "wasm"}} {
+		err := commands.Preset([]string{"save", preset[0], "--from", preset[1]}, env.filestore, env.console())
+		if err != nil {
+			t.Fatalf("Preset(save) error = %v", err)
+		}
+	}
+
+	env.out.Reset()
+	if err := commands.Preset([]string{"list"}, env.filestore, env.console()); err != nil {
+		t.Fatalf("Preset(list) error = %v", err)
+	}
+	if !strings.Contains(env.out.String(), "frontend\nrust-wasm\n") {
+		t.Errorf("both presets should be listed, got:\n%s", env.out.String())
+	}
+
+	err = commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "both",
+		"--preset", "frontend", "--preset", "rust-wasm", "--mise"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg, err := env.filestore.LoadProjectConfig("both")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if cfg.InstallNode != "latest" || cfg.InstallRust != "latest" || !cfg.EnableWasm ||
+		!cfg.InstallNeovim || !cfg.InstallStarship || !cfg.InstallMise {
+		t.Errorf("both presets and flags should be applied, got %+v", cfg)
+	}
+	if !slices.Equal(cfg.Packages, []string{"jq", "binaryen"}) {
+		t.Errorf("packages of both presets should be merged, got %v", cfg.Packages)
+	}
+
+	err = commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "other", "--preset", "unknown"},
+		env.filestore, env.console())
+	if err == nil || !strings.Contains(err.Error(), "unknown preset") {
+		t.Errorf("expected an unknown preset error, got %v", err)
+	}
+
+	err = commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "escaping", "--preset", "../presets/frontend"},
+		env.filestore, env.console())
+	if err == nil || !strings.Contains(err.Error(), "invalid preset name") {
+		t.Errorf("expected an invalid preset name error, got %v", err)
+	}
+}
+
+func TestPreset_SaveExisting(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	if err := commands.Preset([]string{"save", "base", "--from", "myapp"}, env.filestore, env.console()); err != nil {
+		t.Fatalf("Preset(save) error = %v", err)
+	}
+	err := commands.Preset([]string{"save", "base", "--from", "myapp"}, env.filestore, env.console("n"))
+	if err == nil {
+		t.Errorf("replacing a preset should be cancellable")
+	}
+	if err := commands.Preset([]string{"save", "base", "--from", "myapp", "--force"}, env.filestore, env.console()); err != nil {
+		t.Errorf("--force should replace the preset, got %v", err)
+	}
+	if err := commands.Preset([]string{"save", "other", "--from", "unknown"}, env.filestore, env.console()); err == nil {
+		t.Errorf("saving a preset from an unknown project should fail")
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/engine"
 	"github.com/peaberberian/paul-envs/internal/files"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
-func Remove(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+func Remove(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
 	var name string
 	if len(args) == 0 {
 		console.WriteLn("No project name given, listing projects...")
@@ -57,10 +60,14 @@ func Remove(ctx context.Context, args []string, filestore *files.FileStore, cons
 		return nil
 	}
 
-	containerEngine, err := engine.New(ctx)
+	containerEngine, err := engineLoader.Get(ctx)
 	if err != nil {
 		return err
 	}
+	// The volume is backed up through the project's image, so before removing it
+	if err := offerVolumeBackups(ctx, []string{name}, filestore, containerEngine, console); err != nil {
+		return err
+	}
 	err = removeContainer(ctx, name, containerEngine, console)
 	if err != nil {
 		return err
@@ -73,6 +80,10 @@ func Remove(ctx context.Context, args []string, filestore *files.FileStore, cons
 	if err != nil {
 		return err
 	}
+	err = removeCacheVolume(ctx, name, filestore, containerEngine, console)
+	if err != nil {
+		return err
+	}
 	err = removeNetwork(ctx, name, containerEngine, console)
 	if err != nil {
 		return err
@@ -134,7 +145,7 @@ func removeVolume(ctx context.Context, projectName string, containerEngine engin
 		return fmt.Errorf("cannot list current volumes: %w", err)
 	}
 	for _, volume := range volumes {
-		if volume.VolumeName == fmt.Sprintf("paulenv-%s-local", projectName) {
+		if volume.ProjectName != nil && *volume.ProjectName == projectName {
 			if err := containerEngine.RemoveVolume(ctx, volume); err != nil {
 				return err
 			}
@@ -146,6 +157,73 @@ func removeVolume(ctx context.Context, projectName string, containerEngine engin
 	return nil
 }
 
+// Remove the cache volume of project `projectName` if it is private or if
+// it is the one of a cache group no other project belongs to. The shared cache
+// is always kept.
+//
+// Must be called before removing the project's files, which indicate which
+// cache it uses.
+func removeCacheVolume(ctx context.Context, projectName string, filestore *files.FileStore, containerEngine engine.ContainerEngine, console *console.Console) error {
+	// A private cache only belongs to this project, even if it is not used
+	// anymore since an edit
+	privateVolume, err := findPrivateCacheVolume(ctx, containerEngine, projectName)
+	if err != nil {
+		return err
+	}
+	if privateVolume != nil {
+		if err := containerEngine.RemoveVolume(ctx, *privateVolume); err != nil {
+			return err
+		}
+		console.Success("Removed '%s' volume with success!", privateVolume.VolumeName)
+	}
+	cache, err := filestore.GetProjectCache(projectName)
+	if err != nil || cache == config.CachePrivate {
+		return nil
+	}
+	cacheVolume := config.CacheVolumeName(projectName, cache)
+	if cacheVolume == sharedCacheVolume {
+		return nil
+	}
+	users, err := findCacheVolumeUsers(cacheVolume, filestore)
+	if err != nil {
+		return err
+	}
+	users = slices.DeleteFunc(users, func(name string) bool { return name == projectName })
+	if len(users) > 0 {
+		console.Info("Keeping cache volume '%s', still used by: %s", cacheVolume, strings.Join(users, ", "))
+		return nil
+	}
+	return removeNamedVolume(ctx, cacheVolume, containerEngine, console)
+}
+
+// Find the private cache volume of project `projectName`, returning `nil` if
+// it does not exist.
+func findPrivateCacheVolume(ctx context.Context, containerEngine engine.ContainerEngine, projectName string) (*engine.VolumeInfo, error) {
+	volume, err := findVolume(ctx, containerEngine, config.CacheVolumeName(projectName, config.CachePrivate))
+	if err != nil || volume == nil {
+		return nil, err
+	}
+	// Its labels, when set, have to confirm that it belongs to this project
+	if kind, ok := volume.Labels[engine.LabelCacheKind]; ok &&
+		(kind != engine.CacheKindPrivate || volume.Labels[engine.LabelCacheOwner] != projectName) {
+		return nil, nil
+	}
+	return volume, nil
+}
+
+// Remove the volume named `volumeName`, if it exists.
+func removeNamedVolume(ctx context.Context, volumeName string, containerEngine engine.ContainerEngine, console *console.Console) error {
+	volume, err := findVolume(ctx, containerEngine, volumeName)
+	if err != nil || volume == nil {
+		return err
+	}
+	if err := containerEngine.RemoveVolume(ctx, *volume); err != nil {
+		return err
+	}
+	console.Success("Removed '%s' volume with success!", volumeName)
+	return nil
+}
+
 func removeNetwork(ctx context.Context, projectName string, containerEngine engine.ContainerEngine, console *console.Console) error {
 	console.WriteLn("Stopping and removing '%s' network interfaces...", projectName)
 
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Rename(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	if len(args) != 2 {
+		return errors.New("usage: paul-envs rename <name> <new-name>")
+	}
+	project, err := getExistingProject(args[:1], filestore, console, "rename")
+	if err != nil {
+		return err
+	}
+	oldName, newName := project.ProjectName, args[1]
+	if err := utils.ValidateProjectName(newName); err != nil {
+		return fmt.Errorf("invalid project name '%s': %w", newName, err)
+	}
+	if filestore.DoesProjectExist(newName) {
+		return fmt.Errorf("project '%s' already exists", newName)
+	}
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+	container, err := findRunningContainer(ctx, containerEngine, oldName)
+	if err != nil {
+		return err
+	}
+	if container != nil {
+		return fmt.Errorf("the container of project '%s' is running\nHint: Stop it first with 'paul-envs down %s'", oldName, oldName)
+	}
+
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, oldName)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the '%s' project: %w", oldName, err)
+	}
+	volume, err := findProjectVolume(ctx, containerEngine, oldName)
+	if err != nil {
+		return err
+	}
+	if volume != nil && !hasBeenBuilt {
+		return fmt.Errorf("the image of project '%s' is needed to migrate its volume but it has not been built\nHint: Run 'paul-envs build %s' first", oldName, oldName)
+	}
+	cacheVolume, err := findPrivateCacheVolume(ctx, containerEngine, oldName)
+	if err != nil {
+		return err
+	}
+	isUpToDate := false
+	engineInfo, err := containerEngine.Info(ctx)
+	if err == nil && hasBeenBuilt {
+		if buildInfo, err := filestore.ReadBuildInfo(oldName); err == nil {
+			needsRebuild, _, err := filestore.NeedsRebuild(oldName, buildInfo, engineInfo.Name)
+			isUpToDate = err == nil && !needsRebuild
+		}
+	}
+
+	if content, err := filestore.ReadProjectFiles(oldName); err == nil && files.IsLegacyProjectFiles(content) {
+		console.Info("Project '%s' was created by an older version of paul-envs: its files will be re-generated, their previous version kept with a '.bak' extension", oldName)
+		// Its image is not labeled: build it again with the new files
+		isUpToDate = false
+	}
+
+	// Stopped containers still reference the previous image and volume
+	if err := removeContainer(ctx, oldName, containerEngine, console); err != nil {
+		return err
+	}
+
+	if hasBeenBuilt {
+		console.Info("Creating image 'paulenv:%s' from 'paulenv:%s'...", newName, oldName)
+		if err := containerEngine.CopyImage(ctx, oldName, newName); err != nil {
+			return err
+		}
+	}
+	if volume != nil {
+		newVolumeName := fmt.Sprintf("paulenv-%s-local", newName)
+		console.Info("Migrating the data of volume '%s' to '%s'...", volume.VolumeName, newVolumeName)
+		err := containerEngine.CreateVolume(ctx, newVolumeName, renameLabels(volume.Labels, oldName, newName))
+		if err == nil {
+			err = containerEngine.CopyVolume(ctx, volume.VolumeName, newVolumeName, newName)
+		}
+		if err != nil {
+			rollbackRename(ctx, newName, containerEngine, console)
+			return fmt.Errorf("failed to migrate volume '%s': %w", volume.VolumeName, err)
+		}
+	}
+
+	// Copying a volume needs the project's image. Without it, the cache is just
+	// created again empty by the next build
+	if cacheVolume != nil && hasBeenBuilt {
+		newCacheName := config.CacheVolumeName(newName, config.CachePrivate)
+		console.Info("Migrating the data of volume '%s' to '%s'...", cacheVolume.VolumeName, newCacheName)
+		err := containerEngine.CreateVolume(ctx, newCacheName, engine.CacheVolumeLabels(newName, config.CachePrivate))
+		if err == nil {
+			err = containerEngine.CopyVolume(ctx, cacheVolume.VolumeName, newCacheName, newName)
+		}
+		if err != nil {
+			rollbackRename(ctx, newName, containerEngine, console)
+			return fmt.Errorf("failed to migrate volume '%s': %w", cacheVolume.VolumeName, err)
+		}
+	}
+
+	console.Info("Renaming project files...")
+	if err := filestore.RenameProjectFiles(oldName, newName); err != nil {
+		rollbackRename(ctx, newName, containerEngine, console)
+		return err
+	}
+
+	console.Info("Removing the resources of the previous '%s' project...", oldName)
+	if err := removeImage(ctx, oldName, containerEngine, console); err != nil {
+		console.Warn("Could not remove image of '%s': %s", oldName, err)
+	}
+	if err := removeVolume(ctx, oldName, containerEngine, console); err != nil {
+		console.Warn("Could not remove volume of '%s': %s", oldName, err)
+	}
+	if cacheVolume != nil {
+		if err := removeNamedVolume(ctx, cacheVolume.VolumeName, containerEngine, console); err != nil {
+			console.Warn("Could not remove volume '%s': %s", cacheVolume.VolumeName, err)
+		}
+	}
+	if err := removeNetwork(ctx, oldName, containerEngine, console); err != nil {
+		console.Warn("Could not remove network of '%s': %s", oldName, err)
+	}
+
+	// The project files changed, but not what the image was built from
+	if isUpToDate {
+		if err := filestore.RefreshBuildInfoFile(newName, engineInfo.Name, engineInfo.Version); err != nil {
+			console.Warn("Could not refresh 'project.buildinfo' file for this project: %s", err)
+		}
+	}
+	console.Success("Renamed project '%s' to '%s'", oldName, newName)
+	return nil
+}
+
+// Find the persisted local volume of the given project, returning `nil` if it
+// does not exist.
+func findProjectVolume(ctx context.Context, containerEngine engine.ContainerEngine, projectName string) (*engine.VolumeInfo, error) {
+	volumes, err := containerEngine.ListVolumes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list current volumes: %w", err)
+	}
+	for _, volume := range volumes {
+		if volume.ProjectName != nil && *volume.ProjectName == projectName {
+			return &volume, nil
+		}
+	}
+	return nil, nil
+}
+
+// Copy `labels` of a resource of project `oldName` so they refer to project
+// `newName` instead, including labels set by compose on its resources.
+func renameLabels(labels map[string]string, oldName string, newName string) map[string]string {
+	result := map[string]string{
+		engine.LabelOwner:     "true",
+		engine.LabelProject:   newName,
+		engine.LabelProjectID: newName,
+	}
+	for key, value := range labels {
+		switch value {
+		case oldName:
+			result[key] = newName
+		case "paulenv-" + oldName:
+			result[key] = "paulenv-" + newName
+		default:
+			if _, isSet := result[key]; !isSet {
+				result[key] = value
+			}
+		}
+	}
+	return result
+}
+
+// Remove the resources created for project `newName` by an interrupted rename.
+func rollbackRename(ctx context.Context, newName string, containerEngine engine.ContainerEngine, console *console.Console) {
+	if err := removeImage(ctx, newName, containerEngine, console); err != nil {
+		console.Warn("Could not remove image of '%s': %s", newName, err)
+	}
+	if err := removeVolume(ctx, newName, containerEngine, console); err != nil {
+		console.Warn("Could not remove volume of '%s': %s", newName, err)
+	}
+	privateCache := config.CacheVolumeName(newName, config.CachePrivate)
+	if err := removeNamedVolume(ctx, privateCache, containerEngine, console); err != nil {
+		console.Warn("Could not remove volume '%s': %s", privateCache, err)
+	}
+}
@@ -0,0 +1,148 @@
+package commands_test
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestRename(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "old")
+	env.build(t, "old")
+	project, err := env.filestore.GetProject("old")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	if err := commands.Up(env.ctx, []string{"old"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	err = commands.Rename(env.ctx, []string{"old", "new"}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "running") {
+		t.Fatalf("expected an error while the container is running, got %v", err)
+	}
+	if err := commands.Down(env.ctx, []string{"old"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	if err := commands.Rename(env.ctx, []string{"old", "new"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if env.filestore.DoesProjectExist("old") || !env.filestore.DoesProjectExist("new") {
+		t.Fatalf("project should have been renamed")
+	}
+	renamed, err := env.filestore.GetProject("new")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	if renamed.ProjectPath != project.ProjectPath {
+		t.Errorf("renamed project should mount the same path, got %q", renamed.ProjectPath)
+	}
+
+	imageNames := []string{}
+	for _, image := range env.fakeEngine.Images {
+		imageNames = append(imageNames, image.ImageName)
+	}
+	if !slices.Equal(imageNames, []string{"paulenv:new"}) {
+		t.Errorf("only the renamed image should remain, got %v", imageNames)
+	}
+	volumes := []string{}
+	for _, volume := range env.fakeEngine.Volumes {
+		if volume.ProjectName != nil {
+			volumes = append(volumes, volume.VolumeName)
+			if volume.Labels[engine.LabelProject] != "new" {
+				t.Errorf("migrated volume should be labeled with the new project, got %v", volume.Labels)
+			}
+		}
+	}
+	if !slices.Equal(volumes, []string{"paulenv-new-local"}) {
+		t.Errorf("only the migrated volume should remain, got %v", volumes)
+	}
+	if calls := env.fakeEngine.CallsTo("CopyVolume"); len(calls) != 1 || calls[0].Args[0] != "paulenv-old-local" {
+		t.Errorf("the data of the previous volume should be copied, got %+v", calls)
+	}
+	if len(env.fakeEngine.CallsTo("BuildImage")) != 1 {
+		t.Errorf("the project should not be re-built")
+	}
+
+	buildInfo, err := env.filestore.ReadBuildInfo("new")
+	if err != nil {
+		t.Fatalf("ReadBuildInfo() error = %v", err)
+	}
+	needsRebuild, reason, err := env.filestore.NeedsRebuild("new", buildInfo, "fake")
+	if err != nil || needsRebuild {
+		t.Errorf("the renamed project should not need a rebuild, got %v (%s, err: %v)", needsRebuild, reason, err)
+	}
+	if err := commands.Up(env.ctx, []string{"new"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Errorf("Up() error after rename = %v", err)
+	}
+}
+
+func TestRename_LegacyProject(t *testing.T) {
+	env := newTestEnv(t)
+	env.createLegacyProject(t, "old")
+
+	if err := commands.Rename(env.ctx, []string{"old", "new"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	content, err := env.filestore.ReadProjectFiles("new")
+	if err != nil {
+		t.Fatalf("ReadProjectFiles() error = %v", err)
+	}
+	for _, expected := range []string{`paulenv.project: "new"`, "image: paulenv:new", "name: paulenv-new-local", "8080:8080"} {
+		if !strings.Contains(string(content.Compose), expected) {
+			t.Errorf("renamed compose file should contain %q:\n%s", expected, content.Compose)
+		}
+	}
+	project, err := env.filestore.GetProject("new")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	if _, err := os.Stat(project.ComposeFilePath + ".bak"); err != nil {
+		t.Errorf("the previous compose file should be kept: %v", err)
+	}
+	if status, _ := env.filestore.ValidateProjectLock("new"); status != files.ProjectLockValid {
+		t.Errorf("the re-generated files should be up to date, got %s", status)
+	}
+
+	if len(env.fakeEngine.Networks) != 0 {
+		t.Errorf("the network of the previous project should be removed, got %+v", env.fakeEngine.Networks)
+	}
+	volumes := []string{}
+	for _, volume := range env.fakeEngine.Volumes {
+		volumes = append(volumes, volume.VolumeName)
+	}
+	if !slices.Equal(volumes, []string{"paulenv-new-local"}) {
+		t.Errorf("only the migrated volume should remain, got %v", volumes)
+	}
+}
+
+func TestRename_Errors(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "first")
+	env.createProject(t, "second")
+
+	for _, args := range [][]string{{"first", "second"}, {"first", "Invalid Name"}, {"unknown", "other"}, {"first"}} {
+		err := commands.Rename(env.ctx, args, env.filestore, env.engineLoader, env.console())
+		if err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+	if !env.filestore.DoesProjectExist("first") || !env.filestore.DoesProjectExist("second") {
+		t.Errorf("failed renames should not change projects")
+	}
+
+	// A project which has never been built is only renamed
+	if err := commands.Rename(env.ctx, []string{"first", "third"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("CopyImage")) != 0 || !env.filestore.DoesProjectExist("third") {
+		t.Errorf("unbuilt project should only have its files renamed")
+	}
+}
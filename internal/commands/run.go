@@ -11,14 +11,14 @@ import (
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
-func Run(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+func Run(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
-	containerEngine, err := engine.New(ctx)
+	containerEngine, err := engineLoader.Get(ctx)
 	if err != nil {
 		return err
 	}
@@ -79,7 +79,7 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 		if err != nil || !choice {
 			return fmt.Errorf("please run 'paul-envs build %s' first", project.ProjectName)
 		}
-		if err = Build(ctx, []string{project.ProjectName}, filestore, console); err != nil {
+		if err = Build(ctx, []string{project.ProjectName}, filestore, engineLoader, console); err != nil {
 			return fmt.Errorf("did not succeed to build project: %w", err)
 		}
 	}
@@ -91,14 +91,17 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 		console.Warn("Consider running 'build' first.\n\n")
 		// Continue anyway if image exists
 	}
+	warnAboutOutdatedProject(project.ProjectName, status, console)
 
 	buildInfo, err := filestore.ReadBuildInfo(project.ProjectName)
 	if err != nil {
 		console.Warn("Could not get the information from a precedent build: %s", err)
 	} else if buildInfo == nil {
 		console.Warn("NIL BUILD INFO")
+	} else if engineInfo, err := containerEngine.Info(ctx); err != nil {
+		console.Warn("Cannot check previous build metadata: impossible to get container engine version: %s", err)
 	} else {
-		needsRebuild, reason, err := filestore.NeedsRebuild(project.ProjectName, buildInfo)
+		needsRebuild, reason, err := filestore.NeedsRebuild(project.ProjectName, buildInfo, engineInfo.Name)
 		if err != nil {
 			console.Warn("Cannot check previous build metadata: %s", err)
 		}
@@ -106,7 +109,7 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 			console.WriteLn("The '%s' project needs to be re-built: %s", project.ProjectName, reason)
 			choice, err := console.AskYesNo("Do you want to build it first?", true)
 			if err != nil || choice {
-				if err = Build(ctx, []string{project.ProjectName}, filestore, console); err != nil {
+				if err = Build(ctx, []string{project.ProjectName}, filestore, engineLoader, console); err != nil {
 					return fmt.Errorf("did not succeed to build project: %w", err)
 				}
 			}
@@ -114,23 +117,115 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 
 	}
 
-	containerList, err := containerEngine.ListContainers(ctx)
+	return withProjectSession(ctx, project, containerEngine, filestore, console, func(container engine.ContainerInfo) error {
+		return containerEngine.JoinContainer(ctx, container, cmdArgs)
+	})
+}
+
+// Start the container of the given project if it isn't already running, then
+// call `sessionFn` on it while recording that session.
+//
+// If that container has been started here, it is stopped once its last
+// session exits.
+func withProjectSession(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+	sessionFn func(engine.ContainerInfo) error,
+) error {
+	name := project.ProjectName
+	container, err := findRunningContainer(ctx, containerEngine, project.ProjectName)
 	if err != nil {
-		console.Warn("Could not list already launched containers: %s", err)
+		return err
+	}
+	startedHere := false
+	if container != nil {
+		console.Info("Container already started, joining it.")
 	} else {
-		for _, container := range containerList {
-			if *container.ProjectName == name {
-				console.Info("Container already created, joining it.")
-				return containerEngine.JoinContainer(ctx, container, cmdArgs)
+		if err := checkKeepAlive(project, filestore); err != nil {
+			return err
+		}
+		console.Info("Starting the container of the project '%s'.", name)
+		if err = containerEngine.UpContainer(ctx, project); err != nil {
+			return err
+		}
+		container, err = findRunningContainer(ctx, containerEngine, project.ProjectName)
+		if err != nil {
+			return err
+		}
+		if container == nil {
+			return fmt.Errorf("the container of project '%s' is not running after having been started", name)
+		}
+		startedHere = true
+	}
+
+	session := files.NewCurrentSession()
+	err = filestore.UpdateProjectSessions(name, container.ContainerId, func(sessions *files.ProjectSessions) error {
+		if !startedHere {
+			// Its last session may have stopped it since it was found
+			running, err := findRunningContainer(ctx, containerEngine, name)
+			if err != nil {
+				return err
+			}
+			if running == nil || running.ContainerId != container.ContainerId {
+				return errContainerStopped
 			}
+		} else {
+			// Stop it once the last session exits
+			sessions.KeepAlive = false
 		}
+		sessions.Sessions = append(sessions.Sessions, session)
+		return nil
+	})
+	if errors.Is(err, errContainerStopped) {
+		return withProjectSession(ctx, project, containerEngine, filestore, console, sessionFn)
+	} else if err != nil {
+		console.Warn("Could not record this session, the container will not be stopped when exiting it: %s", err)
+		return sessionFn(*container)
 	}
 
-	console.Info("Creating \"leader\" container for the project '%s', other 'run' calls will join it.", name)
-	err = containerEngine.RunContainer(ctx, project, cmdArgs)
+	sessionErr := sessionFn(*container)
+
+	// The container is stopped while holding the lock on its sessions, so no
+	// other session can be attached to it in-between
+	err = filestore.UpdateProjectSessions(name, container.ContainerId, func(sessions *files.ProjectSessions) error {
+		sessions.RemoveSession(session)
+		if len(sessions.Sessions) > 0 || sessions.KeepAlive {
+			return nil
+		}
+		console.Info("Last session exited, stopping the container of project '%s'.", name)
+		// The container should be stopped even if the session was cancelled
+		if err := containerEngine.DownContainer(context.WithoutCancel(ctx), project); err != nil {
+			console.Warn("Could not stop the container: %s", err)
+			return nil
+		}
+		// No session is attached to a stopped container
+		*sessions = files.ProjectSessions{}
+		return nil
+	})
 	if err != nil {
-		return err
+		console.Warn("Could not update the sessions attached to that container: %s", err)
+	}
+	return sessionErr
+}
+
+// Returned when the container a session was about to be attached to has been
+// stopped in the meantime.
+var errContainerStopped = errors.New("the container has been stopped")
+
+// Returns an error if the container of the given project cannot be started in
+// the background, because its compose file was written by an older version of
+// paul-envs.
+func checkKeepAlive(project files.ProjectEntry, filestore *files.FileStore) error {
+	canKeepAlive, err := filestore.CanKeepAlive(project.ProjectName)
+	if err != nil {
+		return fmt.Errorf("failed to read the files of project '%s': %w", project.ProjectName, err)
+	}
+	if !canKeepAlive {
+		return fmt.Errorf("the compose.yaml file of project '%s' was written by an older version of paul-envs, which cannot keep its container running in the background\n"+
+			"Hint: Run 'paul-envs edit %s' to update it, or re-create the project", project.ProjectName, project.ProjectName)
 	}
-	console.Info("Exiting leader container for that project, those that have joined it will also exit.")
 	return nil
 }
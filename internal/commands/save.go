@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func Save(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var outputPath string
+	var noVolume bool
+	var force bool
+	flagset := flag.NewFlagSet("save", flag.ContinueOnError)
+	flagset.StringVar(&outputPath, "o", "", "Path of the written archive")
+	flagset.BoolVar(&noVolume, "no-volume", false, "Only save the image, not the project's local volume")
+	flagset.BoolVar(&force, "force", false, "Replace an existing file without asking")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) > 1 {
+		return errors.New("usage: paul-envs save <name> [-o <file>] [--no-volume]")
+	}
+	project, err := getExistingProject(positionals, filestore, console, "save")
+	if err != nil {
+		return err
+	}
+	name := project.ProjectName
+	if outputPath == "" {
+		outputPath = fmt.Sprintf("paulenv-%s.tar", name)
+	}
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+	engineInfo, err := containerEngine.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("impossible to get container engine version: %w", err)
+	}
+	if err := checkImageReusable(ctx, name, containerEngine, engineInfo, filestore); err != nil {
+		return fmt.Errorf("cannot save the image of project '%s': %w\nHint: Run 'paul-envs build %s' first", name, err, name)
+	}
+	var volume *engine.VolumeInfo
+	if !noVolume {
+		if volume, err = findProjectVolume(ctx, containerEngine, name); err != nil {
+			return err
+		}
+		container, err := findRunningContainer(ctx, containerEngine, name)
+		if err != nil {
+			return err
+		}
+		if volume != nil && container != nil {
+			console.Warn("The container of project '%s' is running, its volume may be saved in an inconsistent state.", name)
+		}
+	}
+	if _, err := os.Stat(outputPath); err == nil && !force {
+		confirm, err := console.AskYesNo(fmt.Sprintf("'%s' already exists. Replace it?", outputPath), false)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			return errors.New("save aborted by user")
+		}
+	}
+
+	info, err := filestore.NewSavedImageInfo(name)
+	if err != nil {
+		return err
+	}
+	// Images are large: stage them next to the output instead of in a
+	// potentially smaller temporary directory
+	stagingDir, err := os.MkdirTemp(filepath.Dir(outputPath), ".paulenv-save-")
+	if err != nil {
+		return fmt.Errorf("could not create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	console.Info("Saving image 'paulenv:%s'...", name)
+	imagePath := filepath.Join(stagingDir, "image.tar")
+	if err := writeToFile(imagePath, func(file *os.File) error {
+		return containerEngine.SaveImage(ctx, name, file)
+	}); err != nil {
+		return err
+	}
+	volumePath := ""
+	if volume != nil {
+		console.Info("Saving volume '%s'...", volume.VolumeName)
+		volumePath = filepath.Join(stagingDir, "volume.tar")
+		if err := writeToFile(volumePath, func(file *os.File) error {
+			return containerEngine.ExportVolume(ctx, volume.VolumeName, name, file)
+		}); err != nil {
+			return err
+		}
+		info.VolumeLabels = volume.Labels
+		if info.VolumeLabels == nil {
+			info.VolumeLabels = map[string]string{}
+		}
+	}
+
+	if err := writeToFile(outputPath, func(file *os.File) error {
+		return files.WriteSavedImage(file, info, imagePath, volumePath)
+	}); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	console.Success("Saved the image of project '%s' to '%s'", name, outputPath)
+	if volume == nil {
+		console.WriteLn("  Without any volume")
+	}
+	console.WriteLn("Load it on another machine where project '%s' exists with:", name)
+	console.WriteLn("  paul-envs load %s", filepath.Base(outputPath))
+	console.WriteLn("Hint: Create that project there from a 'paul-envs export %s' bundle", name)
+	return nil
+}
+
+func Load(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var force bool
+	flagset := flag.NewFlagSet("load", flag.ContinueOnError)
+	flagset.BoolVar(&force, "force", false, "Replace an existing volume without asking")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: paul-envs load <file> [--force]")
+	}
+
+	archive, err := os.Open(positionals[0])
+	if err != nil {
+		return fmt.Errorf("could not open saved image: %w", err)
+	}
+	defer archive.Close()
+	saved, err := files.ReadSavedImage(archive)
+	if err != nil {
+		return err
+	}
+	name := saved.Info.ProjectName
+	if !filestore.DoesProjectExist(name) {
+		return fmt.Errorf("this image has been saved for project '%s', which does not exist\n"+
+			"Hint: Create it first, e.g. with 'paul-envs import' on a bundle written by 'paul-envs export %s'", name, name)
+	}
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+	engineInfo, err := containerEngine.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("impossible to get container engine version: %w", err)
+	}
+	container, err := findRunningContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container != nil {
+		return fmt.Errorf("the container of project '%s' is running\nHint: Stop it first with 'paul-envs down %s'", name, name)
+	}
+
+	restoreVolume := saved.Info.VolumeLabels != nil
+	existingVolume, err := findProjectVolume(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if restoreVolume && existingVolume != nil && !force {
+		restoreVolume, err = console.AskYesNo(
+			fmt.Sprintf("Volume '%s' already exists. Replace its content with the saved one?", existingVolume.VolumeName), false)
+		if err != nil {
+			return err
+		}
+	}
+
+	console.Info("Loading image 'paulenv:%s' (Dockerfile version %s)...", name, saved.Info.DockerfileVersion())
+	if err := containerEngine.LoadImage(ctx, saved.Image()); err != nil {
+		return err
+	}
+	if err := ensureCacheVolume(ctx, name, filestore, containerEngine, console); err != nil {
+		return err
+	}
+
+	if restoreVolume {
+		volumeContent, err := saved.Volume()
+		if err != nil {
+			return err
+		}
+		if err := replaceVolume(ctx, name, saved.Info.VolumeLabels, volumeContent, containerEngine, console); err != nil {
+			return err
+		}
+	} else if saved.Info.VolumeLabels != nil {
+		console.Info("Kept the existing volume '%s'", existingVolume.VolumeName)
+	}
+
+	hash, err := filestore.GetBuildConfigHash(name)
+	if err != nil {
+		return err
+	}
+	if hash != saved.Info.BuildConfigHash {
+		console.Warn("The configuration of project '%s' differs from the one its image was built from, 'paul-envs run %s' will propose to re-build it.", name, name)
+	} else if err := filestore.RefreshBuildInfoFile(name, engineInfo.Name, engineInfo.Version); err != nil {
+		console.Warn("Could not refresh 'project.buildinfo' file for this project: %s", err)
+	}
+	console.Success("Loaded the image of project '%s', run it with 'paul-envs run %s'", name, name)
+	return nil
+}
+
+// Create the file at `filePath` and call `write` with it, closing it
+// afterwards.
+func writeToFile(filePath string, write func(*os.File) error) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("could not create '%s': %w", filePath, err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("could not write '%s': %w", filePath, err)
+	}
+	return nil
+}
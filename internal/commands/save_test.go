@@ -0,0 +1,75 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+)
+
+func TestSaveLoad(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "app")
+	archivePath := filepath.Join(t.TempDir(), "app.tar")
+
+	err := commands.Save(env.ctx, []string{"app", "-o", archivePath}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "not been built") {
+		t.Fatalf("expected an error for an unbuilt project, got %v", err)
+	}
+
+	env.build(t, "app")
+	if err := commands.Up(env.ctx, []string{"app"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if err := commands.Down(env.ctx, []string{"app"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	env.fakeEngine.VolumeData["paulenv-app-local"] = []byte("toolchains")
+	if err := commands.Save(env.ctx, []string{"app", "-o", archivePath}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(archivePath)); len(entries) != 1 {
+		t.Errorf("only the archive should be written, got %v", entries)
+	}
+
+	// Load on another machine, on which the project has been re-created
+	other := newTestEnv(t)
+	err = commands.Load(other.ctx, []string{archivePath}, other.filestore, other.engineLoader, other.console())
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected an error for an unknown project, got %v", err)
+	}
+	other.createProject(t, "app")
+	if err := commands.Load(other.ctx, []string{archivePath}, other.filestore, other.engineLoader, other.console()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if hasBeenBuilt, _ := other.fakeEngine.HasBeenBuilt(other.ctx, "app"); !hasBeenBuilt {
+		t.Errorf("the image should have been loaded")
+	}
+	if data := string(other.fakeEngine.VolumeData["paulenv-app-local"]); data != "toolchains" {
+		t.Errorf("the volume should have been restored, got %q", data)
+	}
+	buildInfo, err := other.filestore.ReadBuildInfo("app")
+	if err != nil {
+		t.Fatalf("ReadBuildInfo() error = %v", err)
+	}
+	if needsRebuild, reason, _ := other.filestore.NeedsRebuild("app", buildInfo, "fake"); needsRebuild {
+		t.Errorf("loaded image should not need a rebuild, got %s", reason)
+	}
+
+	// An existing volume is only replaced once confirmed
+	other.fakeEngine.VolumeData["paulenv-app-local"] = []byte("local changes")
+	if err := commands.Load(other.ctx, []string{archivePath}, other.filestore, other.engineLoader, other.console("n")); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if data := string(other.fakeEngine.VolumeData["paulenv-app-local"]); data != "local changes" {
+		t.Errorf("the existing volume should have been kept, got %q", data)
+	}
+	if err := commands.Load(other.ctx, []string{archivePath, "--force"}, other.filestore, other.engineLoader, other.console()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if data := string(other.fakeEngine.VolumeData["paulenv-app-local"]); data != "toolchains" {
+		t.Errorf("the volume should have been replaced, got %q", data)
+	}
+}
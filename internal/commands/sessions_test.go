@@ -0,0 +1,129 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Record a session for the given container, as another `run` call would have
+// done.
+func recordSession(t *testing.T, env *testEnv, projectName string, containerId string, pid int) {
+	t.Helper()
+	err := env.filestore.UpdateProjectSessions(projectName, containerId, func(sessions *files.ProjectSessions) error {
+		sessions.KeepAlive = false
+		sessions.Sessions = append(sessions.Sessions, files.Session{Pid: pid, StartedAt: time.Now()})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateProjectSessions() error = %v", err)
+	}
+}
+
+func TestRun_KeepsContainerWithOtherSessions(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	container := env.fakeEngine.StartFakeContainer("myapp")
+	// Our parent process is alive for the whole test
+	recordSession(t, env, "myapp", container.ContainerId, os.Getppid())
+
+	err := commands.Run(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("DownContainer")) != 0 {
+		t.Error("the container should be kept running while another session is attached")
+	}
+}
+
+func TestRun_IgnoresStaleSessions(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	container := env.fakeEngine.StartFakeContainer("myapp")
+	// A process that has already exited, e.g. a crashed client
+	recordSession(t, env, "myapp", container.ContainerId, exitedPid(t))
+
+	err := commands.Run(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("DownContainer")) != 1 {
+		t.Error("the container should be stopped once the last live session exits")
+	}
+}
+
+func TestRun_KeepsUnknownContainers(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	// Not started by a `run` call: no session has been recorded for it
+	env.fakeEngine.StartFakeContainer("myapp")
+
+	err := commands.Run(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("DownContainer")) != 0 {
+		t.Error("a container not started by run should be kept running")
+	}
+}
+
+func TestRun_RestartsContainerStoppedByItsLastSession(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	project, err := env.filestore.GetProject("myapp")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	container := env.fakeEngine.StartFakeContainer("myapp")
+	recordSession(t, env, "myapp", container.ContainerId, os.Getpid())
+
+	// Another process exiting the last session of that container holds the
+	// lock on its sessions while stopping it
+	lockPath := filepath.Join(filepath.Dir(project.EnvFilePath), "project.sessions.lock")
+	if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	runErr := make(chan error)
+	go func() {
+		runErr <- commands.Run(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	}()
+	for len(env.fakeEngine.CallsTo("ListContainers")) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := env.fakeEngine.DownContainer(env.ctx, project); err != nil {
+		t.Fatalf("DownContainer() error = %v", err)
+	}
+	os.Remove(lockPath)
+
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 1 {
+		t.Errorf("the container should have been started again")
+	}
+	joins := env.fakeEngine.CallsTo("JoinContainer")
+	if len(joins) != 1 || joins[0].Target == container.ContainerId {
+		t.Errorf("the new container should have been joined, got %+v", joins)
+	}
+}
+
+// Returns the pid of a process which has already exited.
+func exitedPid(t *testing.T) int {
+	t.Helper()
+	process, err := os.StartProcess(os.Args[0], []string{os.Args[0], "-test.run=^$"}, &os.ProcAttr{})
+	if err != nil {
+		t.Fatalf("could not start process: %v", err)
+	}
+	if _, err := process.Wait(); err != nil {
+		t.Fatalf("could not wait for process: %v", err)
+	}
+	return process.Pid
+}
@@ -0,0 +1,257 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Current state of a project, as displayed by the `status` command.
+type projectStatus struct {
+	project files.ProjectEntry
+	// `true` if an image has been built for that project
+	built bool
+	// `true` if that project should be re-built, with `rebuildReason` as reason
+	needsRebuild  bool
+	rebuildReason files.RebuildReason
+	// Set if we could not check whether a rebuild is needed
+	rebuildErr error
+	lockStatus files.ProjectLockStatus
+	// Set if the `project.lock` file is not valid
+	lockErr error
+	// Its container, `nil` if there's none
+	container *engine.ContainerInfo
+	// Number of interactive sessions attached to a running container, -1 if
+	// unknown
+	sessionCount int
+	// `true` if the running container is not stopped once its last session
+	// exits
+	keptAlive bool
+	// Ports exposed by its container, `nil` if unknown
+	ports []string
+	// Size in bytes of its local volume, -1 if unknown or if that volume
+	// doesn't exist
+	localVolumeSize int64
+}
+
+func Status(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments given to 'status'")
+	}
+	var projects []files.ProjectEntry
+	if len(args) == 1 {
+		project, err := getExistingProject(args, filestore, console, "inspect")
+		if err != nil {
+			return err
+		}
+		projects = []files.ProjectEntry{project}
+	} else {
+		var err error
+		projects, err = filestore.GetAllProjects()
+		if err != nil {
+			return fmt.Errorf("could not list all projects: %w", err)
+		}
+		if len(projects) == 0 {
+			console.WriteLn("  (no project found)")
+			console.WriteLn("Hint: Create one with 'paul-envs create <path>'")
+			return nil
+		}
+	}
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+	engineInfo, err := containerEngine.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("impossible to get container engine information: %w", err)
+	}
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list current containers: %w", err)
+	}
+	volumeSizes, err := containerEngine.GetVolumeSizes(ctx)
+	if err != nil {
+		console.Warn("Could not obtain volume sizes: %s", err)
+	}
+
+	if console.IsStructuredOutput() {
+		output := statusOutput{
+			SchemaVersion: outputSchemaVersion(),
+			Engine:        newEngineOutput(engineInfo, engineLoader.Selection()),
+			Projects:      make([]projectStatusOutput, 0, len(projects)),
+		}
+		for _, project := range projects {
+			status := getProjectStatus(ctx, project, containerEngine, engineInfo, containers, volumeSizes, filestore)
+			imageInfo, err := containerEngine.GetImageInfo(ctx, project.ProjectName)
+			if err != nil {
+				console.Warn("Could not obtain image info for project '%s': %s", project.ProjectName, err)
+			}
+			output.Projects = append(output.Projects, newProjectStatusOutput(status, imageInfo))
+		}
+		return console.WriteData(output)
+	}
+
+	for i, project := range projects {
+		if i > 0 {
+			console.WriteLn("")
+		}
+		status := getProjectStatus(ctx, project, containerEngine, engineInfo, containers, volumeSizes, filestore)
+		printProjectStatus(status, console)
+	}
+	return nil
+}
+
+func getProjectStatus(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	engineInfo engine.EngineInfo,
+	containers []engine.ContainerInfo,
+	volumeSizes map[string]int64,
+	filestore *files.FileStore,
+) projectStatus {
+	name := project.ProjectName
+	status := projectStatus{project: project, sessionCount: -1, localVolumeSize: -1}
+
+	status.built, status.rebuildErr = containerEngine.HasBeenBuilt(ctx, name)
+	if status.built {
+		if buildInfo, err := filestore.ReadBuildInfo(name); err != nil {
+			status.rebuildErr = err
+		} else {
+			status.needsRebuild, status.rebuildReason, status.rebuildErr = filestore.NeedsRebuild(name, buildInfo, engineInfo.Name)
+		}
+	}
+	status.lockStatus, status.lockErr = filestore.ValidateProjectLock(name)
+
+	for _, container := range containers {
+		if container.ProjectName == nil || *container.ProjectName != name {
+			continue
+		}
+		// Prefer the running container if there are several
+		if status.container == nil || isContainerRunning(container) {
+			status.container = &container
+		}
+	}
+	if status.container != nil && isContainerRunning(*status.container) {
+		if sessions, err := containerEngine.ListSessions(ctx, *status.container); err == nil {
+			status.sessionCount = len(sessions)
+		}
+		if recorded, err := filestore.GetProjectSessions(name, status.container.ContainerId); err == nil {
+			status.keptAlive = recorded.KeepAlive
+		}
+	}
+
+	if ports, err := filestore.GetProjectPorts(name); err == nil {
+		status.ports = ports
+	}
+	if size, ok := volumeSizes["paulenv-"+name+"-local"]; ok {
+		status.localVolumeSize = size
+	}
+	return status
+}
+
+func printProjectStatus(status projectStatus, console *console.Console) {
+	console.Info("%s", status.project.ProjectName)
+
+	switch {
+	case status.rebuildErr != nil:
+		console.WriteLn("  Build        : Unknown (%s)", status.rebuildErr)
+	case !status.built:
+		console.WriteLn("  Build        : Not built")
+	case status.needsRebuild:
+		console.WriteLn("  Build        : Needs a rebuild (%s)", status.rebuildReason)
+	default:
+		console.WriteLn("  Build        : Up to date")
+	}
+
+	if status.lockStatus == files.ProjectLockOutdated {
+		console.WriteLn("  Project lock : %s (run 'paul-envs edit %s' to update its files)",
+			status.lockStatus, status.project.ProjectName)
+	} else if status.lockStatus.IsValid() {
+		console.WriteLn("  Project lock : %s", status.lockStatus)
+	} else if status.lockErr != nil {
+		console.WriteLn("  Project lock : %s (%s)", status.lockStatus, status.lockErr)
+	} else {
+		console.WriteLn("  Project lock : %s", status.lockStatus)
+	}
+
+	switch {
+	case status.container == nil:
+		console.WriteLn("  Container    : Not running")
+	case !isContainerRunning(*status.container):
+		console.WriteLn("  Container    : Stopped (%s)", status.container.State)
+	default:
+		description := "Running"
+		if status.container.StartedAt != nil {
+			description += " for " + formatDuration(time.Since(*status.container.StartedAt))
+		}
+		switch status.sessionCount {
+		case -1:
+		case 1:
+			description += ", 1 session attached"
+		default:
+			description += fmt.Sprintf(", %d sessions attached", status.sessionCount)
+		}
+		if status.keptAlive {
+			description += " (kept alive)"
+		}
+		console.WriteLn("  Container    : %s", description)
+	}
+
+	switch {
+	case status.ports == nil:
+		console.WriteLn("  Ports        : Unknown")
+	case len(status.ports) == 0:
+		console.WriteLn("  Ports        : None")
+	default:
+		console.WriteLn("  Ports        : %s", strings.Join(status.ports, ", "))
+	}
+
+	if status.localVolumeSize < 0 {
+		console.WriteLn("  Local volume : Unknown size")
+	} else {
+		console.WriteLn("  Local volume : %s", formatSize(status.localVolumeSize))
+	}
+}
+
+// Format a duration for humans, with a precision going down to the second for
+// short durations only (e.g. "3d4h", "2h5m", "45s").
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// Format a size in bytes for humans, relying on powers of 10 like container
+// engines do (e.g. "1.2 GB").
+func formatSize(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	value := float64(size)
+	unitIdx := 0
+	for value >= 1000 && unitIdx < len(units)-1 {
+		value /= 1000
+		unitIdx++
+	}
+	if unitIdx == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unitIdx])
+}
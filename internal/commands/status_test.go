@@ -0,0 +1,78 @@
+package commands_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+)
+
+func TestStatus(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Create([]string{t.TempDir(), "--no-prompt", "--name", "myapp", "--port", "3000"}, env.filestore, env.console())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	env.createProject(t, "other")
+	env.build(t, "myapp")
+	env.fakeEngine.StartFakeContainer("myapp")
+	env.fakeEngine.VolumeSizes["paulenv-myapp-local"] = 12_500_000
+	env.out.Reset()
+
+	err = commands.Status(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	output := env.out.String()
+	for _, expected := range []string{
+		"Build        : Up to date",
+		"Project lock : valid",
+		"Container    : Running for",
+		"1 session attached",
+		"Ports        : 3000:3000",
+		"Local volume : 12.5 MB",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "other") {
+		t.Errorf("only the asked project should be displayed, got:\n%s", output)
+	}
+
+	// Updating the configuration asks for a rebuild
+	envFile := env.filestore.GetProjectEnvFilePath("myapp")
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(envFile, append(content, []byte("\n# updated\n")...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env.out.Reset()
+	err = commands.Status(env.ctx, nil, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	output = env.out.String()
+	for _, expected := range []string{
+		"Build        : Needs a rebuild (.env file has changed since last build)",
+		"Build        : Not built",
+		"Container    : Not running",
+		"Ports        : None",
+		"Local volume : Unknown size",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+}
+
+func TestStatus_UnknownProject(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Status(env.ctx, []string{"unknown"}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Default time let to a container to stop by itself when calling `stop`
+// before it is killed.
+const defaultStopTimeout = 10 * time.Second
+
+func Stop(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var all bool
+	var timeoutSecs uint
+	flagset := flag.NewFlagSet("stop", flag.ContinueOnError)
+	flagset.BoolVar(&all, "all", false, "Stop the containers of all projects")
+	flagset.UintVar(&timeoutSecs, "timeout", uint(defaultStopTimeout.Seconds()), "Seconds to wait for before killing the container")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	timeout := time.Duration(timeoutSecs) * time.Second
+	return stopProjectContainers(ctx, positionals, all, filestore, engineLoader, console, "stop",
+		func(containerEngine engine.ContainerEngine, container engine.ContainerInfo) error {
+			return containerEngine.StopContainer(ctx, container, timeout)
+		})
+}
+
+func Kill(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	var all bool
+	flagset := flag.NewFlagSet("kill", flag.ContinueOnError)
+	flagset.BoolVar(&all, "all", false, "Kill the containers of all projects")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	return stopProjectContainers(ctx, positionals, all, filestore, engineLoader, console, "kill",
+		func(containerEngine engine.ContainerEngine, container engine.ContainerInfo) error {
+			return containerEngine.KillContainer(ctx, container)
+		})
+}
+
+// Common logic of the `stop` and `kill` commands: find the running containers
+// wanted and call `stopFn` on each of them, after listing the sessions
+// attached to them.
+func stopProjectContainers(
+	ctx context.Context,
+	positionals []string,
+	all bool,
+	filestore *files.FileStore,
+	engineLoader *engine.Loader,
+	console *console.Console,
+	action string,
+	stopFn func(engine.ContainerEngine, engine.ContainerInfo) error,
+) error {
+	if all && len(positionals) > 0 {
+		return fmt.Errorf("cannot both give a project name and the --all flag to '%s'", action)
+	}
+	if len(positionals) > 1 {
+		return fmt.Errorf("too many arguments given to '%s'", action)
+	}
+	var name string
+	if !all {
+		var err error
+		name, err = getProjectName(positionals, filestore, console, action)
+		if err != nil {
+			return err
+		}
+		if err := utils.ValidateProjectName(name); err != nil {
+			return err
+		}
+	}
+
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list current containers: %w", err)
+	}
+
+	var toStop []engine.ContainerInfo
+	for _, container := range containers {
+		if container.ProjectName == nil || !isContainerRunning(container) {
+			continue
+		}
+		if all || *container.ProjectName == name {
+			toStop = append(toStop, container)
+		}
+	}
+	if len(toStop) == 0 {
+		if all {
+			console.Info("No running project container found.")
+		} else {
+			console.Info("No running container found for project '%s'.", name)
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, container := range toStop {
+		projectName := *container.ProjectName
+		printAttachedSessions(ctx, containerEngine, container, console)
+		if err := stopFn(containerEngine, container); err != nil {
+			errs = append(errs, fmt.Errorf("failed to %s '%s' container: %w", action, projectName, err))
+			continue
+		}
+		if err := filestore.ClearProjectSessions(projectName); err != nil {
+			console.Warn("Could not clear the sessions attached to '%s' container: %s", projectName, err)
+		}
+		if action == "kill" {
+			console.Success("Killed '%s' container", projectName)
+		} else {
+			console.Success("Stopped '%s' container", projectName)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns `true` if the given container is running, or if its state is
+// unknown.
+func isContainerRunning(container engine.ContainerInfo) bool {
+	return container.State == "" || container.State == "running"
+}
+
+func printAttachedSessions(ctx context.Context, containerEngine engine.ContainerEngine, container engine.ContainerInfo, console *console.Console) {
+	containerName := container.ContainerId
+	if container.ContainerName != nil {
+		containerName = *container.ContainerName
+	}
+	console.Info("Container %s (project '%s')", containerName, *container.ProjectName)
+	sessions, err := containerEngine.ListSessions(ctx, container)
+	if err != nil {
+		console.Warn("  Could not list attached sessions: %s", err)
+		return
+	}
+	if len(sessions) == 0 {
+		console.WriteLn("  No attached session")
+		return
+	}
+	console.WriteLn("  Attached sessions:")
+	for _, session := range sessions {
+		if session.Elapsed == "" {
+			console.WriteLn("    • %s: %s", session.TTY, session.Command)
+		} else {
+			console.WriteLn("    • %s: %s (for %s)", session.TTY, session.Command, session.Elapsed)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package commands_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+)
+
+func TestStop(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.createProject(t, "other")
+	container := env.fakeEngine.StartFakeContainer("myapp")
+	otherContainer := env.fakeEngine.StartFakeContainer("other")
+
+	err := commands.Stop(env.ctx, []string{"myapp", "--timeout", "3"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	stops := env.fakeEngine.CallsTo("StopContainer")
+	if len(stops) != 1 || stops[0].Target != container.ContainerId {
+		t.Fatalf("expected only myapp's container to be stopped, got %+v", stops)
+	}
+	if !strings.Contains(env.out.String(), "pts/0: /bin/bash") {
+		t.Errorf("expected attached sessions to be listed, got:\n%s", env.out.String())
+	}
+
+	// Already stopped containers are ignored
+	err = commands.Stop(env.ctx, []string{"--all"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	stops = env.fakeEngine.CallsTo("StopContainer")
+	if len(stops) != 2 || stops[1].Target != otherContainer.ContainerId {
+		t.Errorf("expected the other container to be stopped, got %+v", stops)
+	}
+}
+
+func TestStop_NothingRunning(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+
+	err := commands.Stop(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("StopContainer")) != 0 {
+		t.Error("nothing should have been stopped")
+	}
+	if !strings.Contains(env.out.String(), "No running container found") {
+		t.Errorf("expected a message indicating that nothing runs, got:\n%s", env.out.String())
+	}
+}
+
+func TestStop_InvalidArguments(t *testing.T) {
+	env := newTestEnv(t)
+	err := commands.Stop(env.ctx, []string{"myapp", "--all"}, env.filestore, env.engineLoader, env.console())
+	if err == nil {
+		t.Error("expected an error when giving both a name and --all")
+	}
+	err = commands.Stop(env.ctx, []string{"myapp", "--timeout", "soon"}, env.filestore, env.engineLoader, env.console())
+	if err == nil {
+		t.Error("expected an error on an invalid timeout")
+	}
+}
+
+func TestKill(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.createProject(t, "other")
+	env.fakeEngine.StartFakeContainer("myapp")
+	env.fakeEngine.StartFakeContainer("other")
+	env.fakeEngine.FailOn("ListSessions", errors.New("top failed"))
+
+	err := commands.Kill(env.ctx, []string{"--all"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("KillContainer")) != 2 {
+		t.Errorf("expected both containers to be killed, got %+v", env.fakeEngine.CallsTo("KillContainer"))
+	}
+	if !strings.Contains(env.out.String(), "Could not list attached sessions") {
+		t.Errorf("expected a warning on the sessions listing failure, got:\n%s", env.out.String())
+	}
+}
+
+func TestKill_Failure(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.fakeEngine.StartFakeContainer("myapp")
+	env.fakeEngine.FailOn("KillContainer", errors.New("permission denied. Please run with elevated privileges"))
+
+	err := commands.Kill(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected a permission error, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Up(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments given to 'up'")
+	}
+	project, err := getExistingProject(args, filestore, console, "start")
+	if err != nil {
+		return err
+	}
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, project.ProjectName)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the '%s' project: %w", project.ProjectName, err)
+	}
+	if !hasBeenBuilt {
+		return fmt.Errorf("the '%s' project has not been built yet\nHint: Run 'paul-envs build %s' first", project.ProjectName, project.ProjectName)
+	}
+
+	container, err := findRunningContainer(ctx, containerEngine, project.ProjectName)
+	if err != nil {
+		return err
+	}
+	if container != nil {
+		console.Info("The container of project '%s' is already running, it will now be kept running after its last session exits.", project.ProjectName)
+		keepContainerAlive(project.ProjectName, *container, filestore, console)
+		return nil
+	}
+
+	if err := checkKeepAlive(project, filestore); err != nil {
+		return err
+	}
+	console.Info("Starting the container of project '%s' in the background...", project.ProjectName)
+	if err := containerEngine.UpContainer(ctx, project); err != nil {
+		return err
+	}
+	console.Success("Started '%s' container", project.ProjectName)
+	if container, err := findRunningContainer(ctx, containerEngine, project.ProjectName); err != nil {
+		console.Warn("%s", err)
+	} else if container != nil {
+		keepContainerAlive(project.ProjectName, *container, filestore, console)
+	}
+	console.WriteLn("Join it with 'paul-envs run %s' and stop it with 'paul-envs down %s'.", project.ProjectName, project.ProjectName)
+	return nil
+}
+
+func Down(ctx context.Context, args []string, filestore *files.FileStore, engineLoader *engine.Loader, console *console.Console) error {
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments given to 'down'")
+	}
+	project, err := getExistingProject(args, filestore, console, "stop")
+	if err != nil {
+		return err
+	}
+	containerEngine, err := engineLoader.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list current containers: %w", err)
+	}
+	found := false
+	for _, container := range containers {
+		if container.ProjectName == nil || *container.ProjectName != project.ProjectName {
+			continue
+		}
+		found = true
+		if isContainerRunning(container) {
+			printAttachedSessions(ctx, containerEngine, container, console)
+		}
+	}
+	if !found {
+		console.Info("No container found for project '%s'.", project.ProjectName)
+		return nil
+	}
+
+	console.Info("Stopping the container of project '%s'...", project.ProjectName)
+	if err := containerEngine.DownContainer(ctx, project); err != nil {
+		return err
+	}
+	console.Success("Stopped '%s' container", project.ProjectName)
+	if err := filestore.ClearProjectSessions(project.ProjectName); err != nil {
+		console.Warn("Could not clear the sessions attached to that container: %s", err)
+	}
+	return nil
+}
+
+// Indicate that the given container should not be stopped when its last
+// session exits.
+func keepContainerAlive(projectName string, container engine.ContainerInfo, filestore *files.FileStore, console *console.Console) {
+	err := filestore.UpdateProjectSessions(projectName, container.ContainerId, func(sessions *files.ProjectSessions) error {
+		sessions.KeepAlive = true
+		return nil
+	})
+	if err != nil {
+		console.Warn("Could not mark the container as kept alive: %s", err)
+	}
+}
+
+// Obtain the project whose name is given in `args`, or asked to the user if
+// none is given, and check that it exists.
+func getExistingProject(args []string, filestore *files.FileStore, console *console.Console, action string) (files.ProjectEntry, error) {
+	name, err := getProjectName(args, filestore, console, action)
+	if err != nil {
+		return files.ProjectEntry{}, err
+	}
+	if err := utils.ValidateProjectName(name); err != nil {
+		return files.ProjectEntry{}, err
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		if !filestore.DoesProjectExist(name) {
+			return files.ProjectEntry{}, fmt.Errorf("project '%s' not found\nHint: Use 'paul-envs list' to see available projects", name)
+		}
+		return files.ProjectEntry{}, fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	return project, nil
+}
+
+// Returns the running container of the given project, or `nil` if there's
+// none.
+func findRunningContainer(ctx context.Context, containerEngine engine.ContainerEngine, projectName string) (*engine.ContainerInfo, error) {
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list current containers: %w", err)
+	}
+	for _, container := range containers {
+		if container.ProjectName != nil && *container.ProjectName == projectName && isContainerRunning(container) {
+			return &container, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,129 @@
+package commands_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+)
+
+func TestUpDown(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+
+	err := commands.Up(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(env.fakeEngine.Containers) != 1 || env.fakeEngine.Containers[0].State != "running" {
+		t.Fatalf("expected a running container, got %+v", env.fakeEngine.Containers)
+	}
+	if len(env.fakeEngine.CallsTo("JoinContainer")) != 0 {
+		t.Error("up should not join the container")
+	}
+
+	// Already running: nothing is started
+	err = commands.Up(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 1 {
+		t.Error("an already running container should not be started again")
+	}
+
+	// Running now joins the started container
+	err = commands.Run(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	joins := env.fakeEngine.CallsTo("JoinContainer")
+	if len(joins) != 1 || joins[0].Target != env.fakeEngine.Containers[0].ContainerId {
+		t.Errorf("expected to join the started container, got %+v", joins)
+	}
+
+	err = commands.Down(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if len(env.fakeEngine.Containers) != 0 || len(env.fakeEngine.Networks) != 0 {
+		t.Errorf("container and network should be removed, got %+v and %+v",
+			env.fakeEngine.Containers, env.fakeEngine.Networks)
+	}
+	if len(env.fakeEngine.Volumes) != 2 {
+		t.Errorf("volumes should be kept, got %+v", env.fakeEngine.Volumes)
+	}
+
+	// Nothing left to stop
+	err = commands.Down(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("DownContainer")) != 1 {
+		t.Error("down should do nothing when no container exists")
+	}
+	if !strings.Contains(env.out.String(), "No container found") {
+		t.Errorf("expected a message indicating that nothing exists, got:\n%s", env.out.String())
+	}
+}
+
+func TestUp_NotBuilt(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+
+	err := commands.Up(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if err == nil || !strings.Contains(err.Error(), "paul-envs build myapp") {
+		t.Fatalf("expected a hint to build first, got %v", err)
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 0 {
+		t.Error("the container should not have been started")
+	}
+}
+
+func TestUp_LegacyProject(t *testing.T) {
+	env := newTestEnv(t)
+	env.createLegacyProject(t, "legacy")
+
+	for name, start := range map[string]func() error{
+		"up": func() error {
+			return commands.Up(env.ctx, []string{"legacy"}, env.filestore, env.engineLoader, env.console())
+		},
+		"run": func() error {
+			return commands.Run(env.ctx, []string{"legacy"}, env.filestore, env.engineLoader, env.console())
+		},
+	} {
+		err := start()
+		if err == nil || !strings.Contains(err.Error(), "paul-envs edit legacy") {
+			t.Errorf("%s: expected to be told to update the compose file, got %v", name, err)
+		}
+	}
+	if len(env.fakeEngine.CallsTo("UpContainer")) != 0 {
+		t.Fatalf("the container should not be started without PAULENV_KEEP_ALIVE")
+	}
+
+	if err := commands.Edit([]string{"legacy"}, env.filestore, env.console()); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	env.build(t, "legacy")
+	if err := commands.Up(env.ctx, []string{"legacy"}, env.filestore, env.engineLoader, env.console()); err != nil {
+		t.Errorf("Up() error after updating the project = %v", err)
+	}
+}
+
+func TestDown_Failure(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "myapp")
+	env.build(t, "myapp")
+	env.fakeEngine.StartFakeContainer("myapp")
+	downErr := errors.New("Down failed: exit status 1")
+	env.fakeEngine.FailOn("DownContainer", downErr)
+
+	err := commands.Down(env.ctx, []string{"myapp"}, env.filestore, env.engineLoader, env.console())
+	if !errors.Is(err, downErr) {
+		t.Fatalf("expected the down error, got %v", err)
+	}
+	if !strings.Contains(env.out.String(), "pts/0: /bin/bash") {
+		t.Errorf("expected attached sessions to be listed, got:\n%s", env.out.String())
+	}
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func UpgradeBase(args []string, filestore *files.FileStore, console *console.Console) error {
+	var force bool
+	flagset := flag.NewFlagSet("upgrade-base", flag.ContinueOnError)
+	flagset.BoolVar(&force, "force", false, "Replace the base files without asking")
+	positionals, err := parseInterspersedFlags(flagset, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 0 {
+		return errors.New("usage: paul-envs upgrade-base [--force]")
+	}
+
+	baseFiles, err := filestore.CheckBaseFiles()
+	if err != nil {
+		return err
+	}
+	toUpgrade := []files.BaseFile{}
+	for _, file := range baseFiles {
+		if file.Status != files.BaseFileUpToDate {
+			toUpgrade = append(toUpgrade, file)
+		}
+	}
+	latestVersion := versions.DockerfileVersion.ToString()
+	if len(toUpgrade) == 0 {
+		console.Success("Base files are already up to date (version %s)", latestVersion)
+		return nil
+	}
+
+	for _, file := range toUpgrade {
+		console.Info("%s: %s", file.Path, describeBaseFile(file))
+		if file.Status != files.BaseFileMissing {
+			console.WriteLn("%s", utils.UnifiedDiff(
+				file.Path, fmt.Sprintf("%s (version %s)", file.Name, latestVersion),
+				file.Content, file.LatestContent))
+		}
+	}
+	if !force {
+		confirm, err := console.AskYesNo(fmt.Sprintf("Replace those files by the ones of version %s?", latestVersion), true)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			return errors.New("upgrade aborted by user")
+		}
+	}
+	backups, err := filestore.UpgradeBaseFiles()
+	for _, backup := range backups {
+		console.Info("The previous file has been kept as '%s'", backup)
+	}
+	if err != nil {
+		return err
+	}
+	console.Success("Upgraded base files to version %s", latestVersion)
+	console.WriteLn("Hint: Projects have to be re-built to use them, 'paul-envs status' lists those concerned")
+	return nil
+}
+
+// Warn about the base files which were kept as is instead of being upgraded
+// to the ones of this version.
+func warnAboutKeptBaseFiles(kept []files.BaseFile, console *console.Console) {
+	for _, file := range kept {
+		console.Warn("Base file '%s' is %s and has been kept as is, instead of the one of version %s.",
+			file.Path, describeBaseFile(file), versions.DockerfileVersion.ToString())
+	}
+	if len(kept) > 0 {
+		console.WriteLn("Hint: Run 'paul-envs upgrade-base' to see the differences with the latest base files and replace them")
+	}
+}
+
+// Describe how a base file differs from the one of this version.
+func describeBaseFile(file files.BaseFile) string {
+	if file.Version == nil || file.Status == files.BaseFileMissing {
+		return file.Status.String()
+	}
+	if file.Status == files.BaseFileModified {
+		return fmt.Sprintf("modified (based on version %s)", file.Version.ToString())
+	}
+	return fmt.Sprintf("%s (version %s)", file.Status, file.Version.ToString())
+}
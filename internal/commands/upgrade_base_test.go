@@ -0,0 +1,54 @@
+package commands_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/commands"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestUpgradeBase(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProject(t, "app")
+	if err := commands.UpgradeBase(nil, env.filestore, env.console()); err != nil {
+		t.Fatalf("UpgradeBase() error = %v", err)
+	}
+	if !strings.Contains(env.out.String(), "already up to date") {
+		t.Errorf("expected base files to be up to date, got:\n%s", env.out.String())
+	}
+
+	baseFiles, err := env.filestore.CheckBaseFiles()
+	if err != nil {
+		t.Fatalf("CheckBaseFiles() error = %v", err)
+	}
+	dockerfile := baseFiles[0]
+	modified := strings.Replace(string(dockerfile.Content), "\n", "\nRUN echo custom\n", 2)
+	if err := os.WriteFile(dockerfile.Path, []byte(modified), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env.out.Reset()
+	env.build(t, "app")
+	if !strings.Contains(env.out.String(), "is modified") || !strings.Contains(env.out.String(), "upgrade-base") {
+		t.Errorf("building should warn about the modified Dockerfile, got:\n%s", env.out.String())
+	}
+	if content, _ := os.ReadFile(dockerfile.Path); string(content) != modified {
+		t.Error("the modified Dockerfile should have been kept by the build")
+	}
+
+	env.out.Reset()
+	err = commands.UpgradeBase(nil, env.filestore, env.console("n"))
+	if err == nil || !strings.Contains(env.out.String(), "-RUN echo custom") {
+		t.Fatalf("expected the differences to be displayed before aborting, got %v:\n%s", err, env.out.String())
+	}
+	if err := commands.UpgradeBase(nil, env.filestore, env.console("y")); err != nil {
+		t.Fatalf("UpgradeBase() error = %v", err)
+	}
+	if content, _ := os.ReadFile(dockerfile.Path + ".old"); string(content) != modified {
+		t.Error("the modified Dockerfile should have been backed up")
+	}
+	if baseFiles, _ = env.filestore.CheckBaseFiles(); baseFiles[0].Status != files.BaseFileUpToDate {
+		t.Errorf("the Dockerfile should have been upgraded, got %s", baseFiles[0].Status)
+	}
+}
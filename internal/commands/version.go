@@ -9,16 +9,18 @@ import (
 	"github.com/peaberberian/paul-envs/internal/engine"
 )
 
-func Version(ctx context.Context, console *console.Console) error {
+func Version(ctx context.Context, engineLoader *engine.Loader, console *console.Console) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
-	console.WriteLn("paul-envs version %d.%d.%d",
-		versions.Version.Major, versions.Version.Minor, versions.Version.Patch)
-	containerEngine, err := engine.New(ctx)
+	if !console.IsStructuredOutput() {
+		console.WriteLn("paul-envs version %d.%d.%d",
+			versions.Version.Major, versions.Version.Minor, versions.Version.Patch)
+	}
+	containerEngine, err := engineLoader.Get(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to fetch information on container engine: %w", err)
 	}
@@ -26,7 +28,14 @@ func Version(ctx context.Context, console *console.Console) error {
 	if err != nil {
 		return fmt.Errorf("failed to fetch information on container engine: %w", err)
 	}
-	console.WriteLn("Container engine: %s", info.Name)
+	if console.IsStructuredOutput() {
+		return console.WriteData(versionOutput{
+			SchemaVersion: outputSchemaVersion(),
+			Version:       versions.Version.ToString(),
+			Engine:        newEngineOutput(info, engineLoader.Selection()),
+		})
+	}
+	console.WriteLn("Container engine: %s (%s)", info.Name, engineLoader.Selection().Source)
 	console.WriteLn("Container engine version: %s", info.Version)
 	return nil
 }
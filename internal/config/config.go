@@ -35,6 +35,39 @@ func (s *Shell) Set(value string) error {
 
 func (s Shell) String() string { return string(s) }
 
+// Special values of `Config.Cache`, any other value being a cache group.
+const (
+	CacheShared  = "shared"
+	CachePrivate = "private"
+)
+
+// Returns an error if `cache` is not a valid value for `Config.Cache`.
+func ValidateCache(cache string) error {
+	if cache == "" || cache == CacheShared || cache == CachePrivate {
+		return nil
+	}
+	if err := utils.ValidateProjectName(cache); err != nil {
+		return fmt.Errorf("invalid cache group '%s'. Must be \"shared\", \"private\" or a name following the same rules than project names", cache)
+	}
+	return nil
+}
+
+// Name of the volume storing the package caches (npm, pip, go...) of project
+// `projectName`, according to its `Config.Cache` value.
+//
+// Private caches and the ones of cache groups have a distinct prefix, so a
+// project and a cache group sharing the same name never share a volume.
+func CacheVolumeName(projectName string, cache string) string {
+	switch cache {
+	case "", CacheShared:
+		return "paulenv-shared-cache"
+	case CachePrivate:
+		return fmt.Sprintf("paulenv-private-%s-cache", projectName)
+	default:
+		return fmt.Sprintf("paulenv-group-%s-cache", cache)
+	}
+}
+
 // Bool helper for tri-state
 func Bool(v bool) *bool { return &v }
 
@@ -94,6 +127,16 @@ type Config struct {
 	InstallZellij   bool
 	InstallJujutsu  bool
 
+	// Which volume stores the package caches (npm, pip, go...) of the
+	// container.
+	//
+	// Values can be:
+	// - if 'shared' or empty: the cache shared by all projects
+	// - if 'private': a cache only used by this project
+	// - If anything else: the name of a cache group, only shared by the
+	//   projects of that same group.
+	Cache string
+
 	Ports    []uint16
 	Volumes  []string
 	Packages []string
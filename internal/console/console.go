@@ -18,18 +18,37 @@ const (
 )
 
 type Console struct {
-	reader    *bufio.Reader
+	reader *bufio.Reader
+	// Where human messages are written
 	writer    io.Writer
 	errWriter io.Writer
-	ctx       context.Context
+	// Where the machine-readable documents of `WriteData` are written
+	dataWriter io.Writer
+	format     OutputFormat
+	ctx        context.Context
 }
 
 func New(ctx context.Context, rd io.Reader, w io.Writer, ew io.Writer) *Console {
 	return &Console{
-		reader:    bufio.NewReader(rd),
-		writer:    w,
-		errWriter: ew,
-		ctx:       ctx,
+		reader:     bufio.NewReader(rd),
+		writer:     w,
+		errWriter:  ew,
+		dataWriter: w,
+		format:     OutputText,
+		ctx:        ctx,
+	}
+}
+
+// Returns a `Console` reading from the same input but writing all messages to
+// the error output, to leave the standard output to a command's own output.
+func (c *Console) WithErrOutput() *Console {
+	return &Console{
+		reader:     c.reader,
+		writer:     c.errWriter,
+		errWriter:  c.errWriter,
+		dataWriter: c.dataWriter,
+		format:     c.format,
+		ctx:        c.ctx,
 	}
 }
 
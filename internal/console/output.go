@@ -0,0 +1,222 @@
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Format in which commands output their result.
+type OutputFormat string
+
+const (
+	// Human-readable text, the default
+	OutputText OutputFormat = "text"
+	// Machine-readable JSON document
+	OutputJSON OutputFormat = "json"
+	// Machine-readable YAML document
+	OutputYAML OutputFormat = "yaml"
+)
+
+// All output formats currently supported.
+var OutputFormats = []OutputFormat{OutputText, OutputJSON, OutputYAML}
+
+// Parse the name of an output format, e.g. as given to the `--output` flag.
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	format := OutputFormat(strings.ToLower(value))
+	if !slices.Contains(OutputFormats, format) {
+		return "", fmt.Errorf("unknown output format '%s', expected one of: text, json, yaml", value)
+	}
+	return format, nil
+}
+
+// Set the format in which commands should output their result.
+//
+// With a machine-readable format, all human messages (information, warnings,
+// prompts...) are written to the error output, so the standard output only
+// contains the document written through `WriteData`.
+func (c *Console) SetOutputFormat(format OutputFormat) {
+	c.format = format
+	if format == OutputText {
+		c.writer = c.dataWriter
+	} else {
+		c.writer = c.errWriter
+	}
+}
+
+// Returns the format in which commands should output their result.
+func (c *Console) OutputFormat() OutputFormat {
+	return c.format
+}
+
+// Returns `true` if commands should output a machine-readable document through
+// `WriteData` instead of human-readable text.
+func (c *Console) IsStructuredOutput() bool {
+	return c.format == OutputJSON || c.format == OutputYAML
+}
+
+// Write `data` to the standard output in the current machine-readable format.
+//
+// `data` is encoded like `encoding/json` would, including its struct tags.
+func (c *Console) WriteData(data any) error {
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode output: %w", err)
+	}
+	switch c.format {
+	case OutputJSON:
+		encoded = append(encoded, '\n')
+	case OutputYAML:
+		if encoded, err = jsonToYAML(encoded); err != nil {
+			return fmt.Errorf("could not encode output: %w", err)
+		}
+	default:
+		return errors.New("no machine-readable output format has been chosen")
+	}
+	_, err = c.dataWriter.Write(encoded)
+	return err
+}
+
+// A parsed JSON value, keeping the order of object keys.
+type jsonNode struct {
+	// Encoded value for scalars (strings, numbers, booleans and null),
+	// empty for objects and arrays.
+	scalar string
+	// `true` for objects, whose content is in `keys` and `values`
+	isObject bool
+	keys     []string
+	values   []*jsonNode
+	// `true` for arrays, whose content is in `items`
+	isArray bool
+	items   []*jsonNode
+}
+
+// Convert a JSON document into the equivalent YAML document, keeping the
+// order of object keys.
+//
+// Strings are always written double-quoted, JSON escapes being compatible with
+// YAML's.
+func jsonToYAML(encoded []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	root, err := decodeJSONNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if root.isObject || root.isArray {
+		writeYAMLNode(&buf, root, 0)
+	} else {
+		buf.WriteString(root.scalar + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeJSONNode(decoder *json.Decoder) (*jsonNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch value := token.(type) {
+	case json.Delim:
+		node := &jsonNode{isObject: value == '{', isArray: value == '['}
+		for decoder.More() {
+			if node.isObject {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyToken.(string)
+				node.keys = append(node.keys, key)
+			}
+			child, err := decodeJSONNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if node.isObject {
+				node.values = append(node.values, child)
+			} else {
+				node.items = append(node.items, child)
+			}
+		}
+		// Closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		quoted, _ := json.Marshal(value)
+		return &jsonNode{scalar: string(quoted)}, nil
+	case json.Number:
+		return &jsonNode{scalar: value.String()}, nil
+	case bool:
+		return &jsonNode{scalar: fmt.Sprint(value)}, nil
+	case nil:
+		return &jsonNode{scalar: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON token %v", token)
+	}
+}
+
+// Returns the YAML representation of a node if it fits on the same line than
+// its key or list marker, or `false` if it spans multiple lines.
+func inlineYAML(node *jsonNode) (string, bool) {
+	switch {
+	case node.isObject && len(node.keys) == 0:
+		return "{}", true
+	case node.isArray && len(node.items) == 0:
+		return "[]", true
+	case node.isObject || node.isArray:
+		return "", false
+	default:
+		return node.scalar, true
+	}
+}
+
+func writeYAMLNode(buf *bytes.Buffer, node *jsonNode, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	if node.isObject {
+		for i, key := range node.keys {
+			writeYAMLEntry(buf, prefix+yamlKey(key)+":", node.values[i], indent)
+		}
+		return
+	}
+	for _, item := range node.items {
+		if inline, ok := inlineYAML(item); ok {
+			buf.WriteString(prefix + "- " + inline + "\n")
+		} else if item.isObject {
+			// The first key is written on the same line than the list marker
+			var itemBuf bytes.Buffer
+			writeYAMLNode(&itemBuf, item, indent+2)
+			buf.WriteString(prefix + "- " + strings.TrimPrefix(itemBuf.String(), prefix+"  "))
+		} else {
+			buf.WriteString(prefix + "-\n")
+			writeYAMLNode(buf, item, indent+2)
+		}
+	}
+}
+
+// Returns the given object key as written in YAML: as is if it only contains
+// letters, digits, "_" or "-", double-quoted otherwise.
+func yamlKey(key string) string {
+	isPlain := key != "" && strings.IndexFunc(key, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) < 0
+	if isPlain {
+		return key
+	}
+	quoted, _ := json.Marshal(key)
+	return string(quoted)
+}
+
+func writeYAMLEntry(buf *bytes.Buffer, key string, value *jsonNode, indent int) {
+	if inline, ok := inlineYAML(value); ok {
+		buf.WriteString(key + " " + inline + "\n")
+		return
+	}
+	buf.WriteString(key + "\n")
+	writeYAMLNode(buf, value, indent+2)
+}
@@ -0,0 +1,86 @@
+package console_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	for input, expected := range map[string]console.OutputFormat{
+		"text": console.OutputText,
+		"json": console.OutputJSON,
+		"YAML": console.OutputYAML,
+	} {
+		format, err := console.ParseOutputFormat(input)
+		if err != nil {
+			t.Fatalf("ParseOutputFormat(%q) error = %v", input, err)
+		}
+		if format != expected {
+			t.Errorf("ParseOutputFormat(%q) = %q, want %q", input, format, expected)
+		}
+	}
+	if _, err := console.ParseOutputFormat("xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestStructuredOutput_MessagesOnErrOutput(t *testing.T) {
+	c, out, errOut, _, cancel := newTestConsole("")
+	defer cancel()
+
+	c.SetOutputFormat(console.OutputJSON)
+	c.Info("building")
+	c.Warn("careful")
+	if err := c.WriteData(map[string]int{"count": 2}); err != nil {
+		t.Fatalf("WriteData() error = %v", err)
+	}
+	if got := out.String(); got != "{\n  \"count\": 2\n}\n" {
+		t.Errorf("unexpected standard output: %q", got)
+	}
+	if !strings.Contains(errOut.String(), "building") || !strings.Contains(errOut.String(), "careful") {
+		t.Errorf("expected messages on the error output, got %q", errOut.String())
+	}
+}
+
+func TestWriteData_YAML(t *testing.T) {
+	c, out, _, _, cancel := newTestConsole("")
+	defer cancel()
+
+	type item struct {
+		Name  string   `json:"name"`
+		Ports []string `json:"ports"`
+		Size  *int64   `json:"size"`
+	}
+	data := struct {
+		Version string `json:"schemaVersion"`
+		Items   []item `json:"items"`
+		Empty   []item `json:"empty"`
+	}{
+		Version: "1.0.0",
+		Items: []item{
+			{Name: "a: b", Ports: []string{"3000:3000"}},
+			{Name: "c", Ports: []string{}},
+		},
+		Empty: []item{},
+	}
+	c.SetOutputFormat(console.OutputYAML)
+	if err := c.WriteData(data); err != nil {
+		t.Fatalf("WriteData() error = %v", err)
+	}
+	expected := `schemaVersion: "1.0.0"
+items:
+  - name: "a: b"
+    ports:
+      - "3000:3000"
+    size: null
+  - name: "c"
+    ports: []
+    size: null
+empty: []
+`
+	if out.String() != expected {
+		t.Errorf("unexpected YAML output:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
@@ -0,0 +1,362 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Helpers shared by the implementations relying on the docker-compatible
+// CLIs (docker, podman).
+
+// Environment variable given to compose when starting a container in the
+// background, so the entrypoint keeps it alive instead of starting a shell.
+const keepAliveEnv = "PAULENV_KEEP_ALIVE=true"
+
+// Format given to `image inspect`, whose output is parsed by
+// `parseImageInspectOutput`.
+const imageInspectFormat = "{{json .RepoTags}}\t{{.Created}}\t{{json .Config.Labels}}"
+
+// Parse the output of an `image inspect` call performed with the
+// `imageInspectFormat` format, returning one `ImageInfo` per tag.
+func parseImageInspectOutput(output string) []ImageInfo {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	result := make([]ImageInfo, 0, len(lines))
+	for _, line := range lines {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		var tags []string
+		if err := json.Unmarshal([]byte(parts[0]), &tags); err != nil {
+			continue
+		}
+		builtAt := parseCLITime(strings.TrimSpace(parts[1]))
+		labels := parseFormattedLabels(parts[2])
+		for _, tag := range tags {
+			if strings.HasSuffix(tag, "<none>") {
+				continue
+			}
+			result = append(result, ImageInfo{
+				ImageName:   tag,
+				ProjectName: projectNameFromLabels(labels),
+				BuiltAt:     builtAt,
+				Labels:      labels,
+			})
+		}
+	}
+	return result
+}
+
+// Format given to `images` to list all images with their name, parsed by
+// `parseLegacyImageIds`.
+const imageListFormat = "{{.ID}}\t{{.Repository}}:{{.Tag}}"
+
+// Returns the ids of the images with a legacy paul-envs name in the output of
+// an `images` call performed with the `imageListFormat` format.
+func parseLegacyImageIds(output string) []string {
+	ids := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		id, name, ok := strings.Cut(line, "\t")
+		if ok && legacyImageProjectName(name) != nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Format given to `container inspect`, whose output is parsed by
+// `applyContainerInspectOutput`.
+const containerInspectFormat = "{{.Id}}\t{{.Created}}\t{{.State.StartedAt}}"
+
+// Parse the output of a `container inspect` call performed with the
+// `containerInspectFormat` format and set the creation and start dates it
+// contains on the corresponding `containers`.
+func applyContainerInspectOutput(output string, containers []ContainerInfo) {
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		for i := range containers {
+			if containers[i].ContainerId != parts[0] {
+				continue
+			}
+			containers[i].CreatedAt = parseCLITime(strings.TrimSpace(parts[1]))
+			// Containers which never started have a zero start date
+			if startedAt := parseCLITime(strings.TrimSpace(parts[2])); startedAt != nil && !startedAt.IsZero() {
+				containers[i].StartedAt = startedAt
+			}
+		}
+	}
+}
+
+// Parse the output of a `system df -v` call, returning the size in bytes of
+// each volume listed in its "Local Volumes" section, keyed by volume name.
+func parseSystemDfVolumes(output string) map[string]int64 {
+	sizes := make(map[string]int64)
+	inVolumesTable := false
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "VOLUME NAME") {
+			inVolumesTable = true
+			continue
+		}
+		if !inVolumesTable {
+			continue
+		}
+		if trimmed == "" {
+			// End of that table
+			if len(sizes) > 0 {
+				break
+			}
+			continue
+		}
+		// Columns are: name, links, size
+		fields := strings.Fields(trimmed)
+		if len(fields) < 3 {
+			continue
+		}
+		if size, ok := parseHumanSize(strings.Join(fields[2:], "")); ok {
+			sizes[fields[0]] = size
+		}
+	}
+	return sizes
+}
+
+// Parse a size as displayed by the docker and podman CLIs (e.g. "1.23GB",
+// "0B", "12.5kB"), which rely on powers of 10.
+func parseHumanSize(str string) (int64, bool) {
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"kB", 1e3}, {"KB", 1e3}, {"B", 1},
+	}
+	for _, unit := range units {
+		if numStr, ok := strings.CutSuffix(str, unit.suffix); ok {
+			num, err := strconv.ParseFloat(numStr, 64)
+			if err != nil || num < 0 {
+				return 0, false
+			}
+			return int64(num * unit.multiplier), true
+		}
+	}
+	return 0, false
+}
+
+// Arguments given to `top` to obtain the output parsed by `parseTopOutput`,
+// both understood by docker (as `ps` options) and podman (as descriptors).
+var topArgs = []string{"pid", "tty", "etime", "args"}
+
+// Parse the output of a `top` call performed with the `topArgs` arguments,
+// grouping processes by terminal to obtain the sessions attached to a
+// container.
+//
+// Processes without a terminal, such as the container's init process, are
+// ignored.
+func parseTopOutput(output string) []SessionInfo {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	sessions := []SessionInfo{}
+	seenTTYs := make(map[string]bool)
+	// First line is the header
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		tty := fields[1]
+		if tty == "?" || tty == "" || seenTTYs[tty] {
+			continue
+		}
+		seenTTYs[tty] = true
+		sessions = append(sessions, SessionInfo{
+			TTY:     tty,
+			Command: strings.Join(fields[3:], " "),
+			Elapsed: fields[2],
+		})
+	}
+	return sessions
+}
+
+// Arguments given to the `exec` command of the docker and podman CLIs to
+// execute a command as described by `opts` in the given container.
+func execArgs(container ContainerInfo, opts ExecOptions) []string {
+	args := []string{"exec", "-i"}
+	if opts.Tty {
+		args = append(args, "-t")
+	}
+	if opts.Workdir != "" {
+		args = append(args, "-w", opts.Workdir)
+	}
+	for _, env := range opts.Env {
+		args = append(args, "-e", env)
+	}
+	if opts.User != "" {
+		args = append(args, "-u", opts.User, container.ContainerId)
+	} else {
+		args = append(args, container.ContainerId, "/usr/local/bin/entrypoint.sh")
+	}
+	return append(args, opts.Args...)
+}
+
+// Convert an error returned by a command ran through `execArgs` into an
+// `*ExitCodeError` if that command exited with a non-zero exit code.
+func asExitCodeError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return &ExitCodeError{ExitCode: exitErr.ExitCode()}
+	}
+	return err
+}
+
+// Build through the given CLI (e.g. "docker") the image `targetImage` of
+// project `targetProject` on top of `sourceImage`, only replacing the labels
+// identifying its project.
+func buildImageCopy(ctx context.Context, cli string, sourceImage string, targetImage string, targetProject string) error {
+	contextDir, err := os.MkdirTemp("", "paulenv-copy-")
+	if err != nil {
+		return fmt.Errorf("could not create build context: %w", err)
+	}
+	defer os.RemoveAll(contextDir)
+	dockerfile := filepath.Join(contextDir, "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte("FROM "+sourceImage+"\n"), 0644); err != nil {
+		return fmt.Errorf("could not write Dockerfile: %w", err)
+	}
+
+	cmdArgs := []string{"build", "-t", targetImage, "-f", dockerfile}
+	cmdArgs = append(cmdArgs, labelArgs(map[string]string{
+		LabelProject:   targetProject,
+		LabelProjectID: targetProject,
+	})...)
+	cmd := exec.CommandContext(ctx, cli, append(cmdArgs, contextDir)...)
+	// Build progress is not the result of the command: keep the standard
+	// output for it.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Run through the given CLI (e.g. "docker") the command `args` in a
+// short-lived container based on `image`, with the given `volumes` mounted
+// (in the "NAME:PATH[:ro]" format).
+//
+// The entrypoint is bypassed and `root` is used so that all files can be
+// read and written with their ownership and permissions.
+//
+// If `stdin` is not `nil`, it is forwarded to that command. If `stdout` is
+// `nil`, its standard output is redirected to the standard error.
+func runHelperContainer(
+	ctx context.Context,
+	cli string,
+	image string,
+	volumes []string,
+	stdin io.Reader,
+	stdout io.Writer,
+	args ...string,
+) error {
+	cmdArgs := []string{"run", "--rm", "--user", "root", "--entrypoint", args[0]}
+	if stdin != nil {
+		cmdArgs = append(cmdArgs, "-i")
+	}
+	for _, volume := range volumes {
+		cmdArgs = append(cmdArgs, "-v", volume)
+	}
+	cmdArgs = append(cmdArgs, image)
+	cmd := exec.CommandContext(ctx, cli, append(cmdArgs, args[1:]...)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	if stdout == nil {
+		cmd.Stdout = os.Stderr
+	}
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Copy through the given CLI (e.g. "docker") the content of volume
+// `sourceVolume` into `targetVolume`, by running `cp` in a short-lived
+// container based on `image`.
+func runVolumeCopy(ctx context.Context, cli string, sourceVolume string, targetVolume string, image string) error {
+	return runHelperContainer(ctx, cli, image,
+		[]string{sourceVolume + ":/from:ro", targetVolume + ":/to"}, nil, nil,
+		"cp", "-a", "/from/.", "/to/")
+}
+
+// Write through the given CLI (e.g. "docker") the content of `volume` to `w`
+// as a tar archive, by running `tar` in a short-lived container based on
+// `image`.
+func runVolumeExport(ctx context.Context, cli string, volume string, image string, w io.Writer) error {
+	return runHelperContainer(ctx, cli, image, []string{volume + ":/from:ro"}, nil, w,
+		"tar", "-C", "/from", "-cf", "-", ".")
+}
+
+// Extract through the given CLI (e.g. "docker") the tar archive read from `r`
+// in `volume`, by running `tar` in a short-lived container based on `image`.
+func runVolumeImport(ctx context.Context, cli string, volume string, image string, r io.Reader) error {
+	return runHelperContainer(ctx, cli, image, []string{volume + ":/to"}, r, nil,
+		"tar", "-C", "/to", "-xpf", "-")
+}
+
+// Run through the given CLI (e.g. "docker") `args` in a short-lived container
+// based on `image`, with `volume` mounted at `HelperVolumePath`.
+func runInVolume(ctx context.Context, cli string, volume string, image string, w io.Writer, args ...string) error {
+	return runHelperContainer(ctx, cli, image, []string{volume + ":" + HelperVolumePath}, nil, w, args...)
+}
+
+// Write through the given CLI (e.g. "docker") the image `image` to `w`.
+func runImageSave(ctx context.Context, cli string, image string, w io.Writer) error {
+	cmd := exec.CommandContext(ctx, cli, "image", "save", image)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Load through the given CLI (e.g. "docker") the image read from `r`.
+func runImageLoad(ctx context.Context, cli string, r io.Reader) error {
+	cmd := exec.CommandContext(ctx, cli, "image", "load")
+	cmd.Stdin = r
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Convert labels to the corresponding `--label` CLI arguments, sorted by key.
+func labelArgs(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	args := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		args = append(args, "--label", key+"="+labels[key])
+	}
+	return args
+}
+
+// Parse a timestamp as output by the docker or podman CLIs, which either
+// rely on RFC 3339 or on Go's default `time.Time` formatting.
+//
+// Returns `nil` if its format is unknown.
+func parseCLITime(timeStr string) *time.Time {
+	formats := []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999 -0700 MST",
+		"2006-01-02 15:04:05 -0700 MST",
+	}
+	for _, format := range formats {
+		if parsedTime, err := time.Parse(format, timeStr); err == nil {
+			return &parsedTime
+		}
+	}
+	return nil
+}
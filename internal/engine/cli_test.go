@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseImageInspectOutput(t *testing.T) {
+	output := `["paulenv:myapp"]	2024-01-02T03:04:05.5Z	{"paulenv":"true","paulenv.project":"myapp"}
+["localhost/paulenv:other","localhost/paulenv:alias"]	2024-01-02 03:04:05.5 +0000 UTC	{"paulenv":"true","paulenv.project":"other"}
+[]	2024-01-02T03:04:05Z	{"paulenv":"true"}
+`
+	images := parseImageInspectOutput(output)
+	if len(images) != 3 {
+		t.Fatalf("expected 3 images, got %d: %+v", len(images), images)
+	}
+	expectedTime := time.Date(2024, 1, 2, 3, 4, 5, 500000000, time.UTC)
+	expected := []struct{ image, project string }{
+		{"paulenv:myapp", "myapp"},
+		{"localhost/paulenv:other", "other"},
+		{"localhost/paulenv:alias", "other"},
+	}
+	for i, exp := range expected {
+		if images[i].ImageName != exp.image {
+			t.Errorf("image %d: expected name %q, got %q", i, exp.image, images[i].ImageName)
+		}
+		if images[i].ProjectName == nil || *images[i].ProjectName != exp.project {
+			t.Errorf("image %d: expected project %q, got %v", i, exp.project, images[i].ProjectName)
+		}
+		if images[i].BuiltAt == nil || !images[i].BuiltAt.Equal(expectedTime) {
+			t.Errorf("image %d: unexpected build date %v", i, images[i].BuiltAt)
+		}
+	}
+}
+
+func TestParseLegacyImageIds(t *testing.T) {
+	output := `sha256:aaa	paulenv:myapp
+sha256:bbb	localhost/paulenv:other
+sha256:ccc	ubuntu:24.04
+sha256:ddd	<none>:<none>
+`
+	ids := parseLegacyImageIds(output)
+	if !reflect.DeepEqual(ids, []string{"sha256:aaa", "sha256:bbb"}) {
+		t.Errorf("unexpected ids %v", ids)
+	}
+}
+
+func TestLabelArgs(t *testing.T) {
+	got := labelArgs(map[string]string{LabelProject: "myapp", LabelOwner: "true"})
+	expected := []string{"--label", "paulenv=true", "--label", "paulenv.project=myapp"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("labelArgs() = %v, want %v", got, expected)
+	}
+}
+
+func TestParseTopOutput(t *testing.T) {
+	output := `PID                 TTY                 ELAPSED             COMMAND
+1201                ?                   01:02:03            /sbin/docker-init -- /usr/local/bin/entrypoint.sh
+1250                pts/0               01:02:03            /bin/bash
+1302                pts/0               00:10               vim some file.txt
+1400                pts/1               00:05               /bin/zsh -l
+`
+	sessions := parseTopOutput(output)
+	expected := []SessionInfo{
+		{TTY: "pts/0", Command: "/bin/bash", Elapsed: "01:02:03"},
+		{TTY: "pts/1", Command: "/bin/zsh -l", Elapsed: "00:05"},
+	}
+	if !reflect.DeepEqual(sessions, expected) {
+		t.Errorf("parseTopOutput() = %+v, want %+v", sessions, expected)
+	}
+
+	if sessions := parseTopOutput("PID TTY ELAPSED COMMAND\n"); len(sessions) != 0 {
+		t.Errorf("expected no session, got %+v", sessions)
+	}
+}
+
+func TestExecArgs(t *testing.T) {
+	container := ContainerInfo{ContainerId: "abc"}
+	tests := []struct {
+		name     string
+		opts     ExecOptions
+		expected string
+	}{
+		{"through entrypoint", ExecOptions{Args: []string{"ls", "-la"}},
+			"exec -i abc /usr/local/bin/entrypoint.sh ls -la"},
+		{"all options", ExecOptions{Args: []string{"id"}, Tty: true, Workdir: "/tmp", Env: []string{"A=1", "B=2"}, User: "root"},
+			"exec -i -t -w /tmp -e A=1 -e B=2 -u root abc id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(execArgs(container, tt.opts), " ")
+			if got != tt.expected {
+				t.Errorf("execArgs() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyContainerInspectOutput(t *testing.T) {
+	containers := []ContainerInfo{{ContainerId: "abc"}, {ContainerId: "def"}, {ContainerId: "ghi"}}
+	output := `abc	2024-01-02T03:04:05.5Z	2024-01-02T04:00:00Z
+def	2024-01-02 03:04:05.5 +0000 UTC	0001-01-01 00:00:00 +0000 UTC
+`
+	applyContainerInspectOutput(output, containers)
+	if containers[0].CreatedAt == nil || !containers[0].CreatedAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 500000000, time.UTC)) {
+		t.Errorf("unexpected creation date %v", containers[0].CreatedAt)
+	}
+	if containers[0].StartedAt == nil || !containers[0].StartedAt.Equal(time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start date %v", containers[0].StartedAt)
+	}
+	if containers[1].CreatedAt == nil || containers[1].StartedAt != nil {
+		t.Errorf("expected a creation date but no start date, got %v and %v", containers[1].CreatedAt, containers[1].StartedAt)
+	}
+	if containers[2].CreatedAt != nil || containers[2].StartedAt != nil {
+		t.Errorf("expected no date for an absent container, got %v and %v", containers[2].CreatedAt, containers[2].StartedAt)
+	}
+}
+
+func TestParseSystemDfVolumes(t *testing.T) {
+	output := `Images space usage:
+
+REPOSITORY   TAG       IMAGE ID       CREATED       SIZE      SHARED SIZE   UNIQUE SIZE   CONTAINERS
+paulenv      myapp     0123456789ab   2 hours ago   1.2GB     0B            1.2GB         1
+
+Local Volumes space usage:
+
+VOLUME NAME            LINKS     SIZE
+paulenv-myapp-local    1         12.5MB
+paulenv-shared-cache   2         1.234GB
+empty                  0         0B
+
+Build cache usage: 0B
+`
+	sizes := parseSystemDfVolumes(output)
+	expected := map[string]int64{
+		"paulenv-myapp-local":  12500000,
+		"paulenv-shared-cache": 1234000000,
+		"empty":                0,
+	}
+	if !reflect.DeepEqual(sizes, expected) {
+		t.Errorf("parseSystemDfVolumes() = %v, want %v", sizes, expected)
+	}
+}
+
+func TestParseHumanSize(t *testing.T) {
+	tests := map[string]int64{"0B": 0, "999B": 999, "12.5kB": 12500, "1.5 MB": 1500000, "2GB": 2000000000}
+	for input, expected := range tests {
+		got, ok := parseHumanSize(strings.ReplaceAll(input, " ", ""))
+		if !ok || got != expected {
+			t.Errorf("parseHumanSize(%q) = %d, %t, want %d", input, got, ok, expected)
+		}
+	}
+	if _, ok := parseHumanSize("lots"); ok {
+		t.Error("expected an invalid size to be rejected")
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,7 +35,9 @@ func (c *DockerEngine) BuildImage(ctx context.Context, project files.ProjectEntr
 		"DOTFILES_DIR="+relativeDotfilesDir,
 	)
 	cmd.Env = envVars
-	cmd.Stdout = os.Stdout
+	// Build progress is not the result of the `build` command: keep the
+	// standard output for it.
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
@@ -43,22 +48,37 @@ func (c *DockerEngine) BuildImage(ctx context.Context, project files.ProjectEntr
 	return nil
 }
 
-func (c *DockerEngine) RunContainer(ctx context.Context, project files.ProjectEntry, args []string) error {
-	cmdArgs := []string{"compose", "-f", project.ComposeFilePath, "--env-file", project.EnvFilePath, "run", "--rm", "paulenv"}
-	cmdArgs = append(cmdArgs, args...)
-	cmd := exec.CommandContext(ctx, "docker", cmdArgs...)
+func (c *DockerEngine) UpContainer(ctx context.Context, project files.ProjectEntry) error {
+	cmd := exec.CommandContext(ctx, "docker", "compose", "-f", project.ComposeFilePath, "--env-file", project.EnvFilePath, "up", "-d", "paulenv")
+	cmd.Env = append(os.Environ(),
+		"COMPOSE_PROJECT_NAME=paulenv-"+project.ProjectName,
+		keepAliveEnv,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("Up failed: %w", err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) DownContainer(ctx context.Context, project files.ProjectEntry) error {
+	cmd := exec.CommandContext(ctx, "docker", "compose", "-f", project.ComposeFilePath, "--env-file", project.EnvFilePath, "down")
 	cmd.Env = append(os.Environ(), "COMPOSE_PROJECT_NAME=paulenv-"+project.ProjectName)
-	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
-		return fmt.Errorf("Run failed: %w", err)
+		return fmt.Errorf("Down failed: %w", err)
 	}
 	return nil
 }
+
 func (c *DockerEngine) JoinContainer(ctx context.Context, containerInfo ContainerInfo, args []string) error {
 	cmdArgs := []string{"exec", "-it", containerInfo.ContainerId, "/usr/local/bin/entrypoint.sh"}
 	cmdArgs = append(cmdArgs, args...)
@@ -75,6 +95,52 @@ func (c *DockerEngine) JoinContainer(ctx context.Context, containerInfo Containe
 	return nil
 }
 
+func (c *DockerEngine) ExecContainer(ctx context.Context, containerInfo ContainerInfo, opts ExecOptions) error {
+	cmd := exec.CommandContext(ctx, "docker", execArgs(containerInfo, opts)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return asExitCodeError(err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) StopContainer(ctx context.Context, container ContainerInfo, timeout time.Duration) error {
+	seconds := strconv.Itoa(int(timeout.Seconds()))
+	cmd := exec.CommandContext(ctx, "docker", "stop", "-t", seconds, container.ContainerId)
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to stop container %s: %w", container.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) KillContainer(ctx context.Context, container ContainerInfo) error {
+	cmd := exec.CommandContext(ctx, "docker", "kill", container.ContainerId)
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to kill container %s: %w", container.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) ListSessions(ctx context.Context, container ContainerInfo) ([]SessionInfo, error) {
+	cmd := exec.CommandContext(ctx, "docker", append([]string{"top", container.ContainerId, "-o"}, strings.Join(topArgs, ","))...)
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return []SessionInfo{}, pErr
+		}
+		return []SessionInfo{}, fmt.Errorf("failed to list processes of container %s: %w", container.ContainerId, err)
+	}
+	return parseTopOutput(string(output)), nil
+}
+
 func (c *DockerEngine) HasBeenBuilt(ctx context.Context, projectName string) (bool, error) {
 	imageName := fmt.Sprintf("paulenv:%s", projectName)
 	cmd := exec.CommandContext(ctx, "docker", "image", "inspect", imageName)
@@ -114,8 +180,21 @@ func (c *DockerEngine) Info(ctx context.Context) (EngineInfo, error) {
 	return EngineInfo{}, fmt.Errorf("failed to obtain docker version, unknown version format: %s", parsed)
 }
 
-func (c *DockerEngine) CreateVolume(ctx context.Context, name string) error {
-	cmd := exec.CommandContext(ctx, "docker", "volume", "create", name)
+func (c *DockerEngine) GetVolumeSizes(ctx context.Context) (map[string]int64, error) {
+	cmd := exec.CommandContext(ctx, "docker", "system", "df", "-v")
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to obtain volume sizes: %w", err)
+	}
+	return parseSystemDfVolumes(string(output)), nil
+}
+
+func (c *DockerEngine) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	cmdArgs := append([]string{"volume", "create"}, labelArgs(labels)...)
+	cmd := exec.CommandContext(ctx, "docker", append(cmdArgs, name)...)
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
@@ -126,6 +205,77 @@ func (c *DockerEngine) CreateVolume(ctx context.Context, name string) error {
 	return nil
 }
 
+func (c *DockerEngine) CopyImage(ctx context.Context, sourceProject string, targetProject string) error {
+	err := buildImageCopy(ctx, "docker", "paulenv:"+sourceProject, "paulenv:"+targetProject, targetProject)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("Failed to copy image: %w", err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) CopyVolume(ctx context.Context, sourceVolume string, targetVolume string, imageProject string) error {
+	if err := runVolumeCopy(ctx, "docker", sourceVolume, targetVolume, "paulenv:"+imageProject); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to copy volume %s to %s: %w", sourceVolume, targetVolume, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) SaveImage(ctx context.Context, projectName string, w io.Writer) error {
+	if err := runImageSave(ctx, "docker", "paulenv:"+projectName, w); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) LoadImage(ctx context.Context, r io.Reader) error {
+	if err := runImageLoad(ctx, "docker", r); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) ExportVolume(ctx context.Context, volumeName string, imageProject string, w io.Writer) error {
+	if err := runVolumeExport(ctx, "docker", volumeName, "paulenv:"+imageProject, w); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to export volume %s: %w", volumeName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) ImportVolume(ctx context.Context, volumeName string, imageProject string, r io.Reader) error {
+	if err := runVolumeImport(ctx, "docker", volumeName, "paulenv:"+imageProject, r); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to import volume %s: %w", volumeName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) RunInVolume(ctx context.Context, volumeName string, imageProject string, w io.Writer, args ...string) error {
+	if err := runInVolume(ctx, "docker", volumeName, "paulenv:"+imageProject, w, args...); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to run command in volume %s: %w", volumeName, err)
+	}
+	return nil
+}
+
 func (c *DockerEngine) GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error) {
 	imageName := fmt.Sprintf("paulenv:%s", projectName)
 	info := &ImageInfo{ImageName: imageName, ProjectName: &projectName}
@@ -148,7 +298,7 @@ func (c *DockerEngine) GetImageInfo(ctx context.Context, projectName string) (*I
 }
 
 func (c *DockerEngine) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "ps", "-a", "--filter", "name=paulenv-", "--format", "{{.ID}}\t{{.Image}}\t{{.Names}}")
+	cmd := exec.CommandContext(ctx, "docker", "ps", "-a", "--no-trunc", "--format", "{{.ID}}\t{{.Image}}\t{{.Names}}\t{{.State}}\t{{json .Labels}}")
 	output, err := cmd.Output()
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
@@ -161,32 +311,35 @@ func (c *DockerEngine) ListContainers(ctx context.Context) ([]ContainerInfo, err
 	result := make([]ContainerInfo, 0, len(lines))
 	for _, s := range lines {
 		if s != "" {
-			parts := strings.SplitN(s, "\t", 3)
-			id := parts[0]
-			var image *string
-			var name *string
-			var projectName *string
-
-			if len(parts) > 1 {
-				image = &parts[1]
-			}
-			if len(parts) > 2 {
-				name = &parts[2]
-			}
-
-			if image != nil && strings.HasPrefix(*image, "paulenv:") && len(*image) > len("paulenv:") {
-				sliced := (*image)[len("paulenv:"):]
-				projectName = &sliced
+			parts := strings.SplitN(s, "\t", 5)
+			if len(parts) < 5 {
+				continue
 			}
-
+			labels := parseFormattedLabels(parts[4])
 			result = append(result, ContainerInfo{
-				ProjectName:   projectName,
-				ContainerName: name,
-				ContainerId:   id,
-				ImageName:     image,
+				ProjectName:   projectNameFromLabels(labels),
+				ContainerName: &parts[2],
+				ContainerId:   parts[0],
+				ImageName:     &parts[1],
+				State:         parts[3],
+				Labels:        labels,
 			})
 		}
 	}
+	result = ownedContainers(result)
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	// `ps` cannot output precise creation and start dates, inspect them
+	cmdArgs := []string{"container", "inspect", "--format", containerInspectFormat}
+	for _, container := range result {
+		cmdArgs = append(cmdArgs, container.ContainerId)
+	}
+	// Those dates are only informative: they are just left unknown if a
+	// container disappeared in-between
+	output, _ = exec.CommandContext(ctx, "docker", cmdArgs...).Output()
+	applyContainerInspectOutput(string(output), result)
 	return result, nil
 }
 
@@ -220,7 +373,7 @@ func (c *DockerEngine) checkPermissions(ctx context.Context) error {
 
 // List volumes currently known by this container engine
 func (c *DockerEngine) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "volume", "ls", "--filter", "name=paulenv-", "--format", "{{.Name}}")
+	cmd := exec.CommandContext(ctx, "docker", "volume", "ls", "--format", "{{.Name}}\t{{json .Labels}}")
 	output, err := cmd.Output()
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
@@ -231,15 +384,19 @@ func (c *DockerEngine) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
 
 	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
 	result := make([]VolumeInfo, 0, len(lines))
-	for _, volumeName := range lines {
+	for _, line := range lines {
+		volumeName, rawLabels, _ := strings.Cut(line, "\t")
 		if volumeName != "" {
+			labels := parseFormattedLabels(rawLabels)
 			result = append(result, VolumeInfo{
-				VolumeId:   volumeName,
-				VolumeName: volumeName,
+				VolumeId:    volumeName,
+				VolumeName:  volumeName,
+				ProjectName: projectNameFromLabels(labels),
+				Labels:      labels,
 			})
 		}
 	}
-	return result, nil
+	return ownedVolumes(result), nil
 }
 
 func (c *DockerEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) error {
@@ -255,7 +412,7 @@ func (c *DockerEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) erro
 
 // List networks currently known by this container engine
 func (c *DockerEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "network", "ls", "--filter", "name=paulenv-", "--format", "{{.ID}}\t{{.Name}}")
+	cmd := exec.CommandContext(ctx, "docker", "network", "ls", "--format", "{{.ID}}\t{{.Name}}\t{{json .Labels}}")
 	output, err := cmd.Output()
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
@@ -267,35 +424,19 @@ func (c *DockerEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error)
 	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
 	result := make([]NetworkInfo, 0, len(lines))
 	for _, line := range lines {
-		if line != "" {
-			parts := strings.SplitN(line, "\t", 2)
-			if len(parts) >= 2 {
-				networkId := parts[0]
-				networkName := parts[1]
-				var projectName *string
-
-				// Extract project name from network name if it follows the pattern "paulenv-{project}_default"
-				if strings.HasPrefix(networkName, "paulenv-") {
-					// Remove "paulenv-" prefix
-					withoutPrefix := networkName[len("paulenv-"):]
-					// Remove "_default" suffix if present
-					if strings.HasSuffix(withoutPrefix, "_default") {
-						sliced := withoutPrefix[:len(withoutPrefix)-len("_default")]
-						projectName = &sliced
-					} else {
-						projectName = &withoutPrefix
-					}
-				}
-
-				result = append(result, NetworkInfo{
-					NetworkId:   networkId,
-					NetworkName: networkName,
-					ProjectName: projectName,
-				})
-			}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
 		}
+		labels := parseFormattedLabels(parts[2])
+		result = append(result, NetworkInfo{
+			NetworkId:   parts[0],
+			NetworkName: parts[1],
+			ProjectName: projectNameFromLabels(labels),
+			Labels:      labels,
+		})
 	}
-	return result, nil
+	return ownedNetworks(result), nil
 }
 
 // Remove network listed from this container engine
@@ -326,7 +467,7 @@ func (c *DockerEngine) PruneBuildCache(ctx context.Context) error {
 
 // List images currently known by this container engine
 func (c *DockerEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "images", "--filter", "reference=paulenv:*", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}")
+	cmd := exec.CommandContext(ctx, "docker", "images", "-q", "--no-trunc", "--filter", ownerLabelFilter)
 	output, err := cmd.Output()
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
@@ -334,47 +475,27 @@ func (c *DockerEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
 		}
 		return []ImageInfo{}, fmt.Errorf("failed to list images: %w", err)
 	}
+	ids := strings.Fields(string(output))
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	result := make([]ImageInfo, 0, len(lines))
-	for _, line := range lines {
-		if line != "" {
-			parts := strings.SplitN(line, "\t", 2)
-			imageName := parts[0]
-			var projectName *string
-			var builtAt *time.Time
-
-			// Extract project name from image name if it follows the pattern "paulenv:{project}"
-			if strings.HasPrefix(imageName, "paulenv:") && len(imageName) > len("paulenv:") {
-				sliced := imageName[len("paulenv:"):]
-				projectName = &sliced
-			}
-
-			// Parse build time if available
-			if len(parts) > 1 {
-				timeStr := strings.TrimSpace(parts[1])
-				// Docker's CreatedAt format can vary, try common formats
-				formats := []string{
-					"2006-01-02 15:04:05 -0700 MST",
-					time.RFC3339,
-					time.RFC3339Nano,
-				}
-				for _, format := range formats {
-					if parsedTime, err := time.Parse(format, timeStr); err == nil {
-						builtAt = &parsedTime
-						break
-					}
-				}
-			}
+	// Images built before they were labeled are recognized through their name
+	cmd = exec.CommandContext(ctx, "docker", "images", "--no-trunc", "--format", imageListFormat)
+	if output, err = cmd.Output(); err != nil {
+		return []ImageInfo{}, fmt.Errorf("failed to list images: %w", err)
+	}
+	ids = append(ids, parseLegacyImageIds(string(output))...)
+	if len(ids) == 0 {
+		return []ImageInfo{}, nil
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
 
-			result = append(result, ImageInfo{
-				ImageName:   imageName,
-				ProjectName: projectName,
-				BuiltAt:     builtAt,
-			})
-		}
+	// `docker images` cannot output labels, inspect them to obtain those
+	cmdArgs := append([]string{"image", "inspect", "--format", imageInspectFormat}, ids...)
+	output, err = exec.CommandContext(ctx, "docker", cmdArgs...).Output()
+	if err != nil {
+		return []ImageInfo{}, fmt.Errorf("failed to inspect images: %w", err)
 	}
-	return result, nil
+	return ownedImages(parseImageInspectOutput(string(output))), nil
 }
 
 // Remove image from this container engine
@@ -0,0 +1,338 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Version of the Docker Engine API we're relying on (Docker 20.10+)
+const dockerAPIVersion = "v1.41"
+
+// Implements `ContainerEngine` for docker, relying on the Docker Engine HTTP
+// API to obtain information on containers, images, volumes and networks.
+//
+// Operations relying on compose (build, run...) still go through the docker
+// CLI.
+type DockerAPIEngine struct {
+	*DockerEngine
+	client *dockerAPIClient
+}
+
+func newDockerAPI(ctx context.Context) (*DockerAPIEngine, error) {
+	docker, err := newDocker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newDockerAPIClient(os.Getenv("DOCKER_HOST"))
+	if err != nil {
+		return nil, err
+	}
+	if err := client.get(ctx, "/_ping", nil, nil); err != nil {
+		return nil, fmt.Errorf("cannot reach the Docker Engine API: %w", err)
+	}
+	return &DockerAPIEngine{DockerEngine: docker, client: client}, nil
+}
+
+func (c *DockerAPIEngine) Info(ctx context.Context) (EngineInfo, error) {
+	var version struct {
+		Version string
+	}
+	if err := c.client.get(ctx, "/version", nil, &version); err != nil {
+		return EngineInfo{}, fmt.Errorf("failed to obtain docker version: %w", err)
+	}
+	// Same name than the CLI-based implementation: both build the same images
+	return EngineInfo{Name: "docker", Version: version.Version}, nil
+}
+
+func (c *DockerAPIEngine) GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error) {
+	imageName := fmt.Sprintf("paulenv:%s", projectName)
+	info := &ImageInfo{ImageName: imageName, ProjectName: &projectName}
+
+	var image struct {
+		Created time.Time
+		Config  struct {
+			Labels map[string]string
+		}
+	}
+	err := c.client.get(ctx, "/images/"+url.PathEscape(imageName)+"/json", nil, &image)
+	if err != nil {
+		var apiErr *dockerAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return info, nil
+		}
+		return nil, err
+	}
+	info.BuiltAt = &image.Created
+	info.Labels = image.Config.Labels
+	return info, nil
+}
+
+func (c *DockerAPIEngine) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
+	var containers []struct {
+		Id      string
+		Names   []string
+		Image   string
+		Labels  map[string]string
+		State   string
+		Created int64
+	}
+	// Unlabeled containers created before paul-envs labeled them are
+	// recognized through their image name, they cannot be filtered here
+	query := url.Values{"all": {"1"}}
+	if err := c.client.get(ctx, "/containers/json", query, &containers); err != nil {
+		return []ContainerInfo{}, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	result := make([]ContainerInfo, 0, len(containers))
+	for _, container := range containers {
+		var name *string
+		if len(container.Names) > 0 {
+			// The API prefixes names by a "/"
+			trimmed := strings.TrimPrefix(container.Names[0], "/")
+			name = &trimmed
+		}
+		image := container.Image
+		createdAt := time.Unix(container.Created, 0)
+		result = append(result, ContainerInfo{
+			ProjectName:   projectNameFromLabels(container.Labels),
+			ContainerName: name,
+			ImageName:     &image,
+			ContainerId:   container.Id,
+			State:         container.State,
+			CreatedAt:     &createdAt,
+			StartedAt:     c.getContainerStartDate(ctx, container.Id, container.State),
+			Labels:        container.Labels,
+		})
+	}
+	return ownedContainers(result), nil
+}
+
+// Returns the last start date of a running container, as it isn't part of
+// the container list. `nil` if unknown or if that container is not running.
+func (c *DockerAPIEngine) getContainerStartDate(ctx context.Context, containerId string, state string) *time.Time {
+	if state != "running" {
+		return nil
+	}
+	var container struct {
+		State struct {
+			StartedAt time.Time
+		}
+	}
+	err := c.client.get(ctx, "/containers/"+url.PathEscape(containerId)+"/json", nil, &container)
+	if err != nil || container.State.StartedAt.IsZero() {
+		return nil
+	}
+	return &container.State.StartedAt
+}
+
+func (c *DockerAPIEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
+	var images []struct {
+		RepoTags []string
+		Created  int64
+		Labels   map[string]string
+	}
+	// Unlabeled images created before paul-envs labeled them are recognized
+	// through their name, they cannot be filtered here
+	if err := c.client.get(ctx, "/images/json", nil, &images); err != nil {
+		return []ImageInfo{}, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	result := make([]ImageInfo, 0, len(images))
+	for _, image := range images {
+		builtAt := time.Unix(image.Created, 0)
+		for _, tag := range image.RepoTags {
+			if strings.HasSuffix(tag, "<none>") {
+				continue
+			}
+			result = append(result, ImageInfo{
+				ProjectName: projectNameFromLabels(image.Labels),
+				ImageName:   tag,
+				BuiltAt:     &builtAt,
+				Labels:      image.Labels,
+			})
+		}
+	}
+	return ownedImages(result), nil
+}
+
+func (c *DockerAPIEngine) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
+	var response struct {
+		Volumes []struct {
+			Name      string
+			Labels    map[string]string
+			CreatedAt string
+		}
+	}
+	// Unlabeled volumes created before paul-envs labeled them are recognized
+	// through their name, they cannot be filtered here
+	if err := c.client.get(ctx, "/volumes", nil, &response); err != nil {
+		return []VolumeInfo{}, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	result := make([]VolumeInfo, 0, len(response.Volumes))
+	for _, volume := range response.Volumes {
+		var createdAt *time.Time
+		if parsed, err := time.Parse(time.RFC3339Nano, volume.CreatedAt); err == nil {
+			createdAt = &parsed
+		}
+		result = append(result, VolumeInfo{
+			VolumeId:    volume.Name,
+			VolumeName:  volume.Name,
+			ProjectName: projectNameFromLabels(volume.Labels),
+			CreatedAt:   createdAt,
+			Labels:      volume.Labels,
+		})
+	}
+	return ownedVolumes(result), nil
+}
+
+func (c *DockerAPIEngine) GetVolumeSizes(ctx context.Context) (map[string]int64, error) {
+	var usage struct {
+		Volumes []struct {
+			Name      string
+			UsageData struct {
+				// -1 if not computed
+				Size int64
+			}
+		}
+	}
+	query := url.Values{"type": {"volume"}}
+	if err := c.client.get(ctx, "/system/df", query, &usage); err != nil {
+		return nil, fmt.Errorf("failed to obtain volume sizes: %w", err)
+	}
+	sizes := make(map[string]int64, len(usage.Volumes))
+	for _, volume := range usage.Volumes {
+		if volume.UsageData.Size >= 0 {
+			sizes[volume.Name] = volume.UsageData.Size
+		}
+	}
+	return sizes, nil
+}
+
+func (c *DockerAPIEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error) {
+	var networks []struct {
+		Id      string
+		Name    string
+		Created time.Time
+		Labels  map[string]string
+	}
+	// Unlabeled networks created before paul-envs labeled them are recognized
+	// through their name, they cannot be filtered here
+	if err := c.client.get(ctx, "/networks", nil, &networks); err != nil {
+		return []NetworkInfo{}, fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	result := make([]NetworkInfo, 0, len(networks))
+	for _, network := range networks {
+		result = append(result, NetworkInfo{
+			NetworkId:   network.Id,
+			NetworkName: network.Name,
+			ProjectName: projectNameFromLabels(network.Labels),
+			Labels:      network.Labels,
+		})
+	}
+	return ownedNetworks(result), nil
+}
+
+// Minimal client for the Docker Engine HTTP API
+type dockerAPIClient struct {
+	httpClient *http.Client
+	// Base URL to which API paths are appended, e.g. "http://docker"
+	baseURL string
+}
+
+// Error returned by the Docker Engine API itself
+type dockerAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *dockerAPIError) Error() string {
+	return fmt.Sprintf("docker API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// Create a client for the Docker Engine API listening at `host`, in the
+// `DOCKER_HOST` format (e.g. "unix:///var/run/docker.sock" or
+// "tcp://127.0.0.1:2375").
+//
+// An empty `host` means docker's default socket.
+func newDockerAPIClient(host string) (*dockerAPIClient, error) {
+	if host == "" {
+		host = "unix:///var/run/docker.sock"
+	}
+	scheme, address, ok := strings.Cut(host, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid docker host '%s'", host)
+	}
+
+	switch scheme {
+	case "unix":
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", address)
+			},
+		}
+		return &dockerAPIClient{
+			httpClient: &http.Client{Transport: transport},
+			// The host is ignored when dialing a unix socket
+			baseURL: "http://docker",
+		}, nil
+	case "tcp", "http":
+		return &dockerAPIClient{
+			httpClient: &http.Client{},
+			baseURL:    "http://" + address,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme '%s'", scheme)
+	}
+}
+
+// Perform a GET request on the given API `path` and decode its JSON response
+// into `out`, if not `nil`.
+func (c *dockerAPIClient) get(ctx context.Context, path string, query url.Values, out any) error {
+	reqURL := c.baseURL + "/" + dockerAPIVersion + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if errors.Is(err, syscall.EACCES) {
+			return errors.New("permission denied. Please run with elevated privileges")
+		}
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(body, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return &dockerAPIError{StatusCode: resp.StatusCode, Message: apiErr.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid docker API response for %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package engine
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// Create a `DockerAPIEngine` talking to a fake Docker Engine API, whose
+// responses are given as a map of paths to JSON bodies.
+func newFakeDockerAPIEngine(t *testing.T, responses map[string]string) *DockerAPIEngine {
+	mux := http.NewServeMux()
+	for path, body := range responses {
+		mux.HandleFunc("/"+dockerAPIVersion+path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		})
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return &DockerAPIEngine{
+		DockerEngine: &DockerEngine{},
+		client:       &dockerAPIClient{httpClient: srv.Client(), baseURL: srv.URL},
+	}
+}
+
+func TestDockerAPI_ListContainers(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+dockerAPIVersion+"/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{
+			"Id": "abc123",
+			"Names": ["/paulenv-myapp-paulenv-run-1"],
+			"Image": "paulenv:myapp",
+			"Labels": {"paulenv": "true", "paulenv.project": "myapp"},
+			"State": "running",
+			"Created": 1700000000
+		}, {
+			"Id": "legacy1",
+			"Names": ["/paulenv-old-paulenv-1"],
+			"Image": "paulenv:old",
+			"Labels": {"com.docker.compose.project": "paulenv-old"},
+			"State": "exited",
+			"Created": 1600000000
+		}, {
+			"Id": "other1",
+			"Names": ["/unrelated"],
+			"Image": "ubuntu:24.04",
+			"Labels": {},
+			"State": "running",
+			"Created": 1600000000
+		}]`))
+	})
+	mux.HandleFunc("/"+dockerAPIVersion+"/containers/abc123/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"State": {"StartedAt": "2023-11-14T22:15:00.5Z"}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c := &DockerAPIEngine{client: &dockerAPIClient{httpClient: srv.Client(), baseURL: srv.URL}}
+
+	containers, err := c.ListContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ListContainers() error = %v", err)
+	}
+
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 containers, got %d", len(containers))
+	}
+	ctr := containers[0]
+	if ctr.ContainerId != "abc123" {
+		t.Errorf("unexpected id %q", ctr.ContainerId)
+	}
+	if ctr.ContainerName == nil || *ctr.ContainerName != "paulenv-myapp-paulenv-run-1" {
+		t.Errorf("unexpected name %v", ctr.ContainerName)
+	}
+	if ctr.ProjectName == nil || *ctr.ProjectName != "myapp" {
+		t.Errorf("unexpected project name %v", ctr.ProjectName)
+	}
+	if ctr.State != "running" {
+		t.Errorf("unexpected state %q", ctr.State)
+	}
+	if ctr.CreatedAt == nil || !ctr.CreatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected creation date %v", ctr.CreatedAt)
+	}
+	if ctr.StartedAt == nil || !ctr.StartedAt.Equal(time.Date(2023, 11, 14, 22, 15, 0, 500000000, time.UTC)) {
+		t.Errorf("unexpected start date %v", ctr.StartedAt)
+	}
+	if ctr.Labels["paulenv"] != "true" {
+		t.Errorf("unexpected labels %v", ctr.Labels)
+	}
+	// Created before containers were labeled
+	if containers[1].ContainerId != "legacy1" || containers[1].ProjectName == nil || *containers[1].ProjectName != "old" {
+		t.Errorf("unexpected legacy container %+v", containers[1])
+	}
+}
+
+func TestDockerAPI_ListImages(t *testing.T) {
+	c := newFakeDockerAPIEngine(t, map[string]string{
+		"/images/json": `[
+			{"RepoTags": ["paulenv:first", "other:tag"], "Created": 1700000000, "Labels": {"paulenv": "true", "paulenv.project": "first"}},
+			{"RepoTags": ["paulenv:second"], "Created": 1700000100, "Labels": {"paulenv": "true", "paulenv.project": "second"}},
+			{"RepoTags": ["<none>:<none>"], "Created": 1700000200, "Labels": {"paulenv": "true"}},
+			{"RepoTags": ["paulenv:legacy"], "Created": 1600000000, "Labels": null},
+			{"RepoTags": ["ubuntu:24.04"], "Created": 1600000000, "Labels": null}
+		]`,
+	})
+	images, err := c.ListImages(context.Background())
+	if err != nil {
+		t.Fatalf("ListImages() error = %v", err)
+	}
+	if len(images) != 4 {
+		t.Fatalf("expected 4 images, got %d: %v", len(images), images)
+	}
+	if images[0].ImageName != "paulenv:first" || *images[0].ProjectName != "first" {
+		t.Errorf("unexpected first image %+v", images[0])
+	}
+	if images[0].BuiltAt == nil || !images[0].BuiltAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected build date %v", images[0].BuiltAt)
+	}
+	if images[0].Labels["paulenv"] != "true" {
+		t.Errorf("unexpected labels %v", images[0].Labels)
+	}
+	// All tags of an image are linked to its project
+	if images[1].ImageName != "other:tag" || *images[1].ProjectName != "first" {
+		t.Errorf("unexpected second image %+v", images[1])
+	}
+	if images[2].ImageName != "paulenv:second" || *images[2].ProjectName != "second" {
+		t.Errorf("unexpected third image %+v", images[2])
+	}
+	// Built before images were labeled
+	if images[3].ImageName != "paulenv:legacy" || images[3].ProjectName == nil || *images[3].ProjectName != "legacy" {
+		t.Errorf("unexpected legacy image %+v", images[3])
+	}
+}
+
+func TestDockerAPI_ListVolumes(t *testing.T) {
+	c := newFakeDockerAPIEngine(t, map[string]string{
+		"/volumes": `{"Volumes": [
+			{"Name": "paulenv-shared-cache", "CreatedAt": "2024-01-02T03:04:05Z", "Labels": {"paulenv": "true"}},
+			{"Name": "paulenv-myapp-local", "CreatedAt": "", "Labels": {"paulenv": "true", "paulenv.project": "myapp"}}
+		], "Warnings": null}`,
+	})
+	volumes, err := c.ListVolumes(context.Background())
+	if err != nil {
+		t.Fatalf("ListVolumes() error = %v", err)
+	}
+	if len(volumes) != 2 || volumes[0].VolumeName != "paulenv-shared-cache" {
+		t.Fatalf("unexpected volumes %+v", volumes)
+	}
+	expected := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if volumes[0].CreatedAt == nil || !volumes[0].CreatedAt.Equal(expected) {
+		t.Errorf("unexpected creation date %v", volumes[0].CreatedAt)
+	}
+	if volumes[0].ProjectName != nil {
+		t.Errorf("shared volume should not be linked to a project, got %v", *volumes[0].ProjectName)
+	}
+	if volumes[1].ProjectName == nil || *volumes[1].ProjectName != "myapp" {
+		t.Errorf("unexpected project name %v", volumes[1].ProjectName)
+	}
+	if volumes[1].CreatedAt != nil {
+		t.Errorf("expected no creation date, got %v", volumes[1].CreatedAt)
+	}
+}
+
+func TestDockerAPI_GetVolumeSizes(t *testing.T) {
+	c := newFakeDockerAPIEngine(t, map[string]string{
+		"/system/df": `{"Volumes": [
+			{"Name": "paulenv-myapp-local", "UsageData": {"Size": 1234, "RefCount": 1}},
+			{"Name": "not-computed", "UsageData": {"Size": -1, "RefCount": 0}}
+		]}`,
+	})
+	sizes, err := c.GetVolumeSizes(context.Background())
+	if err != nil {
+		t.Fatalf("GetVolumeSizes() error = %v", err)
+	}
+	if len(sizes) != 1 || sizes["paulenv-myapp-local"] != 1234 {
+		t.Errorf("unexpected sizes %v", sizes)
+	}
+}
+
+func TestDockerAPI_ListNetworks(t *testing.T) {
+	c := newFakeDockerAPIEngine(t, map[string]string{
+		"/networks": `[
+			{"Id": "net1", "Name": "paulenv-myapp_default", "Labels": {"paulenv": "true", "paulenv.project": "myapp"}},
+			{"Id": "net2", "Name": "paulenv-unrelated", "Labels": {"paulenv.project": "unrelated"}},
+			{"Id": "net3", "Name": "paulenv-old_default", "Labels": {}},
+			{"Id": "net4", "Name": "paulenv-shared", "Labels": {"paulenv": "true"}}
+		]`,
+	})
+	networks, err := c.ListNetworks(context.Background())
+	if err != nil {
+		t.Fatalf("ListNetworks() error = %v", err)
+	}
+	if len(networks) != 3 {
+		t.Fatalf("expected 3 networks, got %d", len(networks))
+	}
+	if networks[0].ProjectName == nil || *networks[0].ProjectName != "myapp" {
+		t.Errorf("unexpected project name %v", networks[0].ProjectName)
+	}
+	// Created before networks were labeled
+	if networks[1].NetworkId != "net3" || networks[1].ProjectName == nil || *networks[1].ProjectName != "old" {
+		t.Errorf("unexpected legacy network %+v", networks[1])
+	}
+	if networks[2].ProjectName != nil {
+		t.Errorf("expected no project name, got %v", *networks[2].ProjectName)
+	}
+}
+
+func TestDockerAPI_GetImageInfo(t *testing.T) {
+	c := newFakeDockerAPIEngine(t, map[string]string{
+		"/images/paulenv:myapp/json": `{"Created": "2024-01-02T03:04:05.123456789Z", "Config": {"Labels": {"paulenv": "true"}}}`,
+	})
+
+	info, err := c.GetImageInfo(context.Background(), "myapp")
+	if err != nil {
+		t.Fatalf("GetImageInfo() error = %v", err)
+	}
+	expected := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	if info.BuiltAt == nil || !info.BuiltAt.Equal(expected) {
+		t.Errorf("unexpected build date %v", info.BuiltAt)
+	}
+	if info.Labels["paulenv"] != "true" {
+		t.Errorf("unexpected labels %v", info.Labels)
+	}
+
+	// Unknown images are reported as never built
+	info, err = c.GetImageInfo(context.Background(), "unknown")
+	if err != nil {
+		t.Fatalf("GetImageInfo() error = %v", err)
+	}
+	if info.BuiltAt != nil {
+		t.Errorf("expected no build date, got %v", info.BuiltAt)
+	}
+}
+
+func TestDockerAPI_ErrorMessage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+dockerAPIVersion+"/volumes", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message": "something broke"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c := &DockerAPIEngine{client: &dockerAPIClient{httpClient: srv.Client(), baseURL: srv.URL}}
+
+	_, err := c.ListVolumes(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := err.Error(); got != "failed to list volumes: docker API error (500): something broke" {
+		t.Errorf("unexpected error message: %s", got)
+	}
+}
+
+func TestDockerAPIClient_Hosts(t *testing.T) {
+	tests := []struct {
+		host    string
+		baseURL string
+		ok      bool
+	}{
+		{"", "http://docker", true},
+		{"unix:///run/user/1000/docker.sock", "http://docker", true},
+		{"tcp://127.0.0.1:2375", "http://127.0.0.1:2375", true},
+		{"ssh://user@host", "", false},
+		{"invalid", "", false},
+	}
+	for _, tt := range tests {
+		client, err := newDockerAPIClient(tt.host)
+		if tt.ok && err != nil {
+			t.Errorf("expected ok for %q, got %v", tt.host, err)
+		} else if !tt.ok && err == nil {
+			t.Errorf("expected error for %q", tt.host)
+		} else if tt.ok && client.baseURL != tt.baseURL {
+			t.Errorf("expected base URL %q for %q, got %q", tt.baseURL, tt.host, client.baseURL)
+		}
+	}
+}
+
+func TestDockerAPIClient_UnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not tested on windows")
+	}
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Version": "27.1.0"}`))
+	}))
+	srv.Listener = listener
+	srv.Start()
+	defer srv.Close()
+
+	client, err := newDockerAPIClient("unix://" + socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &DockerAPIEngine{client: client}
+	info, err := c.Info(context.Background())
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if info.Name != "docker" || info.Version != "27.1.0" {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
@@ -9,6 +9,10 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/peaberberian/paul-envs/internal/files"
@@ -23,39 +27,94 @@ type ContainerEngine interface {
 	// `relDotfilesDir` in the container's $HOME. `relDotfilesDir` must be a relative path
 	// from paul-envs' Dockerfile and reachable from its context.
 	BuildImage(ctx context.Context, project files.ProjectEntry, relDotfilesDir string) error
-	// Run the container whose image has previously been built with `BuildImage`.
+	// Start in the background the container whose image has previously been
+	// built with `BuildImage`. It is then kept alive until stopped, e.g.
+	// through `DownContainer`.
+	UpContainer(ctx context.Context, project files.ProjectEntry) error
+	// Stop and remove the container started by `UpContainer` for the given
+	// project, as well as its network.
+	DownContainer(ctx context.Context, project files.ProjectEntry) error
+	// Attach a new session to the given running container.
 	//
 	// If `args` is empty, will start an interactive tty session with the project's shell of
 	// choice.
 	//
-	// If `args` is not empty, the container will just execute the given commands and then
+	// If `args` is not empty, the session will just execute the given commands and then
 	// exit.
-	RunContainer(ctx context.Context, project files.ProjectEntry, args []string) error
 	JoinContainer(ctx context.Context, containerInfo ContainerInfo, args []string) error
-	// Create the persistent volume whose name is given as argument.
-	CreateVolume(ctx context.Context, name string) error
+	// Execute a single command in the given running container, as described
+	// by `opts`, forwarding the standard input and outputs.
+	//
+	// If that command exits with a non-zero exit code, an `*ExitCodeError`
+	// is returned.
+	ExecContainer(ctx context.Context, containerInfo ContainerInfo, opts ExecOptions) error
+	// Gracefully stop the given running container, killing it if it is still
+	// running after `timeout`.
+	StopContainer(ctx context.Context, container ContainerInfo, timeout time.Duration) error
+	// Immediately kill the given running container.
+	KillContainer(ctx context.Context, container ContainerInfo) error
+	// List the interactive sessions (one per terminal) currently attached to
+	// the given running container.
+	ListSessions(ctx context.Context, container ContainerInfo) ([]SessionInfo, error)
+	// Obtain the disk space used by each volume, in bytes, keyed by volume
+	// name. Volumes whose size is unknown are not included.
+	GetVolumeSizes(ctx context.Context) (map[string]int64, error)
+	// Create the persistent volume whose name is given as argument, with the
+	// given labels.
+	CreateVolume(ctx context.Context, name string, labels map[string]string) error
 	// Check if the project in argument has been built succesfully before and return
 	// `true` if that's the case.
 	//
 	// Return an `error` if we could not do the check, in which case we don't know if the
 	// project has been built.
 	HasBeenBuilt(ctx context.Context, projectName string) (bool, error)
+	// Create the image of the project `targetProject` from the one already
+	// built for `sourceProject`, without rebuilding it.
+	//
+	// The new image is a distinct image, only differing by the labels linking
+	// it to its project, so that each one can be removed independently.
+	CopyImage(ctx context.Context, sourceProject string, targetProject string) error
+	// Copy all the data of the volume `sourceVolume` into the already-created
+	// volume `targetVolume`, through a short-lived container based on the image
+	// built for `imageProject`.
+	CopyVolume(ctx context.Context, sourceVolume string, targetVolume string, imageProject string) error
+	// Write the image built for the given project to `w`, as a tar archive
+	// which can be read back by `LoadImage`.
+	SaveImage(ctx context.Context, projectName string, w io.Writer) error
+	// Load an image written by `SaveImage`, with the name and labels it had.
+	LoadImage(ctx context.Context, r io.Reader) error
+	// Write the content of the given volume to `w` as a tar archive, through
+	// a short-lived container based on the image built for `imageProject`.
+	ExportVolume(ctx context.Context, volumeName string, imageProject string, w io.Writer) error
+	// Extract in the already-created given volume the tar archive read from
+	// `r`, through a short-lived container based on the image built for
+	// `imageProject`.
+	ImportVolume(ctx context.Context, volumeName string, imageProject string, r io.Reader) error
+	// Run `args` in a short-lived container based on the image built for
+	// `imageProject`, as root and bypassing its entrypoint, with the given
+	// volume mounted at `HelperVolumePath`. Its standard output is written to
+	// `w`.
+	RunInVolume(ctx context.Context, volumeName string, imageProject string, w io.Writer, args ...string) error
 	// Returns information on the given project from the point of view of the container
 	// engine.
 	GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error)
-	// List containers currently known by this container engine
+	// List containers created by paul-envs currently known by this container
+	// engine, as recognized through their labels
 	ListContainers(ctx context.Context) ([]ContainerInfo, error)
 	// Remove container listed from this container engine
 	RemoveContainer(ctx context.Context, container ContainerInfo) error
-	// List images currently known by this container engine
+	// List images built by paul-envs currently known by this container engine,
+	// as recognized through their labels
 	ListImages(ctx context.Context) ([]ImageInfo, error)
 	// Remove image listed from this container engine
 	RemoveImage(ctx context.Context, image ImageInfo) error
-	// List volumes currently known by this container engine
+	// List volumes created by paul-envs currently known by this container
+	// engine, as recognized through their labels
 	ListVolumes(ctx context.Context) ([]VolumeInfo, error)
 	// Remove volume listed from this container engine
 	RemoveVolume(ctx context.Context, volume VolumeInfo) error
-	// List networks currently known by this container engine
+	// List networks created by paul-envs currently known by this container
+	// engine, as recognized through their labels
 	ListNetworks(ctx context.Context) ([]NetworkInfo, error)
 	// Remove network listed from this container engine
 	RemoveNetwork(ctx context.Context, network NetworkInfo) error
@@ -64,6 +123,10 @@ type ContainerEngine interface {
 	PruneBuildCache(ctx context.Context) error
 }
 
+// Path at which a volume is mounted in the containers run by
+// `ContainerEngine.RunInVolume`.
+const HelperVolumePath = "/volume"
+
 // Returns information on a specific "engine" able to create images and run containers
 type EngineInfo struct {
 	// The name to which it is refered to, e.g. "docker"
@@ -82,6 +145,8 @@ type ImageInfo struct {
 	// The timestamp at which it has last been built.
 	// `nil` if it never has been built.
 	BuiltAt *time.Time
+	// Labels set on that image, `nil` if unknown
+	Labels map[string]string
 }
 
 // Information on a particular container as stored by the container engine
@@ -94,6 +159,55 @@ type ContainerInfo struct {
 	ImageName *string
 	// Its Id with which it can be refered to
 	ContainerId string
+	// Its state as given by the container engine (e.g. "running", "exited").
+	// Empty if unknown.
+	State string
+	// The timestamp at which it has been created, `nil` if unknown
+	CreatedAt *time.Time
+	// The timestamp at which it has last been started, `nil` if unknown or
+	// if it never has been started
+	StartedAt *time.Time
+	// Labels set on that container, `nil` if unknown
+	Labels map[string]string
+}
+
+// Information on an interactive session attached to a running container,
+// e.g. the initial `run` or a session which joined it.
+type SessionInfo struct {
+	// The terminal allocated to that session, e.g. "pts/1"
+	TTY string
+	// Command line of the first process of that session
+	Command string
+	// Time elapsed since that session started, as reported by the container
+	// engine (e.g. "01:02:03"). Empty if unknown.
+	Elapsed string
+}
+
+// Options given to `ExecContainer`.
+type ExecOptions struct {
+	// The command to execute and its arguments
+	Args []string
+	// If `true`, a pseudo-TTY is allocated for that command
+	Tty bool
+	// If not empty, the working directory of that command in the container
+	Workdir string
+	// Supplementary environment variables, in the "KEY=VALUE" format
+	Env []string
+	// If not empty, the user running that command (e.g. "root"). The
+	// container's entry point is then bypassed. If empty, the command is run
+	// by the project's user through the entry point.
+	User string
+}
+
+// Error returned when a command executed in a container exited with a
+// non-zero exit code.
+type ExitCodeError struct {
+	// Exit code of that command
+	ExitCode int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.ExitCode)
 }
 
 // Information on a particular container Network interface
@@ -104,6 +218,8 @@ type NetworkInfo struct {
 	ProjectName *string
 	// The name it is actually refered to by the container engine.
 	NetworkName string
+	// Labels set on that network, `nil` if unknown
+	Labels map[string]string
 }
 
 // Information on a particular container Network interface
@@ -112,14 +228,171 @@ type VolumeInfo struct {
 	VolumeId string
 	// The name it is actually refered to by the container engine.
 	VolumeName string
+	// The name of the corresponding paulenv project, `nil` for volumes which
+	// are not linked to a single project, such as package cache volumes
+	ProjectName *string
+	// The timestamp at which it has been created, `nil` if unknown
+	CreatedAt *time.Time
+	// Labels set on that volume, `nil` if unknown
+	Labels map[string]string
+}
+
+// Name to use to explicitly ask for automatic detection of the container engine.
+const AutoEngineName = "auto"
+
+// Environment variable which can be set to choose the container engine.
+const EngineEnvVar = "PAUL_ENVS_ENGINE"
+
+// Name of all container engines currently supported.
+var SupportedEngines = []string{"docker", "docker-api", "podman"}
+
+// Indicates where the choice of the container engine to use comes from.
+type SelectionSource int
+
+const (
+	// No preference: the first available engine is used
+	SelectionAuto SelectionSource = iota
+	// The default stored in paul-envs' global configuration
+	SelectionConfig
+	// The `PAUL_ENVS_ENGINE` environment variable
+	SelectionEnv
+	// The `--engine` flag
+	SelectionFlag
+)
+
+func (s SelectionSource) String() string {
+	switch s {
+	case SelectionAuto:
+		return "auto-detected"
+	case SelectionConfig:
+		return "default set in the paul-envs configuration"
+	case SelectionEnv:
+		return EngineEnvVar + " environment variable"
+	case SelectionFlag:
+		return "--engine flag"
+	default:
+		return "unknown source"
+	}
+}
+
+// The container engine wanted by the user and the reason why it was wanted.
+type Selection struct {
+	// Name of the wanted engine (e.g. "docker"). Empty if it should be
+	// auto-detected.
+	Name string
+	// Where that choice comes from
+	Source SelectionSource
+}
+
+// Resolve which container engine should be used, by order of precedence:
+//  1. The `--engine` flag, whose value is given as `flagValue`
+//  2. The `PAUL_ENVS_ENGINE` environment variable
+//  3. The default persisted in paul-envs' configuration, given as `configValue`
+//  4. Automatic detection, docker being preferred over podman
+//
+// Empty values are ignored. An "auto" value stops the resolution and asks
+// for automatic detection.
+func Select(flagValue string, configValue string) Selection {
+	candidates := []Selection{
+		{Name: flagValue, Source: SelectionFlag},
+		{Name: os.Getenv(EngineEnvVar), Source: SelectionEnv},
+		{Name: configValue, Source: SelectionConfig},
+	}
+	for _, candidate := range candidates {
+		if candidate.Name == AutoEngineName {
+			return Selection{Source: SelectionAuto}
+		}
+		if candidate.Name != "" {
+			return candidate
+		}
+	}
+	return Selection{Source: SelectionAuto}
 }
 
-// Create a new `ContainerEngine`, based on what's available right now.
-func New(ctx context.Context) (ContainerEngine, error) {
-	// For now only docker is handled
-	// TODO: other engines
-	if docker, err := newDocker(ctx); err == nil {
+// Returns an error if the given name is neither a supported container engine
+// nor "auto".
+func ValidateEngineName(name string) error {
+	if name == AutoEngineName || slices.Contains(SupportedEngines, name) {
+		return nil
+	}
+	return fmt.Errorf("unknown container engine '%s'. Must be one of: %s, %s",
+		name, strings.Join(SupportedEngines, ", "), AutoEngineName)
+}
+
+// Create a new `ContainerEngine` according to the given `Selection`.
+//
+// When no engine is explicitly wanted, it is based on what's available right
+// now, docker being preferred when both docker and podman are available.
+func New(ctx context.Context, selection Selection) (ContainerEngine, error) {
+	switch selection.Name {
+	case "":
+		if docker, err := newDocker(ctx); err == nil {
+			return docker, nil
+		}
+		if podman, err := newPodman(ctx); err == nil {
+			return podman, nil
+		}
+		return nil, fmt.Errorf("no supported container engine found, please install docker or podman first")
+	case "docker":
+		docker, err := newDocker(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("docker was wanted (%s) but is not usable: %w", selection.Source, err)
+		}
 		return docker, nil
+	case "docker-api":
+		docker, err := newDockerAPI(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("docker-api was wanted (%s) but is not usable: %w", selection.Source, err)
+		}
+		return docker, nil
+	case "podman":
+		podman, err := newPodman(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("podman was wanted (%s) but is not usable: %w", selection.Source, err)
+		}
+		return podman, nil
+	default:
+		if err := ValidateEngineName(selection.Name); err != nil {
+			return nil, fmt.Errorf("invalid engine from %s: %w", selection.Source, err)
+		}
+		return nil, fmt.Errorf("container engine '%s' is not handled", selection.Name)
+	}
+}
+
+// Lazily creates the `ContainerEngine` wanted by the user, only once it is
+// actually needed, as commands such as `create` never need one.
+type Loader struct {
+	selection Selection
+	engine    ContainerEngine
+}
+
+// Create a new `Loader` which will instantiate the container engine described
+// by `selection` on the first `Get` call.
+func NewLoader(selection Selection) *Loader {
+	return &Loader{selection: selection}
+}
+
+// Create a new `Loader` always returning the given, already-created,
+// `ContainerEngine`, e.g. to inject a `FakeEngine` in commands.
+func NewLoaderWith(containerEngine ContainerEngine) *Loader {
+	return &Loader{engine: containerEngine}
+}
+
+// Returns the `ContainerEngine` wanted by the user, creating it on the first
+// call.
+func (l *Loader) Get(ctx context.Context) (ContainerEngine, error) {
+	if l.engine != nil {
+		return l.engine, nil
+	}
+	containerEngine, err := New(ctx, l.selection)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("no supported container engine found, please install docker first")
+	l.engine = containerEngine
+	return containerEngine, nil
+}
+
+// Returns the `Selection` on which this `Loader` relies.
+func (l *Loader) Selection() Selection {
+	return l.selection
 }
@@ -0,0 +1,612 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// In-memory implementation of `ContainerEngine`, which simulates images,
+// containers, volumes and networks without relying on any real container
+// engine.
+//
+// Like real engines, its `List*` methods only return resources carrying the
+// paul-envs owner label.
+//
+// It records every call made to it and allows to inject failures, which makes
+// it mostly useful for tests.
+type FakeEngine struct {
+	mu sync.Mutex
+	// Name returned by `Info`
+	Name string
+	// Version returned by `Info`
+	Version string
+	// Images currently "built"
+	Images []ImageInfo
+	// Containers currently "created"
+	Containers []ContainerInfo
+	// Volumes currently "created"
+	Volumes []VolumeInfo
+	// Networks currently "created"
+	Networks []NetworkInfo
+	// Sessions attached to containers, keyed by container id
+	Sessions map[string][]SessionInfo
+	// Sizes returned by `GetVolumeSizes`, keyed by volume name
+	VolumeSizes map[string]int64
+	// Content of volumes, as written by `ExportVolume`, keyed by volume name
+	VolumeData map[string][]byte
+	// Standard output of the commands run through `RunInVolume`
+	RunInVolumeOutput string
+	// Exit code of the commands executed through `ExecContainer`
+	ExecExitCode int
+	// Options given to each `ExecContainer` call, in order
+	Execs []ExecOptions
+	// All calls performed on this `FakeEngine`, in order
+	Calls []FakeCall
+	// Errors to return, keyed by the name of the `ContainerEngine` method
+	// (e.g. "BuildImage") which should return them.
+	errors map[string]error
+	// Incremented to generate unique ids
+	lastId int
+}
+
+// A call performed on a `FakeEngine`
+type FakeCall struct {
+	// Name of the `ContainerEngine` method called, e.g. "BuildImage"
+	Method string
+	// Main argument it has been called with (e.g. a project, image or volume
+	// name), empty if none.
+	Target string
+	// Arguments given to run commands, if any
+	Args []string
+}
+
+// Create a new `FakeEngine` with nothing built nor running
+func NewFakeEngine() *FakeEngine {
+	return &FakeEngine{
+		Name:        "fake",
+		Version:     "1.0.0",
+		Sessions:    make(map[string][]SessionInfo),
+		VolumeSizes: make(map[string]int64),
+		VolumeData:  make(map[string][]byte),
+		errors:      make(map[string]error),
+	}
+}
+
+// Make all future calls to the `ContainerEngine` method named `method`
+// (e.g. "BuildImage") fail with `err`.
+//
+// Giving a `nil` `err` removes that failure.
+func (f *FakeEngine) FailOn(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errors, method)
+	} else {
+		f.errors[method] = err
+	}
+}
+
+// Returns all calls performed to the `ContainerEngine` method named `method`.
+func (f *FakeEngine) CallsTo(method string) []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []FakeCall
+	for _, call := range f.Calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Record the call and returns the error that should be returned for it, if
+// one. Must be called with `f.mu` locked.
+func (f *FakeEngine) record(method string, target string, args []string) error {
+	f.Calls = append(f.Calls, FakeCall{Method: method, Target: target, Args: slices.Clone(args)})
+	return f.errors[method]
+}
+
+func (f *FakeEngine) nextId(prefix string) string {
+	f.lastId++
+	return fmt.Sprintf("%s%d", prefix, f.lastId)
+}
+
+func (f *FakeEngine) Info(ctx context.Context) (EngineInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Info", "", nil); err != nil {
+		return EngineInfo{}, err
+	}
+	return EngineInfo{Name: f.Name, Version: f.Version}, nil
+}
+
+func (f *FakeEngine) BuildImage(ctx context.Context, project files.ProjectEntry, relDotfilesDir string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("BuildImage", project.ProjectName, nil); err != nil {
+		return err
+	}
+	projectName := project.ProjectName
+	builtAt := time.Now()
+	imageName := "paulenv:" + projectName
+	f.Images = slices.DeleteFunc(f.Images, func(image ImageInfo) bool {
+		return image.ImageName == imageName
+	})
+	f.Images = append(f.Images, ImageInfo{
+		ProjectName: &projectName,
+		ImageName:   imageName,
+		BuiltAt:     &builtAt,
+		Labels:      fakeProjectLabels(projectName),
+	})
+	return nil
+}
+
+// Simulates a `compose up -d`: the project's network and local volume are
+// created if needed, then a container is started and kept running.
+func (f *FakeEngine) UpContainer(ctx context.Context, project files.ProjectEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("UpContainer", project.ProjectName, nil); err != nil {
+		return err
+	}
+	if f.findImage(project.ProjectName) < 0 {
+		return fmt.Errorf("Up failed: no image found for project '%s'", project.ProjectName)
+	}
+	projectName := project.ProjectName
+	networkName := "paulenv-" + projectName + "_default"
+	if !slices.ContainsFunc(f.Networks, func(n NetworkInfo) bool { return n.NetworkName == networkName }) {
+		f.Networks = append(f.Networks, NetworkInfo{
+			NetworkId:   f.nextId("network"),
+			ProjectName: &projectName,
+			NetworkName: networkName,
+			Labels:      fakeProjectLabels(projectName),
+		})
+	}
+	f.createVolume("paulenv-"+projectName+"-local", fakeProjectLabels(projectName))
+	f.startContainer(projectName, "paulenv-"+projectName+"-paulenv-1")
+	return nil
+}
+
+// Simulates a `compose down`: the project's containers and network are
+// removed.
+func (f *FakeEngine) DownContainer(ctx context.Context, project files.ProjectEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("DownContainer", project.ProjectName, nil); err != nil {
+		return err
+	}
+	projectName := project.ProjectName
+	f.Containers = slices.DeleteFunc(f.Containers, func(c ContainerInfo) bool {
+		if c.ProjectName != nil && *c.ProjectName == projectName {
+			delete(f.Sessions, c.ContainerId)
+			return true
+		}
+		return false
+	})
+	f.Networks = slices.DeleteFunc(f.Networks, func(n NetworkInfo) bool {
+		return n.ProjectName != nil && *n.ProjectName == projectName
+	})
+	return nil
+}
+
+func (f *FakeEngine) JoinContainer(ctx context.Context, containerInfo ContainerInfo, args []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("JoinContainer", containerInfo.ContainerId, args); err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(f.Containers, func(c ContainerInfo) bool { return c.ContainerId == containerInfo.ContainerId }) {
+		return fmt.Errorf("no container with id '%s'", containerInfo.ContainerId)
+	}
+	return nil
+}
+
+func (f *FakeEngine) ExecContainer(ctx context.Context, containerInfo ContainerInfo, opts ExecOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ExecContainer", containerInfo.ContainerId, opts.Args); err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(f.Containers, func(c ContainerInfo) bool { return c.ContainerId == containerInfo.ContainerId }) {
+		return fmt.Errorf("no container with id '%s'", containerInfo.ContainerId)
+	}
+	f.Execs = append(f.Execs, opts)
+	if f.ExecExitCode != 0 {
+		return &ExitCodeError{ExitCode: f.ExecExitCode}
+	}
+	return nil
+}
+
+func (f *FakeEngine) StopContainer(ctx context.Context, container ContainerInfo, timeout time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("StopContainer", container.ContainerId, nil); err != nil {
+		return err
+	}
+	return f.exitContainer(container.ContainerId)
+}
+
+func (f *FakeEngine) KillContainer(ctx context.Context, container ContainerInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("KillContainer", container.ContainerId, nil); err != nil {
+		return err
+	}
+	return f.exitContainer(container.ContainerId)
+}
+
+// Must be called with `f.mu` locked.
+func (f *FakeEngine) exitContainer(containerId string) error {
+	idx := slices.IndexFunc(f.Containers, func(c ContainerInfo) bool { return c.ContainerId == containerId })
+	if idx < 0 {
+		return fmt.Errorf("no container with id '%s'", containerId)
+	}
+	f.Containers[idx].State = "exited"
+	delete(f.Sessions, containerId)
+	return nil
+}
+
+func (f *FakeEngine) ListSessions(ctx context.Context, container ContainerInfo) ([]SessionInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListSessions", container.ContainerId, nil); err != nil {
+		return []SessionInfo{}, err
+	}
+	return slices.Clone(f.Sessions[container.ContainerId]), nil
+}
+
+func (f *FakeEngine) GetVolumeSizes(ctx context.Context) (map[string]int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetVolumeSizes", "", nil); err != nil {
+		return nil, err
+	}
+	return maps.Clone(f.VolumeSizes), nil
+}
+
+func (f *FakeEngine) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CreateVolume", name, nil); err != nil {
+		return err
+	}
+	f.createVolume(name, labels)
+	return nil
+}
+
+// Must be called with `f.mu` locked.
+func (f *FakeEngine) createVolume(name string, labels map[string]string) {
+	if slices.ContainsFunc(f.Volumes, func(v VolumeInfo) bool { return v.VolumeName == name }) {
+		return
+	}
+	createdAt := time.Now()
+	f.Volumes = append(f.Volumes, VolumeInfo{
+		VolumeId:    name,
+		VolumeName:  name,
+		ProjectName: projectNameFromLabels(labels),
+		CreatedAt:   &createdAt,
+		Labels:      labels,
+	})
+}
+
+func (f *FakeEngine) HasBeenBuilt(ctx context.Context, projectName string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("HasBeenBuilt", projectName, nil); err != nil {
+		return false, err
+	}
+	return f.findImage(projectName) >= 0, nil
+}
+
+func (f *FakeEngine) CopyImage(ctx context.Context, sourceProject string, targetProject string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CopyImage", targetProject, []string{sourceProject}); err != nil {
+		return err
+	}
+	idx := f.findImage(sourceProject)
+	if idx < 0 {
+		return fmt.Errorf("no image found for project '%s'", sourceProject)
+	}
+	labels := maps.Clone(f.Images[idx].Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[LabelProject] = targetProject
+	labels[LabelProjectID] = targetProject
+	imageName := "paulenv:" + targetProject
+	builtAt := time.Now()
+	f.Images = slices.DeleteFunc(f.Images, func(image ImageInfo) bool {
+		return image.ImageName == imageName
+	})
+	f.Images = append(f.Images, ImageInfo{
+		ProjectName: &targetProject,
+		ImageName:   imageName,
+		BuiltAt:     &builtAt,
+		Labels:      labels,
+	})
+	return nil
+}
+
+func (f *FakeEngine) CopyVolume(ctx context.Context, sourceVolume string, targetVolume string, imageProject string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CopyVolume", targetVolume, []string{sourceVolume, imageProject}); err != nil {
+		return err
+	}
+	for _, name := range []string{sourceVolume, targetVolume} {
+		if !slices.ContainsFunc(f.Volumes, func(v VolumeInfo) bool { return v.VolumeName == name }) {
+			return fmt.Errorf("no volume named '%s'", name)
+		}
+	}
+	if f.findImage(imageProject) < 0 {
+		return fmt.Errorf("no image found for project '%s'", imageProject)
+	}
+	if data, ok := f.VolumeData[sourceVolume]; ok {
+		f.VolumeData[targetVolume] = slices.Clone(data)
+	}
+	return nil
+}
+
+// Prefix of the content written by `SaveImage`, followed by the project name.
+const fakeSavedImagePrefix = "fake-image:"
+
+func (f *FakeEngine) SaveImage(ctx context.Context, projectName string, w io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("SaveImage", projectName, nil); err != nil {
+		return err
+	}
+	if f.findImage(projectName) < 0 {
+		return fmt.Errorf("no image found for project '%s'", projectName)
+	}
+	_, err := io.WriteString(w, fakeSavedImagePrefix+projectName)
+	return err
+}
+
+func (f *FakeEngine) LoadImage(ctx context.Context, r io.Reader) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("LoadImage", "", nil); err != nil {
+		return err
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	projectName, ok := strings.CutPrefix(string(content), fakeSavedImagePrefix)
+	if !ok {
+		return errors.New("invalid image archive")
+	}
+	imageName := "paulenv:" + projectName
+	builtAt := time.Now()
+	f.Images = slices.DeleteFunc(f.Images, func(image ImageInfo) bool {
+		return image.ImageName == imageName
+	})
+	f.Images = append(f.Images, ImageInfo{
+		ProjectName: &projectName,
+		ImageName:   imageName,
+		BuiltAt:     &builtAt,
+		Labels:      fakeProjectLabels(projectName),
+	})
+	return nil
+}
+
+func (f *FakeEngine) ExportVolume(ctx context.Context, volumeName string, imageProject string, w io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ExportVolume", volumeName, []string{imageProject}); err != nil {
+		return err
+	}
+	if err := f.checkHelperContainer(volumeName, imageProject); err != nil {
+		return err
+	}
+	_, err := w.Write(f.VolumeData[volumeName])
+	return err
+}
+
+func (f *FakeEngine) ImportVolume(ctx context.Context, volumeName string, imageProject string, r io.Reader) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ImportVolume", volumeName, []string{imageProject}); err != nil {
+		return err
+	}
+	if err := f.checkHelperContainer(volumeName, imageProject); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.VolumeData[volumeName] = data
+	return nil
+}
+
+func (f *FakeEngine) RunInVolume(ctx context.Context, volumeName string, imageProject string, w io.Writer, args ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RunInVolume", volumeName, append([]string{imageProject}, args...)); err != nil {
+		return err
+	}
+	if err := f.checkHelperContainer(volumeName, imageProject); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, f.RunInVolumeOutput)
+	return err
+}
+
+// Check that a helper container based on the image of `imageProject` can be
+// run with `volumeName` mounted.
+//
+// Must be called with `f.mu` locked.
+func (f *FakeEngine) checkHelperContainer(volumeName string, imageProject string) error {
+	if !slices.ContainsFunc(f.Volumes, func(v VolumeInfo) bool { return v.VolumeName == volumeName }) {
+		return fmt.Errorf("no volume named '%s'", volumeName)
+	}
+	if f.findImage(imageProject) < 0 {
+		return fmt.Errorf("no image found for project '%s'", imageProject)
+	}
+	return nil
+}
+
+func (f *FakeEngine) GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetImageInfo", projectName, nil); err != nil {
+		return nil, err
+	}
+	if idx := f.findImage(projectName); idx >= 0 {
+		image := f.Images[idx]
+		return &image, nil
+	}
+	return &ImageInfo{ImageName: "paulenv:" + projectName, ProjectName: &projectName}, nil
+}
+
+// Must be called with `f.mu` locked.
+func (f *FakeEngine) findImage(projectName string) int {
+	return slices.IndexFunc(f.Images, func(image ImageInfo) bool {
+		return image.ImageName == "paulenv:"+projectName
+	})
+}
+
+// Simulates a container being currently started for the given project, as if
+// another process had started it, and returns it. A single session is
+// attached to it.
+func (f *FakeEngine) StartFakeContainer(projectName string) ContainerInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	container := f.startContainer(projectName, "paulenv-"+projectName+"-paulenv-run-"+fmt.Sprint(f.lastId+1))
+	f.Sessions[container.ContainerId] = []SessionInfo{{TTY: "pts/0", Command: "/bin/bash", Elapsed: "00:01"}}
+	return container
+}
+
+// Must be called with `f.mu` locked.
+func (f *FakeEngine) startContainer(projectName string, containerName string) ContainerInfo {
+	imageName := "paulenv:" + projectName
+	createdAt := time.Now()
+	container := ContainerInfo{
+		ProjectName:   &projectName,
+		ContainerName: &containerName,
+		ImageName:     &imageName,
+		ContainerId:   f.nextId("container"),
+		State:         "running",
+		CreatedAt:     &createdAt,
+		StartedAt:     &createdAt,
+		Labels:        fakeProjectLabels(projectName),
+	}
+	f.Containers = append(f.Containers, container)
+	return container
+}
+
+func (f *FakeEngine) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListContainers", "", nil); err != nil {
+		return []ContainerInfo{}, err
+	}
+	return ownedContainers(f.Containers), nil
+}
+
+func (f *FakeEngine) RemoveContainer(ctx context.Context, container ContainerInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RemoveContainer", container.ContainerId, nil); err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(f.Containers, func(c ContainerInfo) bool { return c.ContainerId == container.ContainerId })
+	if idx < 0 {
+		return fmt.Errorf("no container with id '%s'", container.ContainerId)
+	}
+	f.Containers = slices.Delete(f.Containers, idx, idx+1)
+	return nil
+}
+
+func (f *FakeEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListImages", "", nil); err != nil {
+		return []ImageInfo{}, err
+	}
+	return ownedImages(f.Images), nil
+}
+
+func (f *FakeEngine) RemoveImage(ctx context.Context, image ImageInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RemoveImage", image.ImageName, nil); err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(f.Images, func(i ImageInfo) bool { return i.ImageName == image.ImageName })
+	if idx < 0 {
+		return fmt.Errorf("no image named '%s'", image.ImageName)
+	}
+	f.Images = slices.Delete(f.Images, idx, idx+1)
+	return nil
+}
+
+func (f *FakeEngine) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListVolumes", "", nil); err != nil {
+		return []VolumeInfo{}, err
+	}
+	return ownedVolumes(f.Volumes), nil
+}
+
+func (f *FakeEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RemoveVolume", volume.VolumeName, nil); err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(f.Volumes, func(v VolumeInfo) bool { return v.VolumeId == volume.VolumeId })
+	if idx < 0 {
+		return fmt.Errorf("no volume with id '%s'", volume.VolumeId)
+	}
+	f.Volumes = slices.Delete(f.Volumes, idx, idx+1)
+	delete(f.VolumeData, volume.VolumeName)
+	return nil
+}
+
+func (f *FakeEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListNetworks", "", nil); err != nil {
+		return []NetworkInfo{}, err
+	}
+	return ownedNetworks(f.Networks), nil
+}
+
+func (f *FakeEngine) RemoveNetwork(ctx context.Context, network NetworkInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RemoveNetwork", network.NetworkName, nil); err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(f.Networks, func(n NetworkInfo) bool { return n.NetworkId == network.NetworkId })
+	if idx < 0 {
+		return fmt.Errorf("no network with id '%s'", network.NetworkId)
+	}
+	f.Networks = slices.Delete(f.Networks, idx, idx+1)
+	return nil
+}
+
+func (f *FakeEngine) PruneBuildCache(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.record("PruneBuildCache", "", nil)
+}
+
+// Labels set by paul-envs on the resources linked to the given project.
+func fakeProjectLabels(projectName string) map[string]string {
+	return map[string]string{LabelOwner: "true", LabelProject: projectName}
+}
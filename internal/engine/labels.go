@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Labels set on every container, image, volume and network created by
+// paul-envs, allowing to recognize them without relying on their name.
+const (
+	// Set to "true" on all resources owned by paul-envs
+	LabelOwner = "paulenv"
+	// Name of the paul-envs project a resource is linked to
+	LabelProject = "paulenv.project"
+	// `PROJECT_ID` of the paul-envs project a resource is linked to
+	LabelProjectID = "paulenv.project-id"
+	// Version of the base files (Dockerfile, compose.yaml...) the resource
+	// was created with
+	LabelVersion = "paulenv.version"
+	// Only set on package cache volumes, which are not linked to a project
+	// through `LabelProject`: "shared", "private" or "group"
+	LabelCacheKind = "paulenv.cache-kind"
+	// Project of a private cache volume, or cache group of a group one
+	LabelCacheOwner = "paulenv.cache-owner"
+)
+
+// Values of `LabelCacheKind`.
+const (
+	CacheKindShared  = "shared"
+	CacheKindPrivate = "private"
+	CacheKindGroup   = "group"
+)
+
+// Filter, in the format understood by both docker and podman, only matching
+// resources owned by paul-envs.
+const ownerLabelFilter = "label=" + LabelOwner + "=true"
+
+// Returns `true` if the given labels indicate a resource owned by paul-envs.
+func isOwnedByPaulEnvs(labels map[string]string) bool {
+	return labels[LabelOwner] == "true"
+}
+
+// Returns the name of the project a resource is linked to according to its
+// labels, `nil` if it isn't linked to any.
+func projectNameFromLabels(labels map[string]string) *string {
+	if !isOwnedByPaulEnvs(labels) {
+		return nil
+	}
+	if projectName, ok := labels[LabelProject]; ok && projectName != "" {
+		return &projectName
+	}
+	return nil
+}
+
+// CacheVolumeLabels returns the labels to set on the volume storing the package
+// caches of project `projectName`, according to its `config.Config.Cache`
+// value.
+func CacheVolumeLabels(projectName string, cache string) map[string]string {
+	labels := map[string]string{LabelOwner: "true"}
+	switch cache {
+	case "", config.CacheShared:
+		labels[LabelCacheKind] = CacheKindShared
+	case config.CachePrivate:
+		labels[LabelCacheKind] = CacheKindPrivate
+		labels[LabelCacheOwner] = projectName
+	default:
+		labels[LabelCacheKind] = CacheKindGroup
+		labels[LabelCacheOwner] = cache
+	}
+	return labels
+}
+
+// Parse labels as output by the docker or podman CLIs through a
+// `{{json .Labels}}` format.
+//
+// Depending on the command, they are either a JSON object or a JSON string
+// of comma-separated "key=value" pairs.
+func parseFormattedLabels(raw string) map[string]string {
+	raw = strings.TrimSpace(raw)
+	labels := make(map[string]string)
+	if raw == "" || raw == "null" {
+		return labels
+	}
+	if strings.HasPrefix(raw, "{") {
+		if err := json.Unmarshal([]byte(raw), &labels); err != nil {
+			return make(map[string]string)
+		}
+		return labels
+	}
+	var list string
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		list = raw
+	}
+	for _, pair := range strings.Split(list, ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// Resources created before paul-envs labeled them, by its version 1.0.0 base
+// files, can only be recognized through the names their compose file gave
+// them. Those names are only relied on for unlabeled resources.
+const (
+	// Name of the cache volume shared by all projects at that time
+	legacySharedCacheVolume = "paulenv-shared-cache"
+	// Prefix of the name of all volumes and networks of that time
+	legacyNamePrefix = "paulenv-"
+	// Repository of the images of that time, tagged by project name
+	legacyImageRepository = "paulenv:"
+)
+
+// Returns the project an unlabeled image (e.g. "paulenv:myapp" or, with
+// podman, "localhost/paulenv:myapp") was built for, `nil` if its name does not
+// follow that pattern.
+func legacyImageProjectName(imageName string) *string {
+	name := strings.TrimPrefix(imageName, "localhost/")
+	projectName, ok := strings.CutPrefix(name, legacyImageRepository)
+	return validLegacyProjectName(projectName, ok)
+}
+
+// Returns the project an unlabeled volume named "paulenv-<project>-local" is
+// linked to, `nil` if its name does not follow that pattern.
+func legacyVolumeProjectName(volumeName string) *string {
+	name, hasPrefix := strings.CutPrefix(volumeName, legacyNamePrefix)
+	projectName, hasSuffix := strings.CutSuffix(name, "-local")
+	return validLegacyProjectName(projectName, hasPrefix && hasSuffix)
+}
+
+// Returns the project an unlabeled network named "paulenv-<project>_default"
+// is linked to, `nil` if its name does not follow that pattern.
+func legacyNetworkProjectName(networkName string) *string {
+	name, hasPrefix := strings.CutPrefix(networkName, legacyNamePrefix)
+	projectName, hasSuffix := strings.CutSuffix(name, "_default")
+	return validLegacyProjectName(projectName, hasPrefix && hasSuffix)
+}
+
+func validLegacyProjectName(projectName string, matches bool) *string {
+	if !matches || utils.ValidateProjectName(projectName) != nil {
+		return nil
+	}
+	return &projectName
+}
+
+// Only keep the containers owned by paul-envs, including unlabeled ones
+// created from a legacy image, which are linked to its project.
+func ownedContainers(containers []ContainerInfo) []ContainerInfo {
+	result := make([]ContainerInfo, 0, len(containers))
+	for _, container := range containers {
+		if !isOwnedByPaulEnvs(container.Labels) {
+			if container.ImageName == nil {
+				continue
+			}
+			if container.ProjectName = legacyImageProjectName(*container.ImageName); container.ProjectName == nil {
+				continue
+			}
+		}
+		result = append(result, container)
+	}
+	return result
+}
+
+// Only keep the images owned by paul-envs, including unlabeled ones with a
+// legacy name, which are linked to the corresponding project.
+//
+// Images listed more than once are only kept once.
+func ownedImages(images []ImageInfo) []ImageInfo {
+	result := make([]ImageInfo, 0, len(images))
+	for _, image := range images {
+		if slices.ContainsFunc(result, func(i ImageInfo) bool { return i.ImageName == image.ImageName }) {
+			continue
+		}
+		if !isOwnedByPaulEnvs(image.Labels) {
+			if image.ProjectName = legacyImageProjectName(image.ImageName); image.ProjectName == nil {
+				continue
+			}
+		}
+		result = append(result, image)
+	}
+	return result
+}
+
+// Only keep the volumes owned by paul-envs, including unlabeled ones with a
+// legacy name, which are linked to the corresponding project.
+func ownedVolumes(volumes []VolumeInfo) []VolumeInfo {
+	result := make([]VolumeInfo, 0, len(volumes))
+	for _, volume := range volumes {
+		if _, isCache := volume.Labels[LabelCacheKind]; isCache {
+			// Never mistaken for the local volume of a project
+			volume.ProjectName = nil
+		}
+		if !isOwnedByPaulEnvs(volume.Labels) && volume.VolumeName != legacySharedCacheVolume {
+			if volume.ProjectName = legacyVolumeProjectName(volume.VolumeName); volume.ProjectName == nil {
+				continue
+			}
+		}
+		result = append(result, volume)
+	}
+	return result
+}
+
+// Only keep the networks owned by paul-envs, including unlabeled ones with a
+// legacy name, which are linked to the corresponding project.
+func ownedNetworks(networks []NetworkInfo) []NetworkInfo {
+	result := make([]NetworkInfo, 0, len(networks))
+	for _, network := range networks {
+		if !isOwnedByPaulEnvs(network.Labels) {
+			if network.ProjectName = legacyNetworkProjectName(network.NetworkName); network.ProjectName == nil {
+				continue
+			}
+		}
+		result = append(result, network)
+	}
+	return result
+}
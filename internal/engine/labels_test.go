@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFormattedLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected map[string]string
+	}{
+		{"empty", "", map[string]string{}},
+		{"null", "null", map[string]string{}},
+		{"json object", `{"paulenv":"true","paulenv.project":"myapp"}`,
+			map[string]string{"paulenv": "true", "paulenv.project": "myapp"}},
+		{"json string", `"paulenv=true,paulenv.project=myapp"`,
+			map[string]string{"paulenv": "true", "paulenv.project": "myapp"}},
+		{"raw string", "paulenv=true,paulenv.project-id=a=b",
+			map[string]string{"paulenv": "true", "paulenv.project-id": "a=b"}},
+		{"invalid object", `{"paulenv":`, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseFormattedLabels(tt.raw)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseFormattedLabels(%q) = %v, want %v", tt.raw, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProjectNameFromLabels(t *testing.T) {
+	if name := projectNameFromLabels(map[string]string{LabelOwner: "true", LabelProject: "myapp"}); name == nil || *name != "myapp" {
+		t.Errorf("expected project 'myapp', got %v", name)
+	}
+	if name := projectNameFromLabels(map[string]string{LabelOwner: "true"}); name != nil {
+		t.Errorf("expected no project for shared resources, got %v", *name)
+	}
+	if name := projectNameFromLabels(map[string]string{LabelProject: "myapp"}); name != nil {
+		t.Errorf("expected no project without the owner label, got %v", *name)
+	}
+	if name := projectNameFromLabels(nil); name != nil {
+		t.Errorf("expected no project without labels, got %v", *name)
+	}
+}
+
+func TestLegacyProjectNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    func(string) *string
+		resource string
+		expected string
+	}{
+		{"image", legacyImageProjectName, "paulenv:myapp", "myapp"},
+		{"podman image", legacyImageProjectName, "localhost/paulenv:myapp", "myapp"},
+		{"other image", legacyImageProjectName, "ubuntu:24.04", ""},
+		{"invalid image tag", legacyImageProjectName, "paulenv:Latest", ""},
+		{"volume", legacyVolumeProjectName, "paulenv-my-app-local", "my-app"},
+		{"volume named like a project", legacyVolumeProjectName, "paulenv-a-local-local", "a-local"},
+		{"other volume", legacyVolumeProjectName, "paulenv-myapp-data", ""},
+		{"empty volume project", legacyVolumeProjectName, "paulenv--local", ""},
+		{"network", legacyNetworkProjectName, "paulenv-myapp_default", "myapp"},
+		{"other network", legacyNetworkProjectName, "myapp_default", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.parse(tt.resource)
+			if tt.expected == "" && got != nil {
+				t.Errorf("expected no project for %q, got %q", tt.resource, *got)
+			} else if tt.expected != "" && (got == nil || *got != tt.expected) {
+				t.Errorf("expected project %q for %q, got %v", tt.expected, tt.resource, got)
+			}
+		})
+	}
+}
+
+func TestOwnedVolumes(t *testing.T) {
+	projectName := "myapp"
+	volumes := ownedVolumes([]VolumeInfo{
+		{VolumeName: "paulenv-myapp-local", Labels: map[string]string{LabelOwner: "true", LabelProject: "myapp"}},
+		{VolumeName: "paulenv-shared-cache", Labels: map[string]string{}},
+		{VolumeName: "paulenv-old-local", Labels: map[string]string{}},
+		{VolumeName: "unrelated", Labels: map[string]string{}},
+		{VolumeName: "paulenv-private-myapp-cache", ProjectName: &projectName, Labels: map[string]string{
+			LabelOwner: "true", LabelProject: "myapp", LabelCacheKind: CacheKindPrivate, LabelCacheOwner: "myapp"}},
+	})
+	if len(volumes) != 4 {
+		t.Fatalf("expected 4 volumes, got %+v", volumes)
+	}
+	if volumes[3].ProjectName != nil {
+		t.Errorf("a cache volume should never be linked to a project, got %+v", volumes[3])
+	}
+	if volumes[1].VolumeName != "paulenv-shared-cache" || volumes[1].ProjectName != nil {
+		t.Errorf("expected the legacy shared cache without project, got %+v", volumes[1])
+	}
+	if volumes[2].ProjectName == nil || *volumes[2].ProjectName != "old" {
+		t.Errorf("expected the legacy volume of project 'old', got %+v", volumes[2])
+	}
+}
+
+func TestCacheVolumeLabels(t *testing.T) {
+	tests := []struct {
+		cache     string
+		wantKind  string
+		wantOwner string
+	}{
+		{"", CacheKindShared, ""},
+		{"shared", CacheKindShared, ""},
+		{"private", CacheKindPrivate, "myapp"},
+		{"clients", CacheKindGroup, "clients"},
+	}
+	for _, tt := range tests {
+		labels := CacheVolumeLabels("myapp", tt.cache)
+		if labels[LabelOwner] != "true" || labels[LabelCacheKind] != tt.wantKind || labels[LabelCacheOwner] != tt.wantOwner {
+			t.Errorf("CacheVolumeLabels(%q) = %v", tt.cache, labels)
+		}
+		if _, ok := labels[LabelProject]; ok {
+			t.Errorf("CacheVolumeLabels(%q) should not link it to a project, got %v", tt.cache, labels)
+		}
+	}
+}
@@ -0,0 +1,545 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Implements `ContainerEngine` for podman, relying either on `podman compose`
+// or on the standalone `podman-compose` tool.
+type PodmanEngine struct {
+	// The command (and its first arguments) to call to perform compose
+	// operations, e.g. `["podman", "compose"]` or `["podman-compose"]`.
+	composeCmd []string
+}
+
+func newPodman(ctx context.Context) (*PodmanEngine, error) {
+	cmd := exec.CommandContext(ctx, "podman", "--version")
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("podman command not found: %w", err)
+	}
+
+	composeCmd, err := findPodmanCompose(func(cmdLine []string) bool {
+		return exec.CommandContext(ctx, cmdLine[0], cmdLine[1:]...).Run() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PodmanEngine{composeCmd: composeCmd}, nil
+}
+
+// Returns the command to call to perform compose operations: `podman compose`
+// if available, or else the standalone `podman-compose` tool.
+//
+// `isAvailable` runs the given command line, a "version" sub-command of a
+// candidate, and returns `true` if it succeeded.
+func findPodmanCompose(isAvailable func(cmdLine []string) bool) ([]string, error) {
+	candidates := [][]string{{"podman", "compose"}, {"podman-compose"}}
+	for _, candidate := range candidates {
+		if isAvailable(append(append([]string{}, candidate...), "version")) {
+			return candidate, nil
+		}
+	}
+	return nil, errors.New("neither 'podman compose' nor 'podman-compose' were found")
+}
+
+// Construct a compose command for the given project.
+func (c *PodmanEngine) composeCommand(ctx context.Context, project files.ProjectEntry, args ...string) *exec.Cmd {
+	cmdArgs := append([]string{}, c.composeCmd[1:]...)
+	cmdArgs = append(cmdArgs,
+		"-p", "paulenv-"+project.ProjectName,
+		"-f", project.ComposeFilePath,
+		"--env-file", project.EnvFilePath)
+	cmdArgs = append(cmdArgs, args...)
+	cmd := exec.CommandContext(ctx, c.composeCmd[0], cmdArgs...)
+	cmd.Env = append(os.Environ(),
+		"COMPOSE_PROJECT_NAME=paulenv-"+project.ProjectName,
+		// Rootless podman maps the host's user to root in the container by
+		// default, keep its id instead so mounted directories stay writable.
+		"PODMAN_USERNS=keep-id",
+	)
+	return cmd
+}
+
+func (c *PodmanEngine) BuildImage(ctx context.Context, project files.ProjectEntry, relativeDotfilesDir string) error {
+	cmd := c.composeCommand(ctx, project, "build")
+	cmd.Env = append(cmd.Env, "DOTFILES_DIR="+relativeDotfilesDir)
+	// Build progress is not the result of the `build` command: keep the
+	// standard output for it.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("Build failed: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) UpContainer(ctx context.Context, project files.ProjectEntry) error {
+	cmd := c.composeCommand(ctx, project, "up", "-d", "paulenv")
+	cmd.Env = append(cmd.Env, keepAliveEnv)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("Up failed: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) DownContainer(ctx context.Context, project files.ProjectEntry) error {
+	cmd := c.composeCommand(ctx, project, "down")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("Down failed: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) JoinContainer(ctx context.Context, containerInfo ContainerInfo, args []string) error {
+	cmdArgs := []string{"exec", "-it", containerInfo.ContainerId, "/usr/local/bin/entrypoint.sh"}
+	cmdArgs = append(cmdArgs, args...)
+	cmd := exec.CommandContext(ctx, "podman", cmdArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("join exited: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) ExecContainer(ctx context.Context, containerInfo ContainerInfo, opts ExecOptions) error {
+	cmd := exec.CommandContext(ctx, "podman", execArgs(containerInfo, opts)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return asExitCodeError(err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) StopContainer(ctx context.Context, container ContainerInfo, timeout time.Duration) error {
+	seconds := strconv.Itoa(int(timeout.Seconds()))
+	cmd := exec.CommandContext(ctx, "podman", "stop", "-t", seconds, container.ContainerId)
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to stop container %s: %w", container.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) KillContainer(ctx context.Context, container ContainerInfo) error {
+	cmd := exec.CommandContext(ctx, "podman", "kill", container.ContainerId)
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to kill container %s: %w", container.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) ListSessions(ctx context.Context, container ContainerInfo) ([]SessionInfo, error) {
+	cmd := exec.CommandContext(ctx, "podman", append([]string{"top", container.ContainerId}, topArgs...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return []SessionInfo{}, pErr
+		}
+		return []SessionInfo{}, fmt.Errorf("failed to list processes of container %s: %w", container.ContainerId, err)
+	}
+	return parseTopOutput(string(output)), nil
+}
+
+func (c *PodmanEngine) HasBeenBuilt(ctx context.Context, projectName string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "podman", "image", "exists", podmanImageName(projectName))
+	err := cmd.Run()
+
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return false, pErr
+		}
+		// `podman image exists` exits with 1 if the image is not found
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if exitErr.ExitCode() == 1 {
+				return false, nil
+			}
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *PodmanEngine) Info(ctx context.Context) (EngineInfo, error) {
+	cmd := exec.CommandContext(ctx, "podman", "--version")
+	output, err := cmd.Output()
+	if err != nil {
+		return EngineInfo{}, fmt.Errorf("failed to obtain podman version: %w", err)
+	}
+	parsed := strings.TrimSpace(string(output))
+	re := regexp.MustCompile(`podman version ([0-9]+\.[0-9]+\.[0-9]+)`)
+	matches := re.FindStringSubmatch(parsed)
+	if len(matches) > 1 {
+		return EngineInfo{Version: matches[1], Name: "podman"}, nil
+	}
+	return EngineInfo{}, fmt.Errorf("failed to obtain podman version, unknown version format: %s", parsed)
+}
+
+func (c *PodmanEngine) GetVolumeSizes(ctx context.Context) (map[string]int64, error) {
+	cmd := exec.CommandContext(ctx, "podman", "system", "df", "-v")
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to obtain volume sizes: %w", err)
+	}
+	return parseSystemDfVolumes(string(output)), nil
+}
+
+func (c *PodmanEngine) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	// `--ignore` makes the call idempotent, like `docker volume create` is
+	cmdArgs := append([]string{"volume", "create", "--ignore"}, labelArgs(labels)...)
+	cmd := exec.CommandContext(ctx, "podman", append(cmdArgs, name)...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("Failed to create shared volume: %w.", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) CopyImage(ctx context.Context, sourceProject string, targetProject string) error {
+	err := buildImageCopy(ctx, "podman", podmanImageName(sourceProject), podmanImageName(targetProject), targetProject)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("Failed to copy image: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) CopyVolume(ctx context.Context, sourceVolume string, targetVolume string, imageProject string) error {
+	if err := runVolumeCopy(ctx, "podman", sourceVolume, targetVolume, podmanImageName(imageProject)); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to copy volume %s to %s: %w", sourceVolume, targetVolume, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) SaveImage(ctx context.Context, projectName string, w io.Writer) error {
+	if err := runImageSave(ctx, "podman", podmanImageName(projectName), w); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) LoadImage(ctx context.Context, r io.Reader) error {
+	if err := runImageLoad(ctx, "podman", r); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) ExportVolume(ctx context.Context, volumeName string, imageProject string, w io.Writer) error {
+	if err := runVolumeExport(ctx, "podman", volumeName, podmanImageName(imageProject), w); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to export volume %s: %w", volumeName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) ImportVolume(ctx context.Context, volumeName string, imageProject string, r io.Reader) error {
+	if err := runVolumeImport(ctx, "podman", volumeName, podmanImageName(imageProject), r); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to import volume %s: %w", volumeName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) RunInVolume(ctx context.Context, volumeName string, imageProject string, w io.Writer, args ...string) error {
+	if err := runInVolume(ctx, "podman", volumeName, podmanImageName(imageProject), w, args...); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to run command in volume %s: %w", volumeName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error) {
+	imageName := podmanImageName(projectName)
+	info := &ImageInfo{ImageName: imageName, ProjectName: &projectName}
+
+	cmd := exec.CommandContext(ctx, "podman", "image", "inspect", imageName, "--format", "{{.Created}}")
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		} else if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 125 {
+			// podman exits with 125 when the image is unknown
+			return info, nil
+		}
+		return nil, err
+	}
+	info.BuiltAt = parseCLITime(strings.TrimSpace(string(output)))
+	return info, nil
+}
+
+func (c *PodmanEngine) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
+	cmd := exec.CommandContext(ctx, "podman", "ps", "-a", "--no-trunc", "--format", "{{.ID}}\t{{.Image}}\t{{.Names}}\t{{.State}}\t{{json .Labels}}")
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return []ContainerInfo{}, pErr
+		}
+		return []ContainerInfo{}, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	result := make([]ContainerInfo, 0, len(lines))
+	for _, s := range lines {
+		parts := strings.SplitN(s, "\t", 5)
+		if len(parts) < 5 {
+			continue
+		}
+		labels := parseFormattedLabels(parts[4])
+		result = append(result, ContainerInfo{
+			ProjectName:   projectNameFromLabels(labels),
+			ContainerName: &parts[2],
+			ContainerId:   parts[0],
+			ImageName:     &parts[1],
+			State:         parts[3],
+			Labels:        labels,
+		})
+	}
+	result = ownedContainers(result)
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	// `ps` cannot output precise creation and start dates, inspect them
+	cmdArgs := []string{"container", "inspect", "--format", containerInspectFormat}
+	for _, container := range result {
+		cmdArgs = append(cmdArgs, container.ContainerId)
+	}
+	// Those dates are only informative: they are just left unknown if a
+	// container disappeared in-between
+	output, _ = exec.CommandContext(ctx, "podman", cmdArgs...).Output()
+	applyContainerInspectOutput(string(output), result)
+	return result, nil
+}
+
+func (c *PodmanEngine) RemoveContainer(ctx context.Context, container ContainerInfo) error {
+	cmd := exec.CommandContext(ctx, "podman", "rm", "-f", container.ContainerId)
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *PodmanEngine) checkPermissions(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "podman", "ps")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		stderrStr := stderr.String()
+		if strings.Contains(stderrStr, "permission denied") ||
+			strings.Contains(stderrStr, "access denied") {
+			return errors.New("permission denied. Please check your podman installation (e.g. your subuid/subgid configuration)")
+		}
+		return fmt.Errorf("failed to connect to Podman: %w\n%s", err, stderrStr)
+	}
+	return nil
+}
+
+// List volumes currently known by this container engine
+func (c *PodmanEngine) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
+	cmd := exec.CommandContext(ctx, "podman", "volume", "ls", "--format", "{{.Name}}\t{{json .Labels}}")
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return []VolumeInfo{}, pErr
+		}
+		return []VolumeInfo{}, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	result := make([]VolumeInfo, 0, len(lines))
+	for _, line := range lines {
+		volumeName, rawLabels, _ := strings.Cut(line, "\t")
+		if volumeName == "" {
+			continue
+		}
+		labels := parseFormattedLabels(rawLabels)
+		result = append(result, VolumeInfo{
+			VolumeId:    volumeName,
+			VolumeName:  volumeName,
+			ProjectName: projectNameFromLabels(labels),
+			Labels:      labels,
+		})
+	}
+	return ownedVolumes(result), nil
+}
+
+func (c *PodmanEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) error {
+	cmd := exec.CommandContext(ctx, "podman", "volume", "rm", volume.VolumeName)
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove volume %s: %w", volume.VolumeName, err)
+	}
+	return nil
+}
+
+// List networks currently known by this container engine
+func (c *PodmanEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error) {
+	cmd := exec.CommandContext(ctx, "podman", "network", "ls", "--format", "{{.ID}}\t{{.Name}}\t{{json .Labels}}")
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return []NetworkInfo{}, pErr
+		}
+		return []NetworkInfo{}, fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	result := make([]NetworkInfo, 0, len(lines))
+	for _, line := range lines {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		labels := parseFormattedLabels(parts[2])
+		result = append(result, NetworkInfo{
+			NetworkId:   parts[0],
+			NetworkName: parts[1],
+			ProjectName: projectNameFromLabels(labels),
+			Labels:      labels,
+		})
+	}
+	return ownedNetworks(result), nil
+}
+
+// Remove network listed from this container engine
+func (c *PodmanEngine) RemoveNetwork(ctx context.Context, network NetworkInfo) error {
+	cmd := exec.CommandContext(ctx, "podman", "network", "rm", network.NetworkId)
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove network %s: %w", network.NetworkName, err)
+	}
+	return nil
+}
+
+// Remove the `ContainerEngine`'s build cache from metadata linked to this
+// executable
+func (c *PodmanEngine) PruneBuildCache(ctx context.Context) error {
+	// podman has no separate build cache: intermediate images are the cache
+	cmd := exec.CommandContext(ctx, "podman", "image", "prune", "-f", "--build-cache", "--filter", "label=paulenv=true")
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to prune build cache: %w", err)
+	}
+	return nil
+}
+
+// List images currently known by this container engine
+func (c *PodmanEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
+	cmd := exec.CommandContext(ctx, "podman", "images", "-q", "--no-trunc", "--filter", ownerLabelFilter)
+	output, err := cmd.Output()
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return []ImageInfo{}, pErr
+		}
+		return []ImageInfo{}, fmt.Errorf("failed to list images: %w", err)
+	}
+	ids := strings.Fields(string(output))
+
+	// Images built before they were labeled are recognized through their name
+	cmd = exec.CommandContext(ctx, "podman", "images", "--no-trunc", "--format", imageListFormat)
+	if output, err = cmd.Output(); err != nil {
+		return []ImageInfo{}, fmt.Errorf("failed to list images: %w", err)
+	}
+	ids = append(ids, parseLegacyImageIds(string(output))...)
+	if len(ids) == 0 {
+		return []ImageInfo{}, nil
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	cmdArgs := append([]string{"image", "inspect", "--format", imageInspectFormat}, ids...)
+	output, err = exec.CommandContext(ctx, "podman", cmdArgs...).Output()
+	if err != nil {
+		return []ImageInfo{}, fmt.Errorf("failed to inspect images: %w", err)
+	}
+	return ownedImages(parseImageInspectOutput(string(output))), nil
+}
+
+// Remove image from this container engine
+func (c *PodmanEngine) RemoveImage(ctx context.Context, image ImageInfo) error {
+	cmd := exec.CommandContext(ctx, "podman", "rmi", "-f", image.ImageName)
+	if err := cmd.Run(); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove image %s: %w", image.ImageName, err)
+	}
+	return nil
+}
+
+// Images built by podman without a registry are stored under "localhost/".
+func podmanImageName(projectName string) string {
+	return "localhost/paulenv:" + projectName
+}
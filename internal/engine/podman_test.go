@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestFindPodmanCompose(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []string
+		expected  []string
+	}{
+		{"podman compose first", []string{"podman compose version", "podman-compose version"}, []string{"podman", "compose"}},
+		{"podman-compose fallback", []string{"podman-compose version"}, []string{"podman-compose"}},
+		{"none available", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findPodmanCompose(func(cmdLine []string) bool {
+				return slices.Contains(tt.available, strings.Join(cmdLine, " "))
+			})
+			if tt.expected == nil {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("findPodmanCompose() = %v, %v, want %v", got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPodmanComposeCommand(t *testing.T) {
+	project := files.ProjectEntry{
+		ProjectName:     "myapp",
+		ComposeFilePath: "/data/projects/myapp/compose.yaml",
+		EnvFilePath:     "/data/projects/myapp/.env",
+	}
+	tests := []struct {
+		composeCmd []string
+		expected   string
+	}{
+		{[]string{"podman", "compose"},
+			"podman compose -p paulenv-myapp -f /data/projects/myapp/compose.yaml --env-file /data/projects/myapp/.env up -d paulenv"},
+		{[]string{"podman-compose"},
+			"podman-compose -p paulenv-myapp -f /data/projects/myapp/compose.yaml --env-file /data/projects/myapp/.env up -d paulenv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.composeCmd[0], func(t *testing.T) {
+			engine := &PodmanEngine{composeCmd: tt.composeCmd}
+			cmd := engine.composeCommand(context.Background(), project, "up", "-d", "paulenv")
+			if got := strings.Join(cmd.Args, " "); got != tt.expected {
+				t.Errorf("composeCommand() args = %q, want %q", got, tt.expected)
+			}
+			for _, env := range []string{"COMPOSE_PROJECT_NAME=paulenv-myapp", "PODMAN_USERNS=keep-id"} {
+				if !slices.Contains(cmd.Env, env) {
+					t.Errorf("expected %q in the command's environment", env)
+				}
+			}
+		})
+	}
+}
+
+func TestPodmanImageName(t *testing.T) {
+	if got := podmanImageName("myapp"); got != "localhost/paulenv:myapp" {
+		t.Errorf("podmanImageName() = %q", got)
+	}
+	// Images listed by podman are recognized through their labels, whatever
+	// their registry prefix
+	images := parseImageInspectOutput(`["localhost/paulenv:myapp"]	2024-01-02T03:04:05Z	{"paulenv":"true","paulenv.project":"myapp"}` + "\n")
+	if len(images) != 1 || images[0].ImageName != podmanImageName("myapp") ||
+		images[0].ProjectName == nil || *images[0].ProjectName != "myapp" {
+		t.Errorf("unexpected images: %+v", images)
+	}
+}
@@ -0,0 +1,310 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// The base files are the files shared by the builds of all projects, written
+// in paul-envs' data directory from the ones embedded in the binary.
+//
+// Each of them begins with a header indicating the Dockerfile version it
+// corresponds to, and the hash of what was written is recorded in a
+// "base.lock" file, so copies modified by the user can be told apart from
+// outdated ones.
+var baseFiles = []struct {
+	// Name of the file, both in the data directory and in the embeds
+	name string
+	// Key of its hash in the "base.lock" file
+	lockKey string
+	// Hashes of the content shipped by versions of paul-envs which did not
+	// write a "base.lock" file, so unmodified copies of them are recognized
+	previousHashes []string
+}{
+	{
+		name:    "Dockerfile",
+		lockKey: "DOCKERFILE",
+		previousHashes: []string{
+			"00bb91d93d9d29faf240be66e017cae14a05a26f65293466984e552fed4d388b", // 1.0.0
+		},
+	},
+	{
+		name:    "entrypoint.sh",
+		lockKey: "ENTRYPOINT",
+		previousHashes: []string{
+			"c8d9861ff915ffd3730e711fd5c92b5add5d31f265a8f3cc98e045aed97e25c2", // 1.0.0
+		},
+	},
+}
+
+const baseLockFilename = "base.lock"
+
+// Matches the header line of a base file, e.g. "# Dockerfile - Version: 1.1.0"
+var baseFileHeaderRegexp = regexp.MustCompile(`^# \S+ - Version: (\S+)$`)
+
+// BaseFileStatus indicates how a base file compares to the one embedded in
+// this version of paul-envs.
+type BaseFileStatus int
+
+const (
+	// Identical to the embedded file
+	BaseFileUpToDate BaseFileStatus = iota
+	// Not written yet
+	BaseFileMissing
+	// Written by an older version of paul-envs and not modified since
+	BaseFileOutdated
+	// Written by a newer version of paul-envs
+	BaseFileNewer
+	// Modified since paul-envs wrote it
+	BaseFileModified
+)
+
+func (s BaseFileStatus) String() string {
+	switch s {
+	case BaseFileUpToDate:
+		return "up to date"
+	case BaseFileMissing:
+		return "missing"
+	case BaseFileOutdated:
+		return "outdated"
+	case BaseFileNewer:
+		return "written by a newer version"
+	case BaseFileModified:
+		return "modified"
+	default:
+		return "unknown status"
+	}
+}
+
+// BaseFile describes the state of one of the base files.
+type BaseFile struct {
+	// Name of the file (e.g. "Dockerfile")
+	Name string
+	// Path of the file in paul-envs' data directory
+	Path string
+	// How it compares to the file embedded in this version
+	Status BaseFileStatus
+	// The version indicated by the header of the written file.
+	// `nil` if it is missing or has no header.
+	Version *utils.Version
+	// The content of the written file, `nil` if it is missing
+	Content []byte
+	// The content embedded in this version of paul-envs
+	LatestContent []byte
+	// Key of its hash in the "base.lock" file
+	lockKey string
+	// If `true`, the hash of the content embedded in this version is the one
+	// recorded in the "base.lock" file
+	isRecorded bool
+}
+
+// CheckBaseFiles compares the base files written in paul-envs' data directory
+// to the ones embedded in this version, without modifying anything.
+func (f *FileStore) CheckBaseFiles() ([]BaseFile, error) {
+	lock, err := f.readBaseLock()
+	if err != nil {
+		return nil, err
+	}
+	checked := make([]BaseFile, 0, len(baseFiles))
+	for _, baseFile := range baseFiles {
+		latest, err := assets.ReadFile("embeds/" + baseFile.name)
+		if err != nil {
+			return nil, err
+		}
+		file := BaseFile{
+			Name:          baseFile.name,
+			Path:          filepath.Join(f.baseDataDir, baseFile.name),
+			LatestContent: latest,
+			lockKey:       baseFile.lockKey,
+			isRecorded:    lock[baseFile.lockKey] == utils.BufferHash(latest),
+		}
+		file.Content, err = os.ReadFile(file.Path)
+		if os.IsNotExist(err) {
+			file.Status = BaseFileMissing
+			checked = append(checked, file)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot read base file '%s': %w", file.Path, err)
+		}
+		file.Version = parseBaseFileVersion(file.Content)
+		switch {
+		case bytes.Equal(file.Content, latest):
+			file.Status = BaseFileUpToDate
+		case file.Version != nil && versions.DockerfileVersion.IsOlderThan(*file.Version):
+			file.Status = BaseFileNewer
+		case lock[baseFile.lockKey] == utils.BufferHash(file.Content),
+			slices.Contains(baseFile.previousHashes, utils.BufferHash(file.Content)):
+			file.Status = BaseFileOutdated
+		case bytes.Equal(removeBaseFileHeader(file.Content), removeBaseFileHeader(latest)):
+			// Written before its header was added
+			file.Status = BaseFileOutdated
+		default:
+			file.Status = BaseFileModified
+		}
+		checked = append(checked, file)
+	}
+	return checked, nil
+}
+
+// RefreshBaseFiles writes the base files which are missing and replaces the
+// outdated ones by those of this version.
+//
+// Files which were modified or written by a newer version are kept as is and
+// returned, so they can be reported.
+func (f *FileStore) RefreshBaseFiles() ([]BaseFile, error) {
+	checked, err := f.CheckBaseFiles()
+	if err != nil {
+		return nil, err
+	}
+	toWrite := []BaseFile{}
+	kept := []BaseFile{}
+	for _, file := range checked {
+		switch file.Status {
+		case BaseFileUpToDate:
+			// Files written by versions without a "base.lock" file
+			if !file.isRecorded {
+				toWrite = append(toWrite, file)
+			}
+		case BaseFileMissing, BaseFileOutdated:
+			toWrite = append(toWrite, file)
+		case BaseFileNewer, BaseFileModified:
+			kept = append(kept, file)
+		}
+	}
+	if err := f.writeBaseFiles(toWrite); err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// UpgradeBaseFiles replaces all base files by the ones embedded in this
+// version, even those which were modified.
+//
+// Files which were modified or written by a newer version are first copied
+// next to them with an ".old" extension. The paths of those copies are
+// returned.
+func (f *FileStore) UpgradeBaseFiles() ([]string, error) {
+	checked, err := f.CheckBaseFiles()
+	if err != nil {
+		return nil, err
+	}
+	toWrite := []BaseFile{}
+	backups := []string{}
+	for _, file := range checked {
+		if file.Status == BaseFileUpToDate {
+			continue
+		}
+		if file.Status == BaseFileNewer || file.Status == BaseFileModified {
+			backupPath := file.Path + ".old"
+			if err := f.userFS.WriteFileAsUser(backupPath, file.Content, 0644); err != nil {
+				return backups, fmt.Errorf("cannot back up base file '%s': %w", file.Path, err)
+			}
+			backups = append(backups, backupPath)
+		}
+		toWrite = append(toWrite, file)
+	}
+	return backups, f.writeBaseFiles(toWrite)
+}
+
+// Write the embedded content of the given base files and record their hash in
+// the "base.lock" file.
+func (f *FileStore) writeBaseFiles(toWrite []BaseFile) error {
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return err
+	}
+	if len(toWrite) == 0 {
+		return nil
+	}
+	lock, err := f.readBaseLock()
+	if err != nil {
+		return err
+	}
+	for _, file := range toWrite {
+		if err := f.userFS.WriteFileAsUser(file.Path, file.LatestContent, 0644); err != nil {
+			return fmt.Errorf("cannot write base file '%s': %w", file.Path, err)
+		}
+		lock[file.lockKey] = utils.BufferHash(file.LatestContent)
+	}
+	return f.writeBaseLock(lock)
+}
+
+// Read the hashes recorded in the "base.lock" file, indexed by their key.
+// Returns an empty map if that file does not exist yet.
+func (f *FileStore) readBaseLock() (map[string]string, error) {
+	lock := map[string]string{}
+	file, err := os.Open(filepath.Join(f.baseDataDir, baseLockFilename))
+	if os.IsNotExist(err) {
+		return lock, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not open '%s': %w", baseLockFilename, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		if key == "VERSION" {
+			v, err := utils.ParseVersion(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid '%s' version '%s': %w", baseLockFilename, value, err)
+			}
+			if !v.IsCompatibleWithBase(versions.BaseLockVersion) {
+				return nil, fmt.Errorf("unknown '%s' version '%s'", baseLockFilename, value)
+			}
+			continue
+		}
+		lock[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading '%s': %w", baseLockFilename, err)
+	}
+	return lock, nil
+}
+
+func (f *FileStore) writeBaseLock(lock map[string]string) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "VERSION=%s\n", versions.BaseLockVersion.ToString())
+	for _, baseFile := range baseFiles {
+		if hash, ok := lock[baseFile.lockKey]; ok {
+			fmt.Fprintf(&buf, "%s=%s\n", baseFile.lockKey, hash)
+		}
+	}
+	lockPath := filepath.Join(f.baseDataDir, baseLockFilename)
+	if err := f.userFS.WriteFileAsUser(lockPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write '%s': %w", lockPath, err)
+	}
+	return nil
+}
+
+// Returns the version indicated by the header of a base file, in its first
+// lines, or `nil` if there is none.
+func parseBaseFileVersion(content []byte) *utils.Version {
+	lines := strings.SplitN(string(content), "\n", 4)
+	for _, line := range lines[:min(len(lines), 3)] {
+		if match := baseFileHeaderRegexp.FindStringSubmatch(line); match != nil {
+			if v, err := utils.ParseVersion(match[1]); err == nil {
+				return &v
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the content of a base file without its header line.
+func removeBaseFileHeader(content []byte) []byte {
+	lines := strings.Split(string(content), "\n")
+	lines = slices.DeleteFunc(lines, baseFileHeaderRegexp.MatchString)
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,143 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func TestEmbeddedBaseFilesVersion(t *testing.T) {
+	for _, baseFile := range baseFiles {
+		content, err := assets.ReadFile("embeds/" + baseFile.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		version := parseBaseFileVersion(content)
+		if version == nil || *version != versions.DockerfileVersion {
+			t.Errorf("the header of '%s' should indicate version %s, got %v",
+				baseFile.name, versions.DockerfileVersion.ToString(), version)
+		}
+	}
+}
+
+func TestFileStore_RefreshBaseFiles(t *testing.T) {
+	baseDataDir := t.TempDir()
+	store := &FileStore{
+		userFS: &UserFS{
+			homeDir:  t.TempDir(),
+			sudoUser: nil,
+		},
+		baseDataDir:   baseDataDir,
+		baseConfigDir: t.TempDir(),
+		projectsDir:   filepath.Join(baseDataDir, "projects"),
+	}
+	statuses := func() map[string]BaseFileStatus {
+		t.Helper()
+		checked, err := store.CheckBaseFiles()
+		if err != nil {
+			t.Fatalf("CheckBaseFiles() error = %v", err)
+		}
+		result := map[string]BaseFileStatus{}
+		for _, file := range checked {
+			result[file.Name] = file.Status
+		}
+		return result
+	}
+	dockerfilePath := filepath.Join(baseDataDir, "Dockerfile")
+	entrypointPath := filepath.Join(baseDataDir, "entrypoint.sh")
+
+	if s := statuses(); s["Dockerfile"] != BaseFileMissing || s["entrypoint.sh"] != BaseFileMissing {
+		t.Fatalf("expected missing base files, got %v", s)
+	}
+	if kept, err := store.RefreshBaseFiles(); err != nil || len(kept) != 0 {
+		t.Fatalf("RefreshBaseFiles() = %v, %v", kept, err)
+	}
+	if s := statuses(); s["Dockerfile"] != BaseFileUpToDate || s["entrypoint.sh"] != BaseFileUpToDate {
+		t.Fatalf("expected up to date base files, got %v", s)
+	}
+
+	// Simulate files written by an older version, then modified for the entrypoint
+	older := versions.DockerfileVersion
+	older.Minor--
+	oldDockerfile := []byte("# Dockerfile - Version: " + older.ToString() + "\nFROM ubuntu:22.04\n")
+	oldEntrypoint := []byte("#!/bin/bash\n# entrypoint.sh - Version: " + older.ToString() + "\nexec bash\n")
+	lock := map[string]string{
+		"DOCKERFILE": utils.BufferHash(oldDockerfile),
+		"ENTRYPOINT": utils.BufferHash(oldEntrypoint),
+	}
+	if err := store.writeBaseLock(lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dockerfilePath, oldDockerfile, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entrypointPath, append(oldEntrypoint, "echo custom\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses(); s["Dockerfile"] != BaseFileOutdated || s["entrypoint.sh"] != BaseFileModified {
+		t.Fatalf("expected an outdated Dockerfile and a modified entrypoint, got %v", s)
+	}
+	kept, err := store.RefreshBaseFiles()
+	if err != nil || len(kept) != 1 || kept[0].Name != "entrypoint.sh" || *kept[0].Version != older {
+		t.Fatalf("only the modified entrypoint should have been kept, got %+v (err: %v)", kept, err)
+	}
+	if s := statuses(); s["Dockerfile"] != BaseFileUpToDate {
+		t.Errorf("the outdated Dockerfile should have been upgraded, got %v", s)
+	}
+
+	backups, err := store.UpgradeBaseFiles()
+	if err != nil || len(backups) != 1 || backups[0] != entrypointPath+".old" {
+		t.Fatalf("UpgradeBaseFiles() = %v, %v", backups, err)
+	}
+	if content, _ := os.ReadFile(backups[0]); !strings.Contains(string(content), "echo custom") {
+		t.Errorf("the modified entrypoint should have been backed up, got:\n%s", content)
+	}
+	if s := statuses(); s["entrypoint.sh"] != BaseFileUpToDate {
+		t.Errorf("the entrypoint should have been upgraded, got %v", s)
+	}
+
+	// Files from before headers and the "base.lock" file
+	if err := os.Remove(filepath.Join(baseDataDir, baseLockFilename)); err != nil {
+		t.Fatal(err)
+	}
+	latest, _ := os.ReadFile(entrypointPath)
+	if err := os.WriteFile(entrypointPath, removeBaseFileHeader(latest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses(); s["Dockerfile"] != BaseFileUpToDate || s["entrypoint.sh"] != BaseFileOutdated {
+		t.Errorf("an entrypoint only lacking its header should be outdated, got %v", s)
+	}
+
+	// Files written by version 1.0.0, which had no "base.lock" file
+	for _, name := range []string{"Dockerfile", "entrypoint.sh"} {
+		content, err := os.ReadFile(filepath.Join("testdata", "base-1.0.0", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(baseDataDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := statuses(); s["Dockerfile"] != BaseFileOutdated || s["entrypoint.sh"] != BaseFileOutdated {
+		t.Errorf("unmodified base files of version 1.0.0 should be outdated, got %v", s)
+	}
+	if kept, err := store.RefreshBaseFiles(); err != nil || len(kept) != 0 {
+		t.Fatalf("RefreshBaseFiles() = %v, %v", kept, err)
+	}
+	if s := statuses(); s["Dockerfile"] != BaseFileUpToDate || s["entrypoint.sh"] != BaseFileUpToDate {
+		t.Errorf("base files of version 1.0.0 should have been upgraded, got %v", s)
+	}
+
+	newer := versions.DockerfileVersion
+	newer.Major++
+	if err := os.WriteFile(dockerfilePath, []byte("# Dockerfile - Version: "+newer.ToString()+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses(); s["Dockerfile"] != BaseFileNewer {
+		t.Errorf("expected a Dockerfile written by a newer version, got %v", s)
+	}
+}
@@ -0,0 +1,442 @@
+// # bundle.go
+// This file handles project bundles: portable archives of a project's
+// configuration written by the `export` command, which can be imported on
+// another machine.
+//
+// Values binding a project to the host it has been created on (its mounted
+// path, UID/GID and SSH public key) and to its user (its git identity) are
+// removed from exported files and set again when importing them.
+
+package files
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+const (
+	// File describing the bundle itself
+	bundleInfoFilename = "bundle.info"
+	// Directory of the bundle in which dotfiles are stored
+	bundleDotfilesDir = "dotfiles"
+	// Maximum size of a single file read from a bundle
+	maxBundleFileSize = 64 << 20
+	// Replaces the host path of the mounted SSH public key in exported files
+	bundleSSHKeyPlaceholder = "SSH_PUBLIC_KEY"
+)
+
+// The configuration of a project, in a format which can be shared with
+// another machine.
+type Bundle struct {
+	// Name of the exported project
+	ProjectName string
+	// Its `.env` and `compose.yaml` files, without host-specific values
+	Files ProjectFilesContent
+	// Dotfiles exported alongside it, empty if they were not included
+	Dotfiles []BundleFile
+	// Content of its `project.lock` file
+	lockContent []byte
+	// Parsed `lockContent`
+	lockInfo projectLockInfo
+}
+
+// A file stored in a `Bundle`.
+type BundleFile struct {
+	// Slash-separated path, relative to the directory it comes from
+	Path    string
+	Mode    fs.FileMode
+	Content []byte
+}
+
+// Values binding a project to the machine it runs on.
+type HostBinding struct {
+	// Path on the host to the mounted project
+	ProjectPath string
+	// UID and GID of the container's user
+	UID string
+	GID string
+	// Path on the host to the SSH public key mounted in the container. If
+	// empty, that mount is commented out.
+	SSHKeyPath string
+	// Git author name and e-mail used inside the container, may be empty
+	GitName  string
+	GitEmail string
+}
+
+// Returns the Dockerfile version the files of this bundle have been created
+// for, as "x.y.z".
+func (b *Bundle) DockerfileVersion() string {
+	return b.lockInfo.dockerfileVersion.ToString()
+}
+
+// Returns `true` if the project of this bundle mounts an SSH public key.
+func (b *Bundle) UsesSSHKey() bool {
+	_, found := replaceSSHKeyMount(b.Files.Compose, bundleSSHKeyPlaceholder)
+	return found
+}
+
+// Returns the volumes of the project of this bundle which are mounted from an
+// absolute path of the host it has been exported from, which may not exist on
+// this one.
+func (b *Bundle) HostVolumes() []string {
+	cfg, err := parseProjectConfig(b.Files)
+	if err != nil {
+		return nil
+	}
+	volumes := []string{}
+	for _, volume := range cfg.Volumes {
+		if strings.HasPrefix(volume, "/") || strings.HasPrefix(volume, "~") || filepath.VolumeName(volume) != "" {
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes
+}
+
+// Create a `Bundle` from the given existing project, optionally including
+// paul-envs' dotfiles.
+func (f *FileStore) ExportProject(projectName string, withDotfiles bool) (*Bundle, error) {
+	content, err := f.ReadProjectFiles(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("could not read files of project '%s': %w", projectName, err)
+	}
+	lockContent, err := os.ReadFile(f.getProjectInfoFilePathFor(projectName))
+	if err != nil {
+		return nil, fmt.Errorf("could not read 'project.lock' of project '%s': %w", projectName, err)
+	}
+	lockInfo, err := parseProjectInfo(bytes.NewReader(lockContent))
+	if err != nil {
+		return nil, err
+	}
+
+	portable, err := unbindProjectFiles(content)
+	if err != nil {
+		return nil, err
+	}
+	// Unlike the image, the project files may be used with other IDs and by
+	// other people
+	portable.Env, err = replaceLines(portable.Env, map[string]string{
+		"HOST_UID=":         `HOST_UID=""`,
+		"HOST_GID=":         `HOST_GID=""`,
+		"GIT_AUTHOR_NAME=":  `GIT_AUTHOR_NAME=""`,
+		"GIT_AUTHOR_EMAIL=": `GIT_AUTHOR_EMAIL=""`,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("in .env file: %w", err)
+	}
+	bundle := &Bundle{
+		ProjectName: projectName,
+		Files:       portable,
+		lockContent: lockContent,
+		lockInfo:    lockInfo,
+	}
+
+	if withDotfiles {
+		dotfilesDir, err := f.InitGlobalDotfilesDir()
+		if err != nil {
+			return nil, err
+		}
+		if bundle.Dotfiles, err = readBundleFiles(dotfilesDir); err != nil {
+			return nil, fmt.Errorf("could not read dotfiles: %w", err)
+		}
+	}
+	return bundle, nil
+}
+
+// Create the project `projectName` from the given bundle, bound to this
+// machine through `binding`.
+func (f *FileStore) ImportProject(bundle *Bundle, projectName string, binding HostBinding) error {
+	if f.DoesProjectExist(projectName) {
+		return fmt.Errorf("project '%s' already exists", projectName)
+	}
+	if err := f.ensureCreatedBaseFiles(); err != nil {
+		return fmt.Errorf("create base files: %w", err)
+	}
+	content, err := renameProjectFiles(bundle.Files, bundle.ProjectName, projectName, binding.ProjectPath)
+	if err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+	content.Env, err = replaceLines(content.Env, map[string]string{
+		"HOST_UID=":         fmt.Sprintf(`HOST_UID="%s"`, utils.EscapeEnvValue(binding.UID)),
+		"HOST_GID=":         fmt.Sprintf(`HOST_GID="%s"`, utils.EscapeEnvValue(binding.GID)),
+		"GIT_AUTHOR_NAME=":  fmt.Sprintf(`GIT_AUTHOR_NAME="%s"`, utils.EscapeEnvValue(binding.GitName)),
+		"GIT_AUTHOR_EMAIL=": fmt.Sprintf(`GIT_AUTHOR_EMAIL="%s"`, utils.EscapeEnvValue(binding.GitEmail)),
+	})
+	if err != nil {
+		return fmt.Errorf("invalid bundle: in .env file: %w", err)
+	}
+	content.Compose, _ = replaceSSHKeyMount(content.Compose, binding.SSHKeyPath)
+
+	if err := f.userFS.MkdirAsUser(f.getProjectDir(projectName), 0755); err != nil {
+		return fmt.Errorf("create project directory: %w", err)
+	}
+	if err := f.writeProjectFilesContent(projectName, content); err != nil {
+		return err
+	}
+	if IsLegacyProjectFiles(bundle.Files) {
+		// They have been re-generated for the current version
+		if err := f.writeProjectInfo(projectName); err != nil {
+			return fmt.Errorf("impossibility to write 'project.lock' file: %w", err)
+		}
+		return nil
+	}
+	// Keep the versions those files have been created for
+	if err := f.userFS.WriteFileAsUser(f.getProjectInfoFilePathFor(projectName), bundle.lockContent, 0644); err != nil {
+		return fmt.Errorf("impossibility to write 'project.lock' file: %w", err)
+	}
+	return nil
+}
+
+// Add the dotfiles of the given bundle to paul-envs' dotfiles directory.
+// Existing files are never replaced: their paths are returned instead.
+func (f *FileStore) ImportDotfiles(bundle *Bundle) ([]string, error) {
+	dotfilesDir, err := f.InitGlobalDotfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	skipped := []string{}
+	for _, file := range bundle.Dotfiles {
+		target := filepath.Join(dotfilesDir, filepath.FromSlash(file.Path))
+		if _, err := os.Lstat(target); err == nil {
+			skipped = append(skipped, file.Path)
+			continue
+		}
+		if err := f.userFS.MkdirAsUser(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("could not create directory for '%s': %w", file.Path, err)
+		}
+		if err := f.userFS.WriteFileAsUser(target, file.Content, file.Mode.Perm()); err != nil {
+			return nil, fmt.Errorf("could not write '%s': %w", file.Path, err)
+		}
+	}
+	return skipped, nil
+}
+
+// Write this bundle as a gzip-compressed tar archive.
+func (b *Bundle) Encode(w io.Writer) error {
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+	info := fmt.Appendf(nil, "VERSION=%s\nPROJECT_NAME=%s\n", versions.BundleVersion.ToString(), b.ProjectName)
+	files := []BundleFile{
+		{Path: bundleInfoFilename, Mode: 0644, Content: info},
+		{Path: projectEnvFilename, Mode: 0644, Content: b.Files.Env},
+		{Path: projectComposeFilename, Mode: 0644, Content: b.Files.Compose},
+		{Path: projectInfoFilename, Mode: 0644, Content: b.lockContent},
+	}
+	for _, dotfile := range b.Dotfiles {
+		files = append(files, BundleFile{
+			Path:    path.Join(bundleDotfilesDir, dotfile.Path),
+			Mode:    dotfile.Mode,
+			Content: dotfile.Content,
+		})
+	}
+	now := time.Now()
+	for _, file := range files {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.Path,
+			Mode:     int64(file.Mode.Perm()),
+			Size:     int64(len(file.Content)),
+			ModTime:  now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("could not write '%s' to bundle: %w", file.Path, err)
+		}
+		if _, err := tw.Write(file.Content); err != nil {
+			return fmt.Errorf("could not write '%s' to bundle: %w", file.Path, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("could not write bundle: %w", err)
+	}
+	if err := gzw.Close(); err != nil {
+		return fmt.Errorf("could not write bundle: %w", err)
+	}
+	return nil
+}
+
+// Read a bundle written by `Bundle.Encode`, checking that it is compatible
+// with this version of paul-envs.
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid bundle: %w", err)
+	}
+	defer gzr.Close()
+	tr := tar.NewReader(gzr)
+
+	bundle := &Bundle{}
+	var info []byte
+	var hasEnv, hasCompose bool
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a valid bundle: %w", err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || !fs.ValidPath(name) {
+			return nil, fmt.Errorf("invalid bundle: unexpected entry '%s'", header.Name)
+		}
+		if header.Size > maxBundleFileSize {
+			return nil, fmt.Errorf("invalid bundle: '%s' is too large", name)
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxBundleFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s' from bundle: %w", name, err)
+		}
+
+		switch name {
+		case bundleInfoFilename:
+			info = content
+		case projectEnvFilename:
+			bundle.Files.Env, hasEnv = content, true
+		case projectComposeFilename:
+			bundle.Files.Compose, hasCompose = content, true
+		case projectInfoFilename:
+			bundle.lockContent = content
+		default:
+			dotfilePath, ok := strings.CutPrefix(name, bundleDotfilesDir+"/")
+			if !ok {
+				return nil, fmt.Errorf("invalid bundle: unexpected file '%s'", name)
+			}
+			bundle.Dotfiles = append(bundle.Dotfiles, BundleFile{
+				Path:    dotfilePath,
+				Mode:    fs.FileMode(header.Mode).Perm(),
+				Content: content,
+			})
+		}
+	}
+	if info == nil || !hasEnv || !hasCompose || bundle.lockContent == nil {
+		return nil, errors.New("invalid bundle: missing project files")
+	}
+
+	if err := parseBundleInfo(info, bundle); err != nil {
+		return nil, err
+	}
+	bundle.lockInfo, err = parseProjectInfo(bytes.NewReader(bundle.lockContent))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if !bundle.lockInfo.version.IsCompatibleWithBase(versions.ProjectLockVersion) {
+		return nil, fmt.Errorf("bundle's project.lock version %s is incompatible with current version %s",
+			bundle.lockInfo.version.ToString(), versions.ProjectLockVersion.ToString())
+	}
+	if !bundle.lockInfo.dockerfileVersion.IsCompatibleWithBase(versions.DockerfileVersion) {
+		return nil, fmt.Errorf("bundle's Dockerfile version %s is incompatible with current version %s",
+			bundle.lockInfo.dockerfileVersion.ToString(), versions.DockerfileVersion.ToString())
+	}
+	return bundle, nil
+}
+
+// Parse the `bundle.info` file of a bundle, setting its project name.
+func parseBundleInfo(content []byte, bundle *Bundle) error {
+	var version *utils.Version
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if vStr, ok := strings.CutPrefix(line, "VERSION="); ok {
+			v, err := utils.ParseVersion(vStr)
+			if err != nil {
+				return fmt.Errorf("invalid bundle version '%s': %w", vStr, err)
+			}
+			version = &v
+		} else if name, ok := strings.CutPrefix(line, "PROJECT_NAME="); ok {
+			bundle.ProjectName = name
+		}
+	}
+	if version == nil {
+		return errors.New("invalid bundle: no VERSION")
+	}
+	if !version.IsCompatibleWithBase(versions.BundleVersion) {
+		return fmt.Errorf("bundle version %s is incompatible with current version %s",
+			version.ToString(), versions.BundleVersion.ToString())
+	}
+	if err := utils.ValidateProjectName(bundle.ProjectName); err != nil {
+		return fmt.Errorf("invalid bundle: invalid project name: %w", err)
+	}
+	return nil
+}
+
+// Read all regular files in `dir`, recursively. Other types of files (e.g.
+// symbolic links) are ignored.
+func readBundleFiles(dir string) ([]BundleFile, error) {
+	files := []BundleFile{}
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		files = append(files, BundleFile{Path: filepath.ToSlash(rel), Mode: info.Mode().Perm(), Content: content})
+		return nil
+	})
+	return files, err
+}
+
+// Replace the host path of the SSH public key mounted in the given compose
+// file by `hostPath`, or comment out that mount if `hostPath` is empty.
+//
+// Returns `false` if no SSH public key is mounted.
+func replaceSSHKeyMount(compose []byte, hostPath string) ([]byte, bool) {
+	found := false
+	lines := strings.Split(string(compose), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "- ") || !strings.HasSuffix(trimmed, sshKeyVolumeSuffix) {
+			continue
+		}
+		found = true
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if hostPath == "" {
+			lines[i] = indent + "# - " + bundleSSHKeyPlaceholder + sshKeyVolumeSuffix
+		} else {
+			lines[i] = indent + "- " + hostPath + sshKeyVolumeSuffix
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), found
+}
+
+// Remove from the given project files the values binding them to the host
+// which are not used when building an image: its mounted path and SSH public
+// key.
+func unbindProjectFiles(content ProjectFilesContent) (ProjectFilesContent, error) {
+	env, err := replaceLines(content.Env, map[string]string{
+		"PROJECT_PATH=": `PROJECT_PATH=""`,
+	})
+	if err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("in .env file: %w", err)
+	}
+	compose, _ := replaceSSHKeyMount(content.Compose, bundleSSHKeyPlaceholder)
+	return ProjectFilesContent{Env: env, Compose: compose}, nil
+}
@@ -0,0 +1,80 @@
+package files
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+// Write a bundle archive with the given files, keyed by their path.
+func writeTestBundle(t *testing.T, entries map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, content := range entries {
+		header := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestReadBundle(t *testing.T) {
+	validEntries := func() map[string]string {
+		return map[string]string{
+			"bundle.info":   "VERSION=1.0.0\nPROJECT_NAME=myapp\n",
+			".env":          "PROJECT_ID=\"myapp\"\nPROJECT_PATH=\"\"\n",
+			"compose.yaml":  "services:\n",
+			"project.lock":  "VERSION=1.0.0\nDOCKERFILE_VERSION=1.0.0\n",
+			"dotfiles/.vim": "set nu\n",
+		}
+	}
+
+	bundle, err := ReadBundle(writeTestBundle(t, validEntries()))
+	if err != nil {
+		t.Fatalf("ReadBundle() error = %v", err)
+	}
+	if bundle.ProjectName != "myapp" || bundle.DockerfileVersion() != "1.0.0" {
+		t.Errorf("unexpected bundle: %+v", bundle)
+	}
+	if len(bundle.Dotfiles) != 1 || bundle.Dotfiles[0].Path != ".vim" {
+		t.Errorf("unexpected dotfiles: %+v", bundle.Dotfiles)
+	}
+
+	tests := []struct {
+		name    string
+		update  func(map[string]string)
+		wantErr string
+	}{
+		{"newer Dockerfile", func(e map[string]string) { e["project.lock"] = "VERSION=1.0.0\nDOCKERFILE_VERSION=1.99.0\n" }, "Dockerfile version"},
+		{"other Dockerfile major", func(e map[string]string) { e["project.lock"] = "VERSION=1.0.0\nDOCKERFILE_VERSION=0.1.0\n" }, "Dockerfile version"},
+		{"newer bundle", func(e map[string]string) { e["bundle.info"] = "VERSION=2.0.0\nPROJECT_NAME=myapp\n" }, "bundle version"},
+		{"missing file", func(e map[string]string) { delete(e, "compose.yaml") }, "missing"},
+		{"path traversal", func(e map[string]string) { e["../evil"] = "" }, "unexpected entry"},
+		{"unknown file", func(e map[string]string) { e["other"] = "" }, "unexpected file"},
+		{"invalid name", func(e map[string]string) { e["bundle.info"] = "VERSION=1.0.0\nPROJECT_NAME=../x\n" }, "project name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := validEntries()
+			tt.update(entries)
+			_, err := ReadBundle(writeTestBundle(t, entries))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadBundle() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
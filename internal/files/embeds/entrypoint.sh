@@ -1,4 +1,5 @@
 #!/bin/bash
+# entrypoint.sh - Version: 1.1.0
 
 # This is the container's entry point. All containers will start executiing
 # that script to ensure that everything is initialized and starting the right
@@ -8,6 +9,9 @@
 #
 # It then executes either the default shell (if executed without arguments) or
 # the arguments given to it.
+# When the container is started in the background (`PAULENV_KEEP_ALIVE` set to
+# "true"), its main process instead just keeps it alive, sessions then joining
+# it by re-executing that script.
 
 CONTAINER_USERNAME="${CONTAINER_USERNAME:-dev}"
 USER_SHELL="${USER_SHELL:-/usr/bin/bash}"
@@ -43,6 +47,13 @@ if [[ -d /var/run/sshd ]] && ! pgrep -x sshd >/dev/null; then
     fi
 fi
 
+# Keep the container alive when it has been started in the background. Sessions
+# joining it are not the container's main process (their parent's pid is 0,
+# whereas the main process is either pid 1 or a child of the init process).
+if [[ "${PAULENV_KEEP_ALIVE}" == "true" ]] && [[ $$ -eq 1 || $PPID -eq 1 ]]; then
+    exec sleep infinity
+fi
+
 # Execute command or start shell
 if [[ $# -eq 0 ]]; then
     exec su ${CONTAINER_USERNAME} -s ${USER_SHELL}
@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strings"
+	"time"
 )
 
 const (
@@ -26,6 +28,9 @@ type FileStore struct {
 	baseDataDir   string
 	baseConfigDir string
 	projectsDir   string
+	// Kept outside of `baseDataDir`, so removing all projects does not
+	// remove their backups.
+	backupsDir string
 }
 
 // Information linked to a given project.
@@ -58,6 +63,7 @@ func NewFileStore() (*FileStore, error) {
 		baseDataDir:   paulEnvsDataDir,
 		baseConfigDir: paulEnvsConfigDir,
 		projectsDir:   filepath.Join(paulEnvsDataDir, "projects"),
+		backupsDir:    filepath.Join(userFS.GetUserDataDir(), "paul-envs-backups"),
 	}, nil
 }
 
@@ -77,6 +83,22 @@ func (f *FileStore) DeleteConfigDirectory() error {
 	return os.RemoveAll(f.baseConfigDir)
 }
 
+// Returns the path to the directory in which backups of the projects' local
+// volumes are written by default.
+func (f *FileStore) GetBackupsDir() string {
+	return f.backupsDir
+}
+
+// Returns the path to a new backup of the local volume of the given project,
+// in the backups directory (which is created if needed).
+func (f *FileStore) NewBackupFilePath(projectName string) (string, error) {
+	if err := f.userFS.MkdirAsUser(f.backupsDir, 0755); err != nil {
+		return "", fmt.Errorf("could not create backups directory: %w", err)
+	}
+	filename := fmt.Sprintf("paulenv-%s-local-%s.tar.gz", projectName, time.Now().Format("20060102-150405"))
+	return filepath.Join(f.backupsDir, filename), nil
+}
+
 // Delete files associated to the named project.
 func (f *FileStore) DeleteProjectDirectory(name string) error {
 	return os.RemoveAll(f.getProjectDir(name))
@@ -138,7 +160,7 @@ func (f *FileStore) GetAllProjects() ([]ProjectEntry, error) {
 // Ensure the "dotfiles" directory in paul-envs' config directory is created and
 // return its path so you can advertise it to the user.
 func (f *FileStore) InitGlobalDotfilesDir() (string, error) {
-	dotfilesDir := filepath.Join(f.baseConfigDir, "dotfiles")
+	dotfilesDir := f.getGlobalDotfilesDir()
 	if err := f.userFS.MkdirAsUser(dotfilesDir, 0755); err != nil {
 		return "", fmt.Errorf("create base config directory: %w", err)
 	}
@@ -178,6 +200,18 @@ func (f *FileStore) RemoveProjectDotfilesDir(projectName string) error {
 	return os.RemoveAll(expectedDir)
 }
 
+// Returns the ports exposed by the given project's container, as written in
+// its `compose.yaml` file (e.g. "3000:3000").
+func (f *FileStore) GetProjectPorts(name string) ([]string, error) {
+	composeFile := f.GetProjectComposeFilePath(name)
+	file, err := os.Open(composeFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not open compose file associated to project '%s': %w", name, err)
+	}
+	defer file.Close()
+	return parseComposePorts(file)
+}
+
 // Get path to the given project's compose file.
 // TODO: make private
 func (f *FileStore) GetProjectComposeFilePath(name string) string {
@@ -208,6 +242,12 @@ func (f *FileStore) getBuildInfoFilePathFor(projectName string) string {
 	return filepath.Join(f.projectsDir, projectName, buildInfoFilename)
 }
 
+// Get path to the "dotfiles" directory whose content is copied in all built
+// images.
+func (f *FileStore) getGlobalDotfilesDir() string {
+	return filepath.Join(f.baseConfigDir, "dotfiles")
+}
+
 // Get directory where a specific project's files will be put.
 func (f *FileStore) getProjectDir(name string) string {
 	return filepath.Join(f.projectsDir, name)
@@ -223,12 +263,56 @@ func (f *FileStore) parseProjectPath(name string) (string, error) {
 	}
 	defer file.Close()
 
-	re := regexp.MustCompile(`PROJECT_PATH="([^"]*)"`)
-	scanner := bufio.NewScanner(file)
+	values, err := parseEnvFile(file)
+	if err != nil {
+		return "", fmt.Errorf("could not parse .env file associated to project '%s': %w", name, err)
+	}
+	projectPath, ok := values["PROJECT_PATH"]
+	if !ok {
+		return "", fmt.Errorf("did not found the project path associated to project '%s'", name)
+	}
+	return projectPath, nil
+}
+
+// Parse the entries of the first `ports` list found in a compose file.
+func parseComposePorts(rd io.Reader) ([]string, error) {
+	return parseComposeList(rd, "ports")
+}
+
+// Parse the entries of the first block-style list found under the given key
+// in a compose file (e.g. "ports" or "volumes"), unquoted.
+//
+// This is not a complete YAML parser: it only understands the lists written in
+// the generated `compose.yaml` files.
+func parseComposeList(rd io.Reader, key string) ([]string, error) {
+	entries := []string{}
+	listIndent := -1
+	scanner := bufio.NewScanner(rd)
 	for scanner.Scan() {
-		if matches := re.FindStringSubmatch(scanner.Text()); len(matches) == 2 {
-			return matches[1], nil
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
 		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if listIndent < 0 {
+			if trimmed == key+":" {
+				listIndent = indent
+			}
+			continue
+		}
+		if indent <= listIndent {
+			break
+		}
+		if entry, ok := strings.CutPrefix(trimmed, "- "); ok {
+			if comment := strings.Index(entry, " #"); comment >= 0 {
+				entry = entry[:comment]
+			}
+			entries = append(entries, strings.Trim(strings.TrimSpace(entry), `"'`))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading compose file: %w", err)
 	}
-	return "", fmt.Errorf("did not found the project path associated to project '%s'", name)
+	return entries, nil
 }
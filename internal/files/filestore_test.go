@@ -1,6 +1,8 @@
 package files
 
 import (
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -93,3 +95,31 @@ func TestFileStore_GetProjectEnvFilePath(t *testing.T) {
 		t.Errorf("GetProjectEnvFilePath() = %v, want %v", got, expected)
 	}
 }
+
+func TestParseComposePorts(t *testing.T) {
+	compose := `services:
+  paulenv:
+    # Ports opened in this container
+    ports:
+      - "3000:3000"
+      # To listen for ssh connections:
+      - "22:22" # ssh
+      - 8080:80
+
+    volumes:
+      - ./data:/data
+`
+	ports, err := parseComposePorts(strings.NewReader(compose))
+	if err != nil {
+		t.Fatalf("parseComposePorts() error = %v", err)
+	}
+	expected := []string{"3000:3000", "22:22", "8080:80"}
+	if !slices.Equal(ports, expected) {
+		t.Errorf("parseComposePorts() = %v, want %v", ports, expected)
+	}
+
+	ports, err = parseComposePorts(strings.NewReader("services:\n  paulenv:\n    volumes:\n      - ./data:/data\n"))
+	if err != nil || len(ports) != 0 {
+		t.Errorf("expected no port, got %v (%v)", ports, err)
+	}
+}
@@ -0,0 +1,125 @@
+// # global_config.go
+// This file handles paul-envs' global configuration file, which stores
+// user-wide settings (e.g. the default container engine) as `KEY="VALUE"`
+// lines, just like `.env` files.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+const globalConfigFilename = "paul-envs.conf"
+
+// Get path to paul-envs' global configuration file.
+func (f *FileStore) GetGlobalConfigFilePath() string {
+	return filepath.Join(f.baseConfigDir, globalConfigFilename)
+}
+
+// Read all values currently set in the global configuration file.
+// Returns an empty map if that file does not exist yet.
+func (f *FileStore) ReadGlobalConfig() (map[string]string, error) {
+	values := map[string]string{}
+	file, err := os.Open(f.GetGlobalConfigFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return values, nil
+		}
+		return nil, fmt.Errorf("could not open global configuration: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNb := 0
+	for scanner.Scan() {
+		lineNb++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid global configuration line %d: no '=' found", lineNb)
+		}
+		values[strings.TrimSpace(key)] = parseEnvFileValue(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading global configuration: %w", err)
+	}
+	return values, nil
+}
+
+// Get the value set for `key` in the global configuration file, or an empty
+// string if not set.
+func (f *FileStore) GetGlobalConfigValue(key string) (string, error) {
+	values, err := f.ReadGlobalConfig()
+	if err != nil {
+		return "", err
+	}
+	return values[key], nil
+}
+
+// Set `key` to `value` in the global configuration file, creating it if
+// needed.
+func (f *FileStore) SetGlobalConfigValue(key string, value string) error {
+	values, err := f.ReadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	values[key] = value
+	return f.writeGlobalConfig(values)
+}
+
+// Remove `key` from the global configuration file.
+func (f *FileStore) UnsetGlobalConfigValue(key string) error {
+	values, err := f.ReadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[key]; !ok {
+		return nil
+	}
+	delete(values, key)
+	return f.writeGlobalConfig(values)
+}
+
+func (f *FileStore) writeGlobalConfig(values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString("# paul-envs global configuration.\n" +
+		"# Prefer updating it through the 'paul-envs config' command.\n")
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s=\"%s\"\n", key, utils.EscapeEnvValue(values[key]))
+	}
+
+	if err := f.userFS.MkdirAsUser(f.baseConfigDir, 0755); err != nil {
+		return fmt.Errorf("create base config directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(f.GetGlobalConfigFilePath(), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write global configuration: %w", err)
+	}
+	return nil
+}
+
+// Parse the value part of a `KEY="VALUE"` line, with the same escaping rules
+// than the ones used when writing `.env` files.
+func parseEnvFileValue(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && strings.HasPrefix(raw, `"`) && strings.HasSuffix(raw, `"`) {
+		return utils.UnescapeEnvValue(raw[1 : len(raw)-1])
+	}
+	return raw
+}
@@ -0,0 +1,90 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestGlobalConfigStore(t *testing.T) *FileStore {
+	baseDataDir := t.TempDir()
+	return &FileStore{
+		userFS: &UserFS{
+			homeDir:  t.TempDir(),
+			sudoUser: nil,
+		},
+		baseDataDir:   baseDataDir,
+		baseConfigDir: filepath.Join(t.TempDir(), "paul-envs"),
+		projectsDir:   filepath.Join(baseDataDir, "projects"),
+	}
+}
+
+func TestFileStore_GlobalConfig_Missing(t *testing.T) {
+	store := newTestGlobalConfigStore(t)
+	values, err := store.ReadGlobalConfig()
+	if err != nil {
+		t.Fatalf("ReadGlobalConfig() error = %v", err)
+	}
+	if len(values) != 0 {
+		t.Errorf("expected no value, got %v", values)
+	}
+	val, err := store.GetGlobalConfigValue("ENGINE")
+	if err != nil || val != "" {
+		t.Errorf("expected empty value without error, got %q, %v", val, err)
+	}
+}
+
+func TestFileStore_GlobalConfig_SetGetUnset(t *testing.T) {
+	store := newTestGlobalConfigStore(t)
+
+	if err := store.SetGlobalConfigValue("ENGINE", "podman"); err != nil {
+		t.Fatalf("SetGlobalConfigValue() error = %v", err)
+	}
+	if err := store.SetGlobalConfigValue("OTHER", `with "quotes" and $`); err != nil {
+		t.Fatalf("SetGlobalConfigValue() error = %v", err)
+	}
+
+	val, err := store.GetGlobalConfigValue("ENGINE")
+	if err != nil || val != "podman" {
+		t.Errorf("expected \"podman\", got %q, %v", val, err)
+	}
+	val, err = store.GetGlobalConfigValue("OTHER")
+	if err != nil || val != `with "quotes" and $` {
+		t.Errorf("unexpected escaped value %q, %v", val, err)
+	}
+
+	content, err := os.ReadFile(store.GetGlobalConfigFilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `ENGINE="podman"`) {
+		t.Errorf("global configuration missing expected content, got:\n%s", content)
+	}
+
+	if err := store.UnsetGlobalConfigValue("ENGINE"); err != nil {
+		t.Fatalf("UnsetGlobalConfigValue() error = %v", err)
+	}
+	val, err = store.GetGlobalConfigValue("ENGINE")
+	if err != nil || val != "" {
+		t.Errorf("expected unset value, got %q, %v", val, err)
+	}
+	val, err = store.GetGlobalConfigValue("OTHER")
+	if err != nil || val != `with "quotes" and $` {
+		t.Errorf("other values should be kept, got %q, %v", val, err)
+	}
+}
+
+func TestFileStore_GlobalConfig_Invalid(t *testing.T) {
+	store := newTestGlobalConfigStore(t)
+	if err := os.MkdirAll(store.baseConfigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := os.WriteFile(store.GetGlobalConfigFilePath(), []byte("# comment\nENGINE=\"docker\"\nnot a line\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.ReadGlobalConfig(); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected an error on line 3, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+// # presets.go
+// This file handles the storage of presets: named, reusable configurations
+// for new projects (e.g. "frontend"), written in the same TOML format than a
+// project's `.paul-envs.toml` manifest.
+
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const presetFileExtension = ".toml"
+
+// Error returned when reading a preset which does not exist.
+var ErrPresetNotFound = errors.New("preset not found")
+
+// Get path to the directory storing all presets.
+func (f *FileStore) GetPresetsDir() string {
+	return filepath.Join(f.baseConfigDir, "presets")
+}
+
+// Get path to the file storing the given preset.
+func (f *FileStore) GetPresetFilePath(name string) string {
+	return filepath.Join(f.GetPresetsDir(), name+presetFileExtension)
+}
+
+// Returns `true` if a preset with that name has been saved.
+func (f *FileStore) DoesPresetExist(name string) bool {
+	info, err := os.Stat(f.GetPresetFilePath(name))
+	return err == nil && !info.IsDir()
+}
+
+// Read the content of the given preset.
+// Returns an error wrapping `ErrPresetNotFound` if it does not exist.
+func (f *FileStore) ReadPreset(name string) ([]byte, error) {
+	content, err := os.ReadFile(f.GetPresetFilePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: '%s'", ErrPresetNotFound, name)
+		}
+		return nil, fmt.Errorf("could not read preset '%s': %w", name, err)
+	}
+	return content, nil
+}
+
+// Write the given preset, replacing it if it already exists.
+func (f *FileStore) WritePreset(name string, content []byte) error {
+	if err := f.userFS.MkdirAsUser(f.GetPresetsDir(), 0755); err != nil {
+		return fmt.Errorf("create presets directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(f.GetPresetFilePath(name), content, 0644); err != nil {
+		return fmt.Errorf("write preset '%s': %w", name, err)
+	}
+	return nil
+}
+
+// Remove the given preset.
+// Returns an error wrapping `ErrPresetNotFound` if it does not exist.
+func (f *FileStore) RemovePreset(name string) error {
+	if err := os.Remove(f.GetPresetFilePath(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: '%s'", ErrPresetNotFound, name)
+		}
+		return fmt.Errorf("could not remove preset '%s': %w", name, err)
+	}
+	return nil
+}
+
+// Returns the names of all saved presets, sorted alphabetically.
+func (f *FileStore) GetAllPresets() ([]string, error) {
+	entries, err := os.ReadDir(f.GetPresetsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("could not read presets directory: %w", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), presetFileExtension); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,42 @@
+package files
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFileStore_Presets(t *testing.T) {
+	store := newTestGlobalConfigStore(t)
+	names, err := store.GetAllPresets()
+	if err != nil || len(names) != 0 {
+		t.Fatalf("GetAllPresets() without presets = %v, %v", names, err)
+	}
+	if _, err := store.ReadPreset("frontend"); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("ReadPreset() of a missing preset should return ErrPresetNotFound, got %v", err)
+	}
+
+	for _, name := range []string{"rust-wasm", "frontend"} {
+		if err := store.WritePreset(name, []byte("sudo = true\n")); err != nil {
+			t.Fatalf("WritePreset() error = %v", err)
+		}
+	}
+	names, err = store.GetAllPresets()
+	if err != nil || !slices.Equal(names, []string{"frontend", "rust-wasm"}) {
+		t.Errorf("GetAllPresets() = %v, %v", names, err)
+	}
+	content, err := store.ReadPreset("frontend")
+	if err != nil || string(content) != "sudo = true\n" {
+		t.Errorf("ReadPreset() = %q, %v", content, err)
+	}
+
+	if err := store.RemovePreset("frontend"); err != nil {
+		t.Fatalf("RemovePreset() error = %v", err)
+	}
+	if store.DoesPresetExist("frontend") || !store.DoesPresetExist("rust-wasm") {
+		t.Errorf("only the removed preset should be gone")
+	}
+	if err := store.RemovePreset("frontend"); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("RemovePreset() of a missing preset should return ErrPresetNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package files
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Access right allowing to query the exit code of a process, also granted for
+// processes of other users (unlike `syscall.PROCESS_QUERY_INFORMATION`).
+const processQueryLimitedInformation = 0x1000
+
+// Exit code reported by `GetExitCodeProcess` for a process still running.
+const stillActive = 259
+
+// Returns `true` if a process with the given pid currently exists.
+func processExists(pid int) bool {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// The process exists but belongs to a user we cannot query
+		return errors.Is(err, syscall.ERROR_ACCESS_DENIED)
+	}
+	defer syscall.CloseHandle(handle)
+	var exitCode uint32
+	if err := syscall.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return false
+	}
+	// A process which exited with that exact code is considered as alive, as
+	// Windows itself cannot tell them apart
+	return exitCode == stillActive
+}
@@ -13,6 +13,7 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -20,6 +21,7 @@ import (
 	"time"
 
 	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
@@ -52,6 +54,7 @@ type EnvTemplateData struct {
 	InstallJujutsu  string
 	GitName         string
 	GitEmail        string
+	Cache           string
 }
 
 // Data needed to construct a project's `compose.yaml` file, listing mounted
@@ -62,6 +65,7 @@ type ComposeTemplateData struct {
 	EnableSSH   bool
 	SSHKeyPath  string
 	Volumes     []string
+	Cache       string
 }
 
 // Holds the parsed values from the `project.lock` file associated to each project
@@ -82,6 +86,15 @@ type buildState struct {
 	buildEnvHash string
 	// The hash of the `compose.yaml` file the last time the project has been built
 	buildComposeHash string
+	// The hash of the base Dockerfile used by the last build.
+	// Empty if unknown (written by an older version).
+	buildDockerfileHash string
+	// The hash of the base `entrypoint.sh` file used by the last build.
+	// Empty if unknown (written by an older version).
+	buildEntrypointHash string
+	// The hash of the whole dotfiles directory tree at the time of the last build.
+	// Empty if unknown (written by an older version).
+	buildDotfilesHash string
 	// The last time it was built according to this tool
 	builtAt time.Time
 	// The name of the container engine which produced the last build (e.g. "docker")
@@ -90,6 +103,13 @@ type buildState struct {
 	containerEngineVersion string
 }
 
+// Returns `true` if the hashes of the base Dockerfile, `entrypoint.sh` file
+// and dotfiles used by that build are known. Builds performed by older
+// versions did not record them.
+func (b *buildState) HasBaseFilesHashes() bool {
+	return b.buildDockerfileHash != "" && b.buildEntrypointHash != "" && b.buildDotfilesHash != ""
+}
+
 // RebuildReason indicates why a project needs to be rebuilt
 type RebuildReason int
 
@@ -99,6 +119,9 @@ const (
 	RebuildComposeChanged
 	RebuildEnvChanged
 	RebuildDifferentEngine
+	RebuildDockerfileChanged
+	RebuildEntrypointChanged
+	RebuildDotfilesChanged
 )
 
 func (r RebuildReason) String() string {
@@ -113,6 +136,12 @@ func (r RebuildReason) String() string {
 		return ".env file has changed since last build"
 	case RebuildDifferentEngine:
 		return "built on a different container engine"
+	case RebuildDockerfileChanged:
+		return "Dockerfile has changed since last build"
+	case RebuildEntrypointChanged:
+		return "entrypoint.sh has changed since last build"
+	case RebuildDotfilesChanged:
+		return "dotfiles directory has changed since last build"
 	default:
 		return "unknown reason"
 	}
@@ -127,6 +156,9 @@ const (
 	ProjectLockInvalidVersion
 	ProjectLockIncompatibleDockerfile
 	ProjectLockCorrupted
+	// Written for an older version of the base files, still compatible but
+	// lacking what was added since
+	ProjectLockOutdated
 )
 
 func (s ProjectLockStatus) String() string {
@@ -141,13 +173,23 @@ func (s ProjectLockStatus) String() string {
 		return "incompatible Dockerfile version"
 	case ProjectLockCorrupted:
 		return "corrupted or malformed project.lock"
+	case ProjectLockOutdated:
+		return "written for an older Dockerfile version"
 	default:
 		return "unknown status"
 	}
 }
 
 func (s ProjectLockStatus) IsValid() bool {
-	return s == ProjectLockValid
+	return s == ProjectLockValid || s == ProjectLockOutdated
+}
+
+// Content of the files describing the configuration of a project.
+type ProjectFilesContent struct {
+	// Content of its `.env` file
+	Env []byte
+	// Content of its `compose.yaml` file
+	Compose []byte
 }
 
 // Create the directory and all files needed for the given project name, with
@@ -161,98 +203,297 @@ func (f *FileStore) CreateProjectFiles(
 		return fmt.Errorf("create base files: %w", err)
 	}
 
-	// For env
+	content, err := RenderProjectFiles(envTplData, composeTplData)
+	if err != nil {
+		return err
+	}
+	return f.writeNewProjectFiles(projectName, content)
+}
 
-	envTplCtnt, err := assets.ReadFile("embeds/env.tmpl")
+// Create the project `targetName`, mounting `targetPath`, with the same
+// configuration than the existing project `sourceName`.
+//
+// Its files are copied as is, including manual edits, only updating what
+// identifies the project: its `PROJECT_ID`, its `PROJECT_PATH` and the names
+// of its image and local volume.
+func (f *FileStore) CloneProjectFiles(sourceName string, targetName string, targetPath string) error {
+	if f.DoesProjectExist(targetName) {
+		return fmt.Errorf("project '%s' already exists", targetName)
+	}
+	if err := f.ensureCreatedBaseFiles(); err != nil {
+		return fmt.Errorf("create base files: %w", err)
+	}
+	content, err := f.ReadProjectFiles(sourceName)
+	if err != nil {
+		return fmt.Errorf("could not read files of project '%s': %w", sourceName, err)
+	}
+	cloned, err := renameProjectFiles(content, sourceName, targetName, targetPath)
 	if err != nil {
-		return fmt.Errorf("read env template: %w", err)
+		return fmt.Errorf("could not clone files of project '%s': %w", sourceName, err)
 	}
+	return f.writeNewProjectFiles(targetName, cloned)
+}
 
-	envTpl, err := template.New("env").Parse(string(envTplCtnt))
+// Rename the project `oldName` to `newName`: its directory is moved and its
+// files are updated to describe the new project, without any other change.
+func (f *FileStore) RenameProjectFiles(oldName string, newName string) error {
+	if f.DoesProjectExist(newName) {
+		return fmt.Errorf("project '%s' already exists", newName)
+	}
+	project, err := f.GetProject(oldName)
+	if err != nil {
+		return err
+	}
+	content, err := f.ReadProjectFiles(oldName)
 	if err != nil {
-		return fmt.Errorf("parse env template: %w", err)
+		return fmt.Errorf("could not read files of project '%s': %w", oldName, err)
+	}
+	renamed, err := renameProjectFiles(content, oldName, newName, project.ProjectPath)
+	if err != nil {
+		return fmt.Errorf("could not rename files of project '%s': %w", oldName, err)
 	}
 
-	var buf bytes.Buffer
-	if err := envTpl.Execute(&buf, envTplData); err != nil {
-		return fmt.Errorf("execute env template: %w", err)
+	if err := os.Rename(f.getProjectDir(oldName), f.getProjectDir(newName)); err != nil {
+		return fmt.Errorf("could not move directory of project '%s': %w", oldName, err)
 	}
+	writeRenamed := func() error { return f.writeProjectFilesContent(newName, renamed) }
+	if IsLegacyProjectFiles(content) {
+		// They have been re-generated: keep their previous version, and
+		// record the version they are now written for
+		writeRenamed = func() error {
+			return f.UpdateProjectFiles(newName, renamed,
+				[]string{f.GetProjectEnvFilePath(newName), f.GetProjectComposeFilePath(newName)})
+		}
+	}
+	if err := writeRenamed(); err != nil {
+		// Put back the project as it was
+		if rErr := os.Rename(f.getProjectDir(newName), f.getProjectDir(oldName)); rErr == nil {
+			f.writeProjectFilesContent(oldName, content)
+		}
+		return err
+	}
+	return nil
+}
 
+// Write the files of a project which does not exist yet.
+func (f *FileStore) writeNewProjectFiles(projectName string, content ProjectFilesContent) error {
 	if err := f.userFS.MkdirAsUser(f.getProjectDir(projectName), 0755); err != nil {
 		return fmt.Errorf("create project directory: %w", err)
 	}
 
-	envBytes := buf.Bytes()
-	if err := f.userFS.WriteFileAsUser(f.GetProjectEnvFilePath(projectName), envBytes, 0644); err != nil {
+	if err := f.writeProjectFilesContent(projectName, content); err != nil {
+		return err
+	}
+
+	if err := f.writeProjectInfo(projectName); err != nil {
+		return fmt.Errorf("impossibility to write 'project.lock' file: %w", err)
+	}
+	return nil
+}
+
+// Write the `.env` and `compose.yaml` files in the directory of the given
+// project.
+func (f *FileStore) writeProjectFilesContent(projectName string, content ProjectFilesContent) error {
+	if err := f.userFS.WriteFileAsUser(f.GetProjectEnvFilePath(projectName), content.Env, 0644); err != nil {
 		return fmt.Errorf("write env file: %w", err)
 	}
+	if err := f.userFS.WriteFileAsUser(f.GetProjectComposeFilePath(projectName), content.Compose, 0644); err != nil {
+		return fmt.Errorf("write compose file: %w", err)
+	}
+	return nil
+}
+
+// Generate the `.env` and `compose.yaml` files of a project from the given
+// configuration, without writing them.
+func RenderProjectFiles(envTplData EnvTemplateData, composeTplData ComposeTemplateData) (ProjectFilesContent, error) {
+	// For env
+
+	envTplCtnt, err := assets.ReadFile("embeds/env.tmpl")
+	if err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("read env template: %w", err)
+	}
+
+	envTpl, err := template.New("env").Parse(string(envTplCtnt))
+	if err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("parse env template: %w", err)
+	}
+
+	var envBuf bytes.Buffer
+	if err := envTpl.Execute(&envBuf, envTplData); err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("execute env template: %w", err)
+	}
 
 	// Now for compose
 
 	composeTplCtnt, err := assets.ReadFile("embeds/compose.tmpl")
 	if err != nil {
-		return fmt.Errorf("read compose template: %w", err)
+		return ProjectFilesContent{}, fmt.Errorf("read compose template: %w", err)
 	}
 
-	composeTpl, err := template.New("compose").Parse(string(composeTplCtnt))
+	composeTpl, err := template.New("compose").Funcs(template.FuncMap{
+		"dockerfileVersion": versions.DockerfileVersion.ToString,
+		"cacheVolume":       config.CacheVolumeName,
+	}).Parse(string(composeTplCtnt))
 	if err != nil {
-		return fmt.Errorf("parse compose template: %w", err)
+		return ProjectFilesContent{}, fmt.Errorf("parse compose template: %w", err)
 	}
 
-	buf.Reset()
-	if err := composeTpl.Execute(&buf, composeTplData); err != nil {
-		return fmt.Errorf("execute compose template: %w", err)
+	var composeBuf bytes.Buffer
+	if err := composeTpl.Execute(&composeBuf, composeTplData); err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("execute compose template: %w", err)
 	}
 
-	composeBytes := buf.Bytes()
-	if err := f.userFS.WriteFileAsUser(f.GetProjectComposeFilePath(projectName), composeBytes, 0644); err != nil {
-		return fmt.Errorf("write compose file: %w", err)
+	return ProjectFilesContent{Env: envBuf.Bytes(), Compose: composeBuf.Bytes()}, nil
+}
+
+// Read the current `.env` and `compose.yaml` files of the given project.
+func (f *FileStore) ReadProjectFiles(projectName string) (ProjectFilesContent, error) {
+	envBytes, err := os.ReadFile(f.GetProjectEnvFilePath(projectName))
+	if err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("read env file: %w", err)
 	}
+	composeBytes, err := os.ReadFile(f.GetProjectComposeFilePath(projectName))
+	if err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("read compose file: %w", err)
+	}
+	return ProjectFilesContent{Env: envBytes, Compose: composeBytes}, nil
+}
 
+// Replace the `.env` and `compose.yaml` files of an existing project, e.g.
+// after updating its configuration, and refresh its `project.lock` file.
+//
+// The current version of the files whose path is in `backedUpFiles` is first
+// copied next to them, with a `.bak` extension.
+func (f *FileStore) UpdateProjectFiles(projectName string, content ProjectFilesContent, backedUpFiles []string) error {
+	if !f.DoesProjectExist(projectName) {
+		return fmt.Errorf("project '%s' does not exist", projectName)
+	}
+	if err := f.ensureCreatedBaseFiles(); err != nil {
+		return fmt.Errorf("create base files: %w", err)
+	}
+	for _, path := range backedUpFiles {
+		current, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("back up '%s': %w", path, err)
+		}
+		if err := f.userFS.WriteFileAsUser(path+".bak", current, 0644); err != nil {
+			return fmt.Errorf("back up '%s': %w", path, err)
+		}
+	}
+	if err := f.writeProjectFilesContent(projectName, content); err != nil {
+		return err
+	}
 	if err := f.writeProjectInfo(projectName); err != nil {
 		return fmt.Errorf("impossibility to write 'project.lock' file: %w", err)
 	}
 	return nil
 }
 
-// Write the base Dockerfile file in the base directory if not already done
-func (f *FileStore) ensureCreatedBaseFiles() error {
-	// Write Dockerfile if needed
-	baseDockerfilePath := filepath.Join(f.baseDataDir, "Dockerfile")
-	_, err := os.Stat(baseDockerfilePath)
-	if os.IsNotExist(err) {
-		dockerfileData, err := assets.ReadFile("embeds/Dockerfile")
-		if err != nil {
-			return err
-		}
+// Returns `true` if the given project files were written by a version of
+// paul-envs which did not label the resources of its projects yet, with
+// version 1.0.0 of the base files.
+func IsLegacyProjectFiles(content ProjectFilesContent) bool {
+	return !bytes.Contains(content.Compose, []byte("paulenv.project:"))
+}
 
-		if err = f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
-			return err
-		}
-		if err = f.userFS.WriteFileAsUser(
-			filepath.Join(f.baseDataDir, "Dockerfile"),
-			dockerfileData, 0644); err != nil {
-			return err
-		}
-	} else if err != nil {
-		return err
+// Returns `true` if the compose file of the given project forwards the
+// `PAULENV_KEEP_ALIVE` variable to its container, which is needed to start it
+// in the background. Those written by older versions do not.
+func (f *FileStore) CanKeepAlive(projectName string) (bool, error) {
+	compose, err := os.ReadFile(f.GetProjectComposeFilePath(projectName))
+	if err != nil {
+		return false, fmt.Errorf("read compose file: %w", err)
 	}
+	return bytes.Contains(compose, []byte("PAULENV_KEEP_ALIVE")), nil
+}
 
-	baseEntryPointPath := filepath.Join(f.baseDataDir, "entrypoint.sh")
-	_, err = os.Stat(baseEntryPointPath)
-	if os.IsNotExist(err) {
-		entrypointData, err := assets.ReadFile("embeds/entrypoint.sh")
+// Re-generate the files of a project written by an older version of paul-envs
+// from the configuration they describe, so they contain everything this
+// version relies on.
+//
+// Manual edits which cannot be expressed as configuration are lost.
+func migrateProjectFiles(content ProjectFilesContent, projectName string) (ProjectFilesContent, error) {
+	cfg, err := parseProjectConfig(content)
+	if err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("could not parse files written by an older version: %w", err)
+	}
+	cfg.ProjectName = projectName
+	return RenderProjectFiles(NewProjectTemplateData(&cfg))
+}
+
+// Update the content of a project's files so they describe the project
+// `newName`, mounting `newPath`, instead of `oldName`.
+//
+// Files written before resources were labeled are re-generated first, as
+// they do not name all of them.
+func renameProjectFiles(content ProjectFilesContent, oldName string, newName string, newPath string) (ProjectFilesContent, error) {
+	if IsLegacyProjectFiles(content) {
+		migrated, err := migrateProjectFiles(content, oldName)
 		if err != nil {
-			return err
+			return ProjectFilesContent{}, err
 		}
+		content = migrated
+	}
+	env, err := replaceLines(content.Env, map[string]string{
+		"PROJECT_ID=":   fmt.Sprintf(`PROJECT_ID="%s"`, utils.EscapeEnvValue(newName)),
+		"PROJECT_PATH=": fmt.Sprintf(`PROJECT_PATH="%s"`, utils.EscapeEnvValue(newPath)),
+	})
+	if err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("in .env file: %w", err)
+	}
+	composeReplacements := map[string]string{
+		fmt.Sprintf(`paulenv.project: "%s"`, oldName):  fmt.Sprintf(`paulenv.project: "%s"`, newName),
+		fmt.Sprintf("image: paulenv:%s", oldName):      fmt.Sprintf("image: paulenv:%s", newName),
+		fmt.Sprintf("name: paulenv-%s-local", oldName): fmt.Sprintf("name: paulenv-%s-local", newName),
+	}
+	values, err := parseEnvFile(bytes.NewReader(content.Env))
+	if err != nil {
+		return ProjectFilesContent{}, err
+	}
+	if values["PACKAGE_CACHE"] == config.CachePrivate {
+		oldCache := config.CacheVolumeName(oldName, config.CachePrivate)
+		composeReplacements["name: "+oldCache] = "name: " + config.CacheVolumeName(newName, config.CachePrivate)
+	}
+	compose, err := replaceLines(content.Compose, composeReplacements)
+	if err != nil {
+		return ProjectFilesContent{}, fmt.Errorf("in compose file: %w", err)
+	}
+	return ProjectFilesContent{Env: env, Compose: compose}, nil
+}
 
-		err = f.userFS.WriteFileAsUser(
-			filepath.Join(f.baseDataDir, "entrypoint.sh"),
-			entrypointData, 0644)
-		if err != nil {
-			return err
+// Replace the lines of `content` which, once indented, are equal to a key of
+// `replacements` by the corresponding value, keeping their indentation. Keys
+// ending with "=" instead match all lines beginning with them.
+//
+// Returns an error if one of those keys has not been found.
+func replaceLines(content []byte, replacements map[string]string) ([]byte, error) {
+	found := make(map[string]bool, len(replacements))
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		for key, replacement := range replacements {
+			matches := strings.TrimRight(trimmed, " \t\r") == key
+			if strings.HasSuffix(key, "=") {
+				matches = strings.HasPrefix(trimmed, key)
+			}
+			if matches {
+				lines[i] = line[:len(line)-len(trimmed)] + replacement
+				found[key] = true
+			}
+		}
+	}
+	for key := range replacements {
+		if !found[key] {
+			return nil, fmt.Errorf("no line '%s' found", key)
 		}
-	} else if err != nil {
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// Write the base Dockerfile and entrypoint files in the base directory if not
+// already done, or replace them if they are outdated and were not modified.
+func (f *FileStore) ensureCreatedBaseFiles() error {
+	if _, err := f.RefreshBaseFiles(); err != nil {
 		return err
 	}
 
@@ -295,9 +536,13 @@ func (filestore *FileStore) ReadProjectInfo(projectName string) (projectLockInfo
 		return projectLockInfo{}, fmt.Errorf("could not open project.lock: %w", err)
 	}
 	defer file.Close()
+	return parseProjectInfo(file)
+}
 
+// Parse the content of a project.lock file.
+func parseProjectInfo(rd io.Reader) (projectLockInfo, error) {
 	var pInfo projectLockInfo
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(rd)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -350,12 +595,19 @@ func (f *FileStore) RefreshBuildInfoFile(projectName string, engineName string,
 		return fmt.Errorf("failed to create 'project.buildinfo' file due to impossibility to read file '%s': %w", composeFilePath, err)
 	}
 	composeHash := utils.BufferHash(composeBytes)
+	baseHashes, err := f.hashBaseFiles()
+	if err != nil {
+		return fmt.Errorf("failed to create 'project.buildinfo' file: %w", err)
+	}
 	now := time.Now()
 	buildInfoBytes, err := formatBuildInfo(buildState{
 		version:                versions.BuildInfoVersion,
 		builtBy:                machineId,
 		buildEnvHash:           envHash,
 		buildComposeHash:       composeHash,
+		buildDockerfileHash:    baseHashes.buildDockerfileHash,
+		buildEntrypointHash:    baseHashes.buildEntrypointHash,
+		buildDotfilesHash:      baseHashes.buildDotfilesHash,
 		builtAt:                now,
 		containerEngine:        engineName,
 		containerEngineVersion: engineVersion,
@@ -410,6 +662,18 @@ func (filestore *FileStore) ReadBuildInfo(projectName string) (*buildState, erro
 			bState.buildComposeHash = v
 			continue
 		}
+		if v, ok := strings.CutPrefix(line, "BUILD_DOCKERFILE="); ok {
+			bState.buildDockerfileHash = v
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "BUILD_ENTRYPOINT="); ok {
+			bState.buildEntrypointHash = v
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "BUILD_DOTFILES="); ok {
+			bState.buildDotfilesHash = v
+			continue
+		}
 		if v, ok := strings.CutPrefix(line, "CONTAINER_ENGINE="); ok {
 			bState.containerEngine = v
 			continue
@@ -460,7 +724,9 @@ func (filestore *FileStore) ReadBuildInfo(projectName string) (*buildState, erro
 	return &bState, nil
 }
 
-func (filestore *FileStore) NeedsRebuild(projectName string, bState *buildState) (bool, RebuildReason, error) {
+// Check whether the given project should be re-built, based on its last build
+// state and on the container engine (e.g. "docker") currently used.
+func (filestore *FileStore) NeedsRebuild(projectName string, bState *buildState, engineName string) (bool, RebuildReason, error) {
 	if bState == nil {
 		return false, RebuildNotNeeded, errors.New("cannot determine if rebuild is needed, no build state")
 	}
@@ -488,7 +754,22 @@ func (filestore *FileStore) NeedsRebuild(projectName string, bState *buildState)
 		return true, RebuildEnvChanged, nil
 	}
 
-	if bState.containerEngine != "docker" {
+	// Those hashes are not known for builds performed by older versions
+	current, err := filestore.hashBaseFiles()
+	if err != nil {
+		return false, RebuildNotNeeded, fmt.Errorf("cannot hash current build files: %w", err)
+	}
+	if bState.buildDockerfileHash != "" && bState.buildDockerfileHash != current.buildDockerfileHash {
+		return true, RebuildDockerfileChanged, nil
+	}
+	if bState.buildEntrypointHash != "" && bState.buildEntrypointHash != current.buildEntrypointHash {
+		return true, RebuildEntrypointChanged, nil
+	}
+	if bState.buildDotfilesHash != "" && bState.buildDotfilesHash != current.buildDotfilesHash {
+		return true, RebuildDotfilesChanged, nil
+	}
+
+	if bState.containerEngine != engineName {
 		return true, RebuildDifferentEngine, nil
 	}
 
@@ -525,10 +806,35 @@ func (filestore *FileStore) ValidateProjectLock(projectName string) (ProjectLock
 			versions.DockerfileVersion.ToString(),
 		)
 	}
+	if pInfo.dockerfileVersion.IsOlderThan(versions.DockerfileVersion) {
+		return ProjectLockOutdated, nil
+	}
 
 	return ProjectLockValid, nil
 }
 
+// Hash the files shared by all projects' builds: the base Dockerfile, the base
+// `entrypoint.sh` file and the dotfiles directory.
+//
+// Only the corresponding hash fields of the returned buildState are set.
+func (f *FileStore) hashBaseFiles() (buildState, error) {
+	var hashes buildState
+	var err error
+	hashes.buildDockerfileHash, err = utils.FileHash(filepath.Join(f.baseDataDir, "Dockerfile"))
+	if err != nil {
+		return buildState{}, fmt.Errorf("cannot hash the Dockerfile: %w", err)
+	}
+	hashes.buildEntrypointHash, err = utils.FileHash(filepath.Join(f.baseDataDir, "entrypoint.sh"))
+	if err != nil {
+		return buildState{}, fmt.Errorf("cannot hash the entrypoint.sh file: %w", err)
+	}
+	hashes.buildDotfilesHash, err = utils.DirHash(f.getGlobalDotfilesDir())
+	if err != nil {
+		return buildState{}, fmt.Errorf("cannot hash the dotfiles directory: %w", err)
+	}
+	return hashes, nil
+}
+
 // Returns the format of the "project.buildinfo" file which contains information on
 // the last build of a project.
 func formatBuildInfo(bInfo buildState) ([]byte, error) {
@@ -538,6 +844,9 @@ func formatBuildInfo(bInfo buildState) ([]byte, error) {
 			"BUILT_BY=%s\n"+
 			"BUILD_ENV=%s\n"+
 			"BUILD_COMPOSE=%s\n"+
+			"BUILD_DOCKERFILE=%s\n"+
+			"BUILD_ENTRYPOINT=%s\n"+
+			"BUILD_DOTFILES=%s\n"+
 			"LAST_BUILT_AT=%s\n"+
 			"CONTAINER_ENGINE=%s\n"+
 			"CONTAINER_ENGINE_VERSION=%s\n",
@@ -545,6 +854,9 @@ func formatBuildInfo(bInfo buildState) ([]byte, error) {
 		bInfo.builtBy,
 		bInfo.buildEnvHash,
 		bInfo.buildComposeHash,
+		bInfo.buildDockerfileHash,
+		bInfo.buildEntrypointHash,
+		bInfo.buildDotfilesHash,
 		bInfo.builtAt.Format(time.RFC3339),
 		bInfo.containerEngine,
 		bInfo.containerEngineVersion,
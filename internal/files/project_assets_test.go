@@ -118,6 +118,10 @@ func TestFileStore_CreateProjectFiles(t *testing.T) {
 		`./data:/app/data`,
 		`./config:/app/config`,
 		`/home/user/.ssh/id_ed25519.pub:/etc/ssh/authorized_keys/${USERNAME:-dev}:ro`,
+		`paulenv.project: "testproject"`,
+		`name: paulenv-shared-cache`,
+		`paulenv.project-id: "${PROJECT_ID}"`,
+		`paulenv.version: "` + versions.DockerfileVersion.ToString() + `"`,
 	}
 
 	for _, check := range composeChecks {
@@ -223,3 +227,164 @@ func TestFileStore_CreateProjectComposeFiles(t *testing.T) {
 		t.Error("compose file should not contain SSH key mount when disabled")
 	}
 }
+
+func TestRenameProjectFiles(t *testing.T) {
+	content := ProjectFilesContent{
+		Env: []byte("PROJECT_ID=\"old\"\nPROJECT_DIRNAME=\"old\"\nPROJECT_PATH=\"/src/old\"\n"),
+		Compose: []byte("services:\n  paulenv:\n    image: paulenv:old\n    labels:\n" +
+			"      paulenv.project: \"old\"\nvolumes:\n  local:\n    name: paulenv-old-local\n"),
+	}
+	renamed, err := renameProjectFiles(content, "old", "new", `/src/a "b"`)
+	if err != nil {
+		t.Fatalf("renameProjectFiles() error = %v", err)
+	}
+	expectedEnv := "PROJECT_ID=\"new\"\nPROJECT_DIRNAME=\"old\"\nPROJECT_PATH=\"/src/a \\\"b\\\"\"\n"
+	if string(renamed.Env) != expectedEnv {
+		t.Errorf("unexpected env file:\n%s", renamed.Env)
+	}
+	expectedCompose := "services:\n  paulenv:\n    image: paulenv:new\n    labels:\n" +
+		"      paulenv.project: \"new\"\nvolumes:\n  local:\n    name: paulenv-new-local\n"
+	if string(renamed.Compose) != expectedCompose {
+		t.Errorf("unexpected compose file:\n%s", renamed.Compose)
+	}
+
+	// A private cache is named after the project
+	content.Env = append(content.Env, "PACKAGE_CACHE=\"private\"\n"...)
+	content.Compose = append(content.Compose, "  shared-cache:\n    name: paulenv-private-old-cache\n"...)
+	if renamed, err = renameProjectFiles(content, "old", "new", "/src/new"); err != nil {
+		t.Fatalf("renameProjectFiles() error = %v", err)
+	}
+	if !strings.Contains(string(renamed.Compose), "name: paulenv-private-new-cache\n") {
+		t.Errorf("the private cache volume should have been renamed:\n%s", renamed.Compose)
+	}
+
+	content.Compose = []byte("services:\n  paulenv:\n    image: custom\n    labels:\n      paulenv.project: \"old\"\n")
+	if _, err := renameProjectFiles(content, "old", "new", "/src/new"); err == nil {
+		t.Error("expected an error when the compose file does not name the project's image")
+	}
+}
+
+func TestRenameProjectFiles_Legacy(t *testing.T) {
+	// Files written by version 1.0.0, before resources were labeled
+	var content ProjectFilesContent
+	var err error
+	if content.Env, err = os.ReadFile(filepath.Join("testdata", "legacy-1.0.0", ".env")); err != nil {
+		t.Fatal(err)
+	}
+	if content.Compose, err = os.ReadFile(filepath.Join("testdata", "legacy-1.0.0", "compose.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if !IsLegacyProjectFiles(content) {
+		t.Fatal("files of version 1.0.0 should be recognized as legacy ones")
+	}
+
+	renamed, err := renameProjectFiles(content, "legacy", "renamed", "/src/renamed")
+	if err != nil {
+		t.Fatalf("renameProjectFiles() error = %v", err)
+	}
+	if IsLegacyProjectFiles(renamed) {
+		t.Error("renamed files should have been re-generated with labels")
+	}
+	for _, expected := range []string{
+		`paulenv.project: "renamed"`,
+		"image: paulenv:renamed",
+		"name: paulenv-renamed-local",
+		"PAULENV_KEEP_ALIVE",
+		`- "8080:8080"`,
+		"- /home/me/notes:/home/dev/notes",
+	} {
+		if !strings.Contains(string(renamed.Compose), expected) {
+			t.Errorf("compose file should contain %q:\n%s", expected, renamed.Compose)
+		}
+	}
+	for _, expected := range []string{
+		`PROJECT_ID="renamed"`,
+		`PROJECT_PATH="/src/renamed"`,
+		`USER_SHELL="zsh"`,
+		`SUPPLEMENTARY_PACKAGES="ripgrep"`,
+		`GIT_AUTHOR_NAME="Me"`,
+	} {
+		if !strings.Contains(string(renamed.Env), expected) {
+			t.Errorf(".env file should contain %q:\n%s", expected, renamed.Env)
+		}
+	}
+}
+
+func TestFileStore_NeedsRebuild(t *testing.T) {
+	baseDataDir := t.TempDir()
+	store := &FileStore{
+		userFS: &UserFS{
+			homeDir:  t.TempDir(),
+			sudoUser: nil,
+		},
+		baseDataDir:   baseDataDir,
+		baseConfigDir: t.TempDir(),
+		projectsDir:   filepath.Join(baseDataDir, "projects"),
+	}
+	envTplData := EnvTemplateData{ProjectID: "test-id", ProjectDestPath: "app", ProjectHostPath: "/host/path"}
+	composeTplData := ComposeTemplateData{ProjectName: "app"}
+	if err := store.CreateProjectFiles("app", envTplData, composeTplData); err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+	dotfilesDir, err := store.InitGlobalDotfilesDir()
+	if err != nil {
+		t.Fatalf("InitGlobalDotfilesDir() error = %v", err)
+	}
+
+	checkReason := func(expected RebuildReason) {
+		t.Helper()
+		bState, err := store.ReadBuildInfo("app")
+		if err != nil {
+			t.Fatalf("ReadBuildInfo() error = %v", err)
+		}
+		_, reason, err := store.NeedsRebuild("app", bState, "docker")
+		if err != nil || reason != expected {
+			t.Errorf("expected %q, got %q (err: %v)", expected, reason, err)
+		}
+	}
+	appendTo := func(path string) {
+		t.Helper()
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString("\n# updated\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		path     string
+		expected RebuildReason
+	}{
+		{filepath.Join(baseDataDir, "Dockerfile"), RebuildDockerfileChanged},
+		{filepath.Join(baseDataDir, "entrypoint.sh"), RebuildEntrypointChanged},
+		{filepath.Join(dotfilesDir, ".bashrc"), RebuildDotfilesChanged},
+	} {
+		if err := store.RefreshBuildInfoFile("app", "docker", "1.0"); err != nil {
+			t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+		}
+		checkReason(RebuildNotNeeded)
+		appendTo(tt.path)
+		checkReason(tt.expected)
+	}
+
+	// Build information written by older versions do not know those hashes
+	buildInfoPath := store.getBuildInfoFilePathFor("app")
+	content, err := os.ReadFile(buildInfoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(line, "BUILD_DOCKERFILE=") && !strings.HasPrefix(line, "BUILD_ENTRYPOINT=") &&
+			!strings.HasPrefix(line, "BUILD_DOTFILES=") {
+			lines = append(lines, strings.Replace(line, "VERSION="+versions.BuildInfoVersion.ToString(), "VERSION=1.0.0", 1))
+		}
+	}
+	if err := os.WriteFile(buildInfoPath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	checkReason(RebuildNotNeeded)
+}
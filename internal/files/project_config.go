@@ -0,0 +1,250 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Suffix of the volume entry mounting the SSH public key allowed to connect
+// to the container, in a project's `compose.yaml` file.
+const sshKeyVolumeSuffix = ":/etc/ssh/authorized_keys/${USERNAME:-dev}:ro"
+
+// Obtain the configuration of an existing project, as written in its `.env`
+// and `compose.yaml` files.
+//
+// Parts of those files which do not correspond to any `config.Config` field
+// (comments, supplementary compose keys...) are ignored.
+func (f *FileStore) LoadProjectConfig(projectName string) (config.Config, error) {
+	content, err := f.ReadProjectFiles(projectName)
+	if err != nil {
+		return config.Config{}, fmt.Errorf("could not read files of project '%s': %w", projectName, err)
+	}
+	cfg, err := parseProjectConfig(content)
+	if err != nil {
+		return config.Config{}, fmt.Errorf("could not parse files of project '%s': %w", projectName, err)
+	}
+	cfg.ProjectName = projectName
+	return cfg, nil
+}
+
+// Obtain the `config.Config.Cache` value of an existing project: the
+// `PACKAGE_CACHE` value of its `.env` file.
+//
+// Unlike `LoadProjectConfig`, the rest of its files is not parsed.
+func (f *FileStore) GetProjectCache(projectName string) (string, error) {
+	content, err := os.ReadFile(f.GetProjectEnvFilePath(projectName))
+	if err != nil {
+		return "", fmt.Errorf("could not read .env file of project '%s': %w", projectName, err)
+	}
+	values, err := parseEnvFile(bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("could not parse .env file of project '%s': %w", projectName, err)
+	}
+	if err := config.ValidateCache(values["PACKAGE_CACHE"]); err != nil {
+		return "", fmt.Errorf("invalid PACKAGE_CACHE in .env file of project '%s': %w", projectName, err)
+	}
+	return values["PACKAGE_CACHE"], nil
+}
+
+// Obtain the name of the volume storing the package caches of an existing
+// project, according to the `PACKAGE_CACHE` value of its `.env` file.
+func (f *FileStore) GetProjectCacheVolume(projectName string) (string, error) {
+	cache, err := f.GetProjectCache(projectName)
+	if err != nil {
+		return "", err
+	}
+	return config.CacheVolumeName(projectName, cache), nil
+}
+
+// Convert a project's configuration into the data needed to generate its
+// `.env` and `compose.yaml` files.
+//
+// `LoadProjectConfig` performs the reverse operation.
+func NewProjectTemplateData(cfg *config.Config) (EnvTemplateData, ComposeTemplateData) {
+	envData := EnvTemplateData{
+		ProjectID:       utils.EscapeEnvValue(cfg.ProjectName),
+		ProjectDestPath: utils.EscapeEnvValue(cfg.ProjectDestPath),
+		ProjectHostPath: utils.EscapeEnvValue(cfg.ProjectHostPath),
+		HostUID:         utils.EscapeEnvValue(cfg.UID),
+		HostGID:         utils.EscapeEnvValue(cfg.GID),
+		Username:        utils.EscapeEnvValue(cfg.Username),
+		Shell:           string(cfg.Shell),
+		InstallNode:     utils.EscapeEnvValue(cfg.InstallNode),
+		InstallRust:     utils.EscapeEnvValue(cfg.InstallRust),
+		InstallPython:   utils.EscapeEnvValue(cfg.InstallPython),
+		InstallGo:       utils.EscapeEnvValue(cfg.InstallGo),
+		EnableWasm:      strconv.FormatBool(cfg.EnableWasm),
+		EnableSSH:       strconv.FormatBool(cfg.EnableSsh),
+		EnableSudo:      strconv.FormatBool(cfg.EnableSudo),
+		Packages:        utils.EscapeEnvValue(strings.Join(cfg.Packages, " ")),
+		InstallNeovim:   strconv.FormatBool(cfg.InstallNeovim),
+		InstallStarship: strconv.FormatBool(cfg.InstallStarship),
+		InstallAtuin:    strconv.FormatBool(cfg.InstallAtuin),
+		InstallMise:     strconv.FormatBool(cfg.InstallMise),
+		InstallZellij:   strconv.FormatBool(cfg.InstallZellij),
+		InstallJujutsu:  strconv.FormatBool(cfg.InstallJujutsu),
+		GitName:         utils.EscapeEnvValue(cfg.GitName),
+		GitEmail:        utils.EscapeEnvValue(cfg.GitEmail),
+		Cache:           utils.EscapeEnvValue(cacheOrDefault(cfg.Cache)),
+	}
+
+	composeData := ComposeTemplateData{
+		ProjectName: cfg.ProjectName,
+		Ports:       cfg.Ports,
+		EnableSSH:   cfg.EnableSsh,
+		SSHKeyPath:  cfg.SshKeyPath,
+		Volumes:     cfg.Volumes,
+		Cache:       cfg.Cache,
+	}
+	return envData, composeData
+}
+
+// Parse the configuration of a project from the content of its files.
+func parseProjectConfig(content ProjectFilesContent) (config.Config, error) {
+	values, err := parseEnvFile(bytes.NewReader(content.Env))
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	cfg := config.Config{
+		ProjectName:     values["PROJECT_ID"],
+		ProjectDestPath: values["PROJECT_DIRNAME"],
+		ProjectHostPath: values["PROJECT_PATH"],
+		UID:             values["HOST_UID"],
+		GID:             values["HOST_GID"],
+		Username:        values["USERNAME"],
+		InstallNode:     values["INSTALL_NODE"],
+		InstallRust:     values["INSTALL_RUST"],
+		InstallPython:   values["INSTALL_PYTHON"],
+		InstallGo:       values["INSTALL_GO"],
+		EnableWasm:      values["ENABLE_WASM"] == "true",
+		EnableSsh:       values["ENABLE_SSH"] == "true",
+		EnableSudo:      values["ENABLE_SUDO"] == "true",
+		InstallNeovim:   values["INSTALL_NEOVIM"] == "true",
+		InstallStarship: values["INSTALL_STARSHIP"] == "true",
+		InstallAtuin:    values["INSTALL_ATUIN"] == "true",
+		InstallMise:     values["INSTALL_MISE"] == "true",
+		InstallZellij:   values["INSTALL_ZELLIJ"] == "true",
+		InstallJujutsu:  values["INSTALL_JUJUTSU"] == "true",
+		Packages:        strings.Fields(values["SUPPLEMENTARY_PACKAGES"]),
+		GitName:         values["GIT_AUTHOR_NAME"],
+		GitEmail:        values["GIT_AUTHOR_EMAIL"],
+	}
+	// Projects created before cache groups have no PACKAGE_CACHE
+	if cache := values["PACKAGE_CACHE"]; cache != config.CacheShared {
+		cfg.Cache = cache
+	}
+	if err := cfg.Shell.Set(values["USER_SHELL"]); err != nil {
+		return config.Config{}, fmt.Errorf("invalid USER_SHELL in .env file: %w", err)
+	}
+	if err := config.ValidateCache(cfg.Cache); err != nil {
+		return config.Config{}, fmt.Errorf("invalid PACKAGE_CACHE in .env file: %w", err)
+	}
+
+	portEntries, err := parseComposeList(bytes.NewReader(content.Compose), "ports")
+	if err != nil {
+		return config.Config{}, err
+	}
+	if cfg.EnableSsh && len(portEntries) > 0 && portEntries[len(portEntries)-1] == "22:22" {
+		// Added after all other ports because of ENABLE_SSH
+		portEntries = portEntries[:len(portEntries)-1]
+	}
+	cfg.Ports = []uint16{}
+	for _, entry := range portEntries {
+		hostPort, _, _ := strings.Cut(entry, ":")
+		port, err := strconv.Atoi(hostPort)
+		if err != nil || utils.ValidatePort(port) != nil {
+			return config.Config{}, fmt.Errorf("invalid port '%s' in compose file", entry)
+		}
+		cfg.Ports = append(cfg.Ports, uint16(port))
+	}
+
+	volumeEntries, err := parseComposeList(bytes.NewReader(content.Compose), "volumes")
+	if err != nil {
+		return config.Config{}, err
+	}
+	cfg.Volumes = []string{}
+	for _, entry := range volumeEntries {
+		switch {
+		case strings.HasPrefix(entry, "${PROJECT_PATH}:"),
+			strings.HasPrefix(entry, "shared-cache:"),
+			strings.HasPrefix(entry, "local-state:"):
+			// Always added by paul-envs
+		case cfg.EnableSsh && strings.HasSuffix(entry, sshKeyVolumeSuffix):
+			cfg.SshKeyPath = strings.TrimSuffix(entry, sshKeyVolumeSuffix)
+		default:
+			cfg.Volumes = append(cfg.Volumes, entry)
+		}
+	}
+	return cfg, nil
+}
+
+// Returns `cache`, a `config.Config.Cache` value, or the default one if it is
+// empty.
+func cacheOrDefault(cache string) string {
+	if cache == "" {
+		return config.CacheShared
+	}
+	return cache
+}
+
+// Parse a `.env` file as written by paul-envs, returning its values by
+// variable name.
+//
+// Double-quoted values are unescaped like `utils.UnescapeEnvValue` does.
+// Comments and empty lines are ignored.
+func parseEnvFile(rd io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(rd)
+	lineNb := 0
+	for scanner.Scan() {
+		lineNb++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %d in .env file: no '=' sign", lineNb)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if quoted, ok := strings.CutPrefix(value, `"`); ok {
+			end := closingQuoteIndex(quoted)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid line %d in .env file: unterminated quoted value", lineNb)
+			}
+			value = utils.UnescapeEnvValue(quoted[:end])
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading .env file: %w", err)
+	}
+	return values, nil
+}
+
+// Returns the index of the first double quote not escaped by a backslash in
+// `str`, or -1 if there's none.
+func closingQuoteIndex(str string) int {
+	escaped := false
+	for i, r := range str {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			return i
+		}
+	}
+	return -1
+}
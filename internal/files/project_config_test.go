@@ -0,0 +1,173 @@
+package files
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func TestProjectConfig_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+	}{
+		{
+			name: "defaults",
+			cfg: config.Config{
+				ProjectName:     "myapp",
+				ProjectDestPath: "myapp",
+				ProjectHostPath: "/home/me/myapp",
+				Username:        "dev",
+				Shell:           config.ShellBash,
+				UID:             "1000",
+				GID:             "1000",
+				Ports:           []uint16{},
+				Volumes:         []string{},
+				Packages:        []string{},
+			},
+		},
+		{
+			name: "everything enabled",
+			cfg: config.Config{
+				ProjectName:     "api",
+				ProjectDestPath: "api",
+				ProjectHostPath: `/home/me/my "api" $dir`,
+				Username:        "paul",
+				Shell:           config.ShellZsh,
+				UID:             "1001",
+				GID:             "1002",
+				InstallNode:     "22.0.0",
+				InstallRust:     "latest",
+				InstallPython:   "none",
+				InstallGo:       "1.21.5",
+				EnableWasm:      true,
+				EnableSsh:       true,
+				EnableSudo:      true,
+				InstallNeovim:   true,
+				InstallStarship: true,
+				InstallAtuin:    true,
+				InstallMise:     true,
+				InstallZellij:   true,
+				InstallJujutsu:  true,
+				Ports:           []uint16{3000, 5432},
+				Volumes:         []string{"/home/me/.aws:/home/${USERNAME}/.aws:ro", "/tmp:/tmp"},
+				Packages:        []string{"ripgrep", "fzf"},
+				GitName:         `John "JD" Doe \ Jr`,
+				GitEmail:        "john@example.com",
+				SshKeyPath:      "/home/me/.ssh/id_ed25519.pub",
+				Cache:           config.CachePrivate,
+			},
+		},
+		{
+			name: "ssh without key",
+			cfg: config.Config{
+				ProjectName:     "ssh",
+				ProjectDestPath: "ssh",
+				ProjectHostPath: "/srv/ssh",
+				Username:        "dev",
+				Shell:           config.ShellFish,
+				UID:             "1000",
+				GID:             "1000",
+				EnableSsh:       true,
+				Cache:           "clients",
+				Ports:           []uint16{22},
+				Volumes:         []string{},
+				Packages:        []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := RenderProjectFiles(NewProjectTemplateData(&tt.cfg))
+			if err != nil {
+				t.Fatalf("RenderProjectFiles() error = %v", err)
+			}
+			loaded, err := parseProjectConfig(rendered)
+			if err != nil {
+				t.Fatalf("parseProjectConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(loaded, tt.cfg) {
+				t.Errorf("loaded configuration differs:\n got %+v\nwant %+v", loaded, tt.cfg)
+			}
+			reRendered, err := RenderProjectFiles(NewProjectTemplateData(&loaded))
+			if err != nil {
+				t.Fatalf("RenderProjectFiles() error = %v", err)
+			}
+			if !bytes.Equal(reRendered.Env, rendered.Env) {
+				t.Errorf(".env file not identical after a round trip:\n%s\n---\n%s", reRendered.Env, rendered.Env)
+			}
+			if !bytes.Equal(reRendered.Compose, rendered.Compose) {
+				t.Errorf("compose file not identical after a round trip:\n%s\n---\n%s", reRendered.Compose, rendered.Compose)
+			}
+		})
+	}
+}
+
+func TestLoadProjectConfig(t *testing.T) {
+	store := newSessionsTestStore(t, "myapp")
+	cfg := config.Config{
+		ProjectName:     "myapp",
+		ProjectDestPath: "myapp",
+		ProjectHostPath: "/home/me/myapp",
+		Username:        "dev",
+		Shell:           config.ShellBash,
+		UID:             "1000",
+		GID:             "1000",
+		InstallNode:     "latest",
+		Ports:           []uint16{8080},
+		Volumes:         []string{},
+		Packages:        []string{"jq"},
+	}
+	envData, composeData := NewProjectTemplateData(&cfg)
+	if err := store.CreateProjectFiles("myapp", envData, composeData); err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+
+	loaded, err := store.LoadProjectConfig("myapp")
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("loaded configuration differs:\n got %+v\nwant %+v", loaded, cfg)
+	}
+	project, err := store.GetProject("myapp")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	if project.ProjectPath != "/home/me/myapp" {
+		t.Errorf("unexpected project path %q", project.ProjectPath)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	values, err := parseEnvFile(strings.NewReader(`# comment
+
+QUOTED="a \"b\" \$c \\ d"
+UNQUOTED=value
+  SPACED = "x" # trailing comment
+`))
+	if err != nil {
+		t.Fatalf("parseEnvFile() error = %v", err)
+	}
+	expected := map[string]string{
+		"QUOTED":   `a "b" $c \ d`,
+		"UNQUOTED": "value",
+		"SPACED":   "x",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("parseEnvFile() = %v, want %v", values, expected)
+	}
+
+	_, err = parseEnvFile(strings.NewReader("A=\"b\"\nnot a variable\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error on line 2, got %v", err)
+	}
+	_, err = parseEnvFile(strings.NewReader(`A="unterminated`))
+	if err == nil {
+		t.Error("expected an error for an unterminated value")
+	}
+}
@@ -0,0 +1,295 @@
+// # saved_image.go
+// This file handles saved images: archives written by the `save` command
+// containing the image built for a project and optionally the content of its
+// local volume, so they can be loaded on a machine which cannot build it.
+//
+// Such an archive is an uncompressed tar file with the following entries, in
+// that order:
+//   - `save.info`: description of the archive
+//   - `volume.labels`: labels of the saved volume, if one has been saved
+//   - `image.tar`: the image, as written by the container engine
+//   - `volume.tar`: the content of the volume, if one has been saved
+
+package files
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+const (
+	savedImageInfoFilename   = "save.info"
+	savedImageLabelsFilename = "volume.labels"
+	savedImageImageFilename  = "image.tar"
+	savedImageVolumeFilename = "volume.tar"
+	// Maximum size of the description files of a saved image
+	maxSavedImageInfoSize = 1 << 20
+)
+
+// Description of a saved image.
+type SavedImageInfo struct {
+	// Name of the project the image has been built for
+	ProjectName string
+	// Hash of the project files the image has been built from, as returned
+	// by `GetBuildConfigHash`
+	BuildConfigHash string
+	// Labels of the saved volume, `nil` if no volume has been saved
+	VolumeLabels map[string]string
+	// Dockerfile version the image has been built with
+	dockerfileVersion utils.Version
+}
+
+// Returns the Dockerfile version the saved image has been built with, as
+// "x.y.z".
+func (i *SavedImageInfo) DockerfileVersion() string {
+	return i.dockerfileVersion.ToString()
+}
+
+// Create the `SavedImageInfo` of the given project, describing its current
+// project files.
+func (f *FileStore) NewSavedImageInfo(projectName string) (SavedImageInfo, error) {
+	lockInfo, err := f.ReadProjectInfo(projectName)
+	if err != nil {
+		return SavedImageInfo{}, err
+	}
+	hash, err := f.GetBuildConfigHash(projectName)
+	if err != nil {
+		return SavedImageInfo{}, err
+	}
+	return SavedImageInfo{
+		ProjectName:       projectName,
+		BuildConfigHash:   hash,
+		dockerfileVersion: lockInfo.dockerfileVersion,
+	}, nil
+}
+
+// Returns a hash of the project files of the given project, ignoring the
+// values which have no effect on its image and which may differ from one
+// machine to another.
+//
+// Two projects with the same hash can thus use the same image.
+func (f *FileStore) GetBuildConfigHash(projectName string) (string, error) {
+	content, err := f.ReadProjectFiles(projectName)
+	if err != nil {
+		return "", fmt.Errorf("could not read files of project '%s': %w", projectName, err)
+	}
+	unbound, err := unbindProjectFiles(content)
+	if err != nil {
+		return "", err
+	}
+	return utils.BufferHash(slices.Concat(unbound.Env, []byte{0}, unbound.Compose)), nil
+}
+
+// Write a saved image archive described by `info` to `w`.
+//
+// `imagePath` is the path to the image written by the container engine.
+// `volumePath` is the path to the tar archive of the volume's content, or an
+// empty string if no volume is saved.
+func WriteSavedImage(w io.Writer, info SavedImageInfo, imagePath string, volumePath string) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+	writeEntry := func(name string, size int64, content io.Reader) error {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     size,
+			ModTime:  now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("could not write '%s' to saved image: %w", name, err)
+		}
+		if _, err := io.Copy(tw, content); err != nil {
+			return fmt.Errorf("could not write '%s' to saved image: %w", name, err)
+		}
+		return nil
+	}
+	writeFile := func(name string, filePath string) error {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		stat, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		return writeEntry(name, stat.Size(), file)
+	}
+
+	infoContent := fmt.Appendf(nil,
+		"VERSION=%s\nPROJECT_NAME=%s\nBUILD_CONFIG=%s\nDOCKERFILE_VERSION=%s\n",
+		versions.SavedImageVersion.ToString(),
+		info.ProjectName,
+		info.BuildConfigHash,
+		info.dockerfileVersion.ToString(),
+	)
+	if err := writeEntry(savedImageInfoFilename, int64(len(infoContent)), bytes.NewReader(infoContent)); err != nil {
+		return err
+	}
+	if volumePath != "" {
+		var labels bytes.Buffer
+		for _, key := range slices.Sorted(maps.Keys(info.VolumeLabels)) {
+			fmt.Fprintf(&labels, "%s=%s\n", key, info.VolumeLabels[key])
+		}
+		if err := writeEntry(savedImageLabelsFilename, int64(labels.Len()), &labels); err != nil {
+			return err
+		}
+	}
+	if err := writeFile(savedImageImageFilename, imagePath); err != nil {
+		return err
+	}
+	if volumePath != "" {
+		if err := writeFile(savedImageVolumeFilename, volumePath); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("could not write saved image: %w", err)
+	}
+	return nil
+}
+
+// Sequential reader of a saved image archive.
+type SavedImageReader struct {
+	// Description of the saved image
+	Info SavedImageInfo
+	tr   *tar.Reader
+}
+
+// Read the description of a saved image archive written by
+// `WriteSavedImage`, checking that it is compatible with this version of
+// paul-envs.
+//
+// The returned reader is then positioned on the image, see `Image`.
+func ReadSavedImage(r io.Reader) (*SavedImageReader, error) {
+	tr := tar.NewReader(r)
+	var info SavedImageInfo
+	var infoContent []byte
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("invalid saved image: no image found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a valid saved image: %w", err)
+		}
+		if header.Name == savedImageImageFilename {
+			break
+		}
+		if header.Size > maxSavedImageInfoSize {
+			return nil, fmt.Errorf("invalid saved image: '%s' is too large", header.Name)
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxSavedImageInfoSize))
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s' from saved image: %w", header.Name, err)
+		}
+		switch header.Name {
+		case savedImageInfoFilename:
+			infoContent = content
+		case savedImageLabelsFilename:
+			info.VolumeLabels = parseSavedImageLabels(content)
+		default:
+			return nil, fmt.Errorf("invalid saved image: unexpected entry '%s'", header.Name)
+		}
+	}
+	if infoContent == nil {
+		return nil, errors.New("invalid saved image: missing 'save.info'")
+	}
+	if err := parseSavedImageInfo(infoContent, &info); err != nil {
+		return nil, err
+	}
+	return &SavedImageReader{Info: info, tr: tr}, nil
+}
+
+// Returns a reader of the saved image, as written by the container engine.
+//
+// It has to be read before calling `Volume`.
+func (s *SavedImageReader) Image() io.Reader {
+	return s.tr
+}
+
+// Returns a reader of the tar archive of the saved volume's content, or `nil`
+// if no volume has been saved.
+func (s *SavedImageReader) Volume() (io.Reader, error) {
+	if s.Info.VolumeLabels == nil {
+		return nil, nil
+	}
+	header, err := s.tr.Next()
+	if err == io.EOF {
+		return nil, errors.New("invalid saved image: missing volume")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("not a valid saved image: %w", err)
+	}
+	if header.Name != savedImageVolumeFilename {
+		return nil, fmt.Errorf("invalid saved image: unexpected entry '%s'", header.Name)
+	}
+	return s.tr, nil
+}
+
+// Parse the `save.info` file of a saved image into `info`.
+func parseSavedImageInfo(content []byte, info *SavedImageInfo) error {
+	var version, dockerfileVersion *utils.Version
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if vStr, ok := strings.CutPrefix(line, "VERSION="); ok {
+			v, err := utils.ParseVersion(vStr)
+			if err != nil {
+				return fmt.Errorf("invalid saved image version '%s': %w", vStr, err)
+			}
+			version = &v
+		} else if vStr, ok := strings.CutPrefix(line, "DOCKERFILE_VERSION="); ok {
+			v, err := utils.ParseVersion(vStr)
+			if err != nil {
+				return fmt.Errorf("invalid saved image Dockerfile version '%s': %w", vStr, err)
+			}
+			dockerfileVersion = &v
+		} else if name, ok := strings.CutPrefix(line, "PROJECT_NAME="); ok {
+			info.ProjectName = name
+		} else if hash, ok := strings.CutPrefix(line, "BUILD_CONFIG="); ok {
+			info.BuildConfigHash = hash
+		}
+	}
+	if version == nil || dockerfileVersion == nil {
+		return errors.New("invalid saved image: no VERSION or DOCKERFILE_VERSION")
+	}
+	if !version.IsCompatibleWithBase(versions.SavedImageVersion) {
+		return fmt.Errorf("saved image version %s is incompatible with current version %s",
+			version.ToString(), versions.SavedImageVersion.ToString())
+	}
+	if !dockerfileVersion.IsCompatibleWithBase(versions.DockerfileVersion) {
+		return fmt.Errorf("saved image's Dockerfile version %s is incompatible with current version %s",
+			dockerfileVersion.ToString(), versions.DockerfileVersion.ToString())
+	}
+	info.dockerfileVersion = *dockerfileVersion
+	if err := utils.ValidateProjectName(info.ProjectName); err != nil {
+		return fmt.Errorf("invalid saved image: invalid project name: %w", err)
+	}
+	return nil
+}
+
+// Parse the `volume.labels` file of a saved image.
+func parseSavedImageLabels(content []byte) map[string]string {
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
@@ -0,0 +1,73 @@
+package files
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+)
+
+func TestSavedImageRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "image.tar")
+	volumePath := filepath.Join(dir, "volume.tar")
+	if err := os.WriteFile(imagePath, []byte("image content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(volumePath, []byte("volume content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info := SavedImageInfo{
+		ProjectName:       "myapp",
+		BuildConfigHash:   "abc",
+		VolumeLabels:      map[string]string{"paulenv.project": "myapp", "other": "a=b"},
+		dockerfileVersion: versions.DockerfileVersion,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSavedImage(&buf, info, imagePath, volumePath); err != nil {
+		t.Fatalf("WriteSavedImage() error = %v", err)
+	}
+	saved, err := ReadSavedImage(&buf)
+	if err != nil {
+		t.Fatalf("ReadSavedImage() error = %v", err)
+	}
+	if saved.Info.ProjectName != "myapp" || saved.Info.BuildConfigHash != "abc" || saved.Info.VolumeLabels["other"] != "a=b" {
+		t.Errorf("unexpected info: %+v", saved.Info)
+	}
+	if image, _ := io.ReadAll(saved.Image()); string(image) != "image content" {
+		t.Errorf("unexpected image: %q", image)
+	}
+	volume, err := saved.Volume()
+	if err != nil || volume == nil {
+		t.Fatalf("Volume() = %v, %v", volume, err)
+	}
+	if content, _ := io.ReadAll(volume); string(content) != "volume content" {
+		t.Errorf("unexpected volume: %q", content)
+	}
+
+	// Without a volume
+	buf.Reset()
+	if err := WriteSavedImage(&buf, info, imagePath, ""); err != nil {
+		t.Fatalf("WriteSavedImage() error = %v", err)
+	}
+	if saved, err = ReadSavedImage(&buf); err != nil {
+		t.Fatalf("ReadSavedImage() error = %v", err)
+	}
+	if volume, err := saved.Volume(); volume != nil || err != nil {
+		t.Errorf("expected no volume, got %v, %v", volume, err)
+	}
+
+	// Incompatible Dockerfile version
+	info.dockerfileVersion.Major++
+	buf.Reset()
+	if err := WriteSavedImage(&buf, info, imagePath, ""); err != nil {
+		t.Fatalf("WriteSavedImage() error = %v", err)
+	}
+	if _, err := ReadSavedImage(&buf); err == nil {
+		t.Error("expected an error for an incompatible Dockerfile version")
+	}
+}
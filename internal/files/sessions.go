@@ -0,0 +1,254 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sessionsFilename     = "project.sessions"
+	sessionsLockFilename = "project.sessions.lock"
+)
+
+// Time after which we consider that the lock on a `project.sessions` file has
+// been left by a crashed process, and can be taken anyway.
+const sessionsLockStaleAfter = 30 * time.Second
+
+// Maximum time we wait for the lock on a `project.sessions` file.
+const sessionsLockTimeout = 10 * time.Second
+
+// Returns `true` if a process with the given pid currently exists, see the
+// platform-specific `processExists`. Isolated for tests.
+var isProcessAlive = processExists
+
+// Keep track of the `paul-envs` sessions currently attached to the container
+// of a project, so it can be stopped when the last one exits.
+type ProjectSessions struct {
+	// Id of the container those sessions are attached to. Sessions recorded
+	// for another container are not relevant anymore.
+	ContainerId string
+	// If `true`, the container has explicitly been started in the background
+	// (e.g. through `up`) and should not be stopped when the last session
+	// exits.
+	KeepAlive bool
+	// Sessions currently attached to that container.
+	Sessions []Session
+}
+
+// A single `paul-envs` process attached to a project's container.
+type Session struct {
+	// Pid of the `paul-envs` process on the host.
+	Pid int
+	// When that session started.
+	StartedAt time.Time
+}
+
+// Create the `Session` corresponding to the current process.
+func NewCurrentSession() Session {
+	// Sub-second precision is lost when written
+	return Session{Pid: os.Getpid(), StartedAt: time.Now().Truncate(time.Second)}
+}
+
+// Remove the given session from the recorded ones, if present.
+func (s *ProjectSessions) RemoveSession(session Session) {
+	s.Sessions = slices.DeleteFunc(s.Sessions, func(other Session) bool {
+		return other.Pid == session.Pid && other.StartedAt.Equal(session.StartedAt)
+	})
+}
+
+// Read the sessions recorded for the given project and running in the
+// container whose id is given, let `updateFn` update them, then write back
+// the result.
+//
+// All of this is done while holding a lock on that file, so concurrent
+// `paul-envs` processes see a consistent state.
+//
+// Sessions whose process does not exist anymore (e.g. a crashed client) or
+// which were attached to another container are removed before calling
+// `updateFn`. A container for which nothing was recorded has not been started
+// by a `run` call, and is thus initially considered as kept alive.
+func (f *FileStore) UpdateProjectSessions(projectName string, containerId string, updateFn func(*ProjectSessions) error) error {
+	unlock, err := f.lockProjectSessions(projectName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sessions, err := f.readAliveProjectSessions(projectName, containerId)
+	if err != nil {
+		return err
+	}
+	if err := updateFn(&sessions); err != nil {
+		return err
+	}
+	return f.userFS.WriteFileAsUser(f.getSessionsFilePathFor(projectName), formatProjectSessions(sessions), 0644)
+}
+
+// Obtain the sessions recorded for the given project and attached to the
+// container whose id is given, in the same way than `UpdateProjectSessions`
+// but without updating them.
+func (f *FileStore) GetProjectSessions(projectName string, containerId string) (ProjectSessions, error) {
+	unlock, err := f.lockProjectSessions(projectName)
+	if err != nil {
+		return ProjectSessions{}, err
+	}
+	defer unlock()
+	return f.readAliveProjectSessions(projectName, containerId)
+}
+
+// Forget all sessions recorded for the given project, e.g. because its
+// container has been stopped.
+func (f *FileStore) ClearProjectSessions(projectName string) error {
+	unlock, err := f.lockProjectSessions(projectName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = os.Remove(f.getSessionsFilePathFor(projectName))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove 'project.sessions': %w", err)
+	}
+	return nil
+}
+
+// Take the lock on the `project.sessions` file of the given project and return
+// the function to call to release it.
+func (f *FileStore) lockProjectSessions(projectName string) (func(), error) {
+	if !f.DoesProjectExist(projectName) {
+		return nil, fmt.Errorf("cannot track sessions of project '%s': this project does not exist", projectName)
+	}
+	lockPath := filepath.Join(f.getProjectDir(projectName), sessionsLockFilename)
+	deadline := time.Now().Add(sessionsLockTimeout)
+	for {
+		lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			lockFile.Close()
+			stopRefresh := refreshLockFile(lockPath)
+			return func() {
+				stopRefresh()
+				os.Remove(lockPath)
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("could not lock 'project.sessions': %w", err)
+		}
+		if stat, err := os.Stat(lockPath); err == nil && time.Since(stat.ModTime()) > sessionsLockStaleAfter {
+			// Left by a crashed process
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("could not lock 'project.sessions': '%s' is held by another process", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Regularly update the modification time of the lock file at `lockPath`
+// while it is held, so a long operation performed under that lock (e.g.
+// stopping a container) is not mistaken for a crashed process.
+//
+// Returns the function to call to stop doing so.
+func refreshLockFile(lockPath string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(sessionsLockStaleAfter / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				os.Chtimes(lockPath, now, now)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// Read the sessions recorded for the given project, only keeping those still
+// alive and attached to the container whose id is given.
+//
+// Must be called while holding the lock on that file.
+func (f *FileStore) readAliveProjectSessions(projectName string, containerId string) (ProjectSessions, error) {
+	sessions, err := f.readProjectSessions(projectName)
+	if err != nil {
+		return ProjectSessions{}, err
+	}
+	if sessions.ContainerId != containerId {
+		return ProjectSessions{ContainerId: containerId, KeepAlive: true}, nil
+	}
+	aliveSessions := make([]Session, 0, len(sessions.Sessions))
+	for _, session := range sessions.Sessions {
+		if isProcessAlive(session.Pid) {
+			aliveSessions = append(aliveSessions, session)
+		}
+	}
+	sessions.Sessions = aliveSessions
+	return sessions, nil
+}
+
+// Read the `project.sessions` file of the given project. An absent file means
+// that no session is recorded.
+func (f *FileStore) readProjectSessions(projectName string) (ProjectSessions, error) {
+	file, err := os.Open(f.getSessionsFilePathFor(projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ProjectSessions{}, nil
+		}
+		return ProjectSessions{}, fmt.Errorf("could not open 'project.sessions': %w", err)
+	}
+	defer file.Close()
+
+	var sessions ProjectSessions
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if v, ok := strings.CutPrefix(line, "CONTAINER="); ok {
+			sessions.ContainerId = v
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "KEEP_ALIVE="); ok {
+			sessions.KeepAlive = v == "true"
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "SESSION="); ok {
+			pidStr, startedAtStr, _ := strings.Cut(v, " ")
+			pid, err := strconv.Atoi(pidStr)
+			if err != nil {
+				return ProjectSessions{}, fmt.Errorf("invalid 'project.sessions' SESSION value '%s': %w", v, err)
+			}
+			startedAt, err := time.Parse(time.RFC3339, startedAtStr)
+			if err != nil {
+				return ProjectSessions{}, fmt.Errorf("invalid 'project.sessions' SESSION value '%s': %w", v, err)
+			}
+			sessions.Sessions = append(sessions.Sessions, Session{Pid: pid, StartedAt: startedAt})
+			continue
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ProjectSessions{}, fmt.Errorf("error reading 'project.sessions': %w", err)
+	}
+	return sessions, nil
+}
+
+func formatProjectSessions(sessions ProjectSessions) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CONTAINER=%s\nKEEP_ALIVE=%t\n", sessions.ContainerId, sessions.KeepAlive)
+	for _, session := range sessions.Sessions {
+		fmt.Fprintf(&buf, "SESSION=%d %s\n", session.Pid, session.StartedAt.Format(time.RFC3339))
+	}
+	return buf.Bytes()
+}
+
+// Get path to the 'project.sessions' file associated to a project.
+func (f *FileStore) getSessionsFilePathFor(projectName string) string {
+	return filepath.Join(f.projectsDir, projectName, sessionsFilename)
+}
@@ -0,0 +1,120 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newSessionsTestStore(t *testing.T, projectName string) *FileStore {
+	t.Helper()
+	baseDir := t.TempDir()
+	store := &FileStore{
+		userFS:      &UserFS{homeDir: baseDir},
+		baseDataDir: baseDir,
+		projectsDir: filepath.Join(baseDir, "projects"),
+	}
+	if err := os.MkdirAll(store.getProjectDir(projectName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestUpdateProjectSessions(t *testing.T) {
+	store := newSessionsTestStore(t, "myapp")
+	alivePids := map[int]bool{10: true, 11: true}
+	originalIsProcessAlive := isProcessAlive
+	isProcessAlive = func(pid int) bool { return alivePids[pid] }
+	defer func() { isProcessAlive = originalIsProcessAlive }()
+
+	startedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := store.UpdateProjectSessions("myapp", "abc", func(sessions *ProjectSessions) error {
+		if !sessions.KeepAlive || len(sessions.Sessions) != 0 {
+			t.Errorf("unexpected initial state: %+v", sessions)
+		}
+		sessions.KeepAlive = false
+		sessions.Sessions = append(sessions.Sessions,
+			Session{Pid: 10, StartedAt: startedAt},
+			Session{Pid: 11, StartedAt: startedAt})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateProjectSessions() error = %v", err)
+	}
+
+	// Session 11 crashed
+	delete(alivePids, 11)
+	err = store.UpdateProjectSessions("myapp", "abc", func(sessions *ProjectSessions) error {
+		if sessions.KeepAlive || len(sessions.Sessions) != 1 || sessions.Sessions[0].Pid != 10 {
+			t.Errorf("expected only the live session to be kept, got %+v", sessions)
+		}
+		sessions.RemoveSession(Session{Pid: 10, StartedAt: startedAt})
+		if len(sessions.Sessions) != 0 {
+			t.Errorf("expected the session to be removed, got %+v", sessions.Sessions)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateProjectSessions() error = %v", err)
+	}
+
+	// Another container: previous sessions are not relevant
+	err = store.UpdateProjectSessions("myapp", "abc", func(sessions *ProjectSessions) error {
+		sessions.Sessions = append(sessions.Sessions, Session{Pid: 10, StartedAt: startedAt})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateProjectSessions() error = %v", err)
+	}
+	err = store.UpdateProjectSessions("myapp", "def", func(sessions *ProjectSessions) error {
+		if sessions.ContainerId != "def" || !sessions.KeepAlive || len(sessions.Sessions) != 0 {
+			t.Errorf("expected a reset state for a new container, got %+v", sessions)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateProjectSessions() error = %v", err)
+	}
+}
+
+func TestLockProjectSessions_StaleLock(t *testing.T) {
+	store := newSessionsTestStore(t, "myapp")
+	lockPath := filepath.Join(store.getProjectDir("myapp"), sessionsLockFilename)
+	if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * sessionsLockStaleAfter)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := store.lockProjectSessions("myapp")
+	if err != nil {
+		t.Fatalf("expected a stale lock to be taken over, got %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("lock file should be removed once unlocked")
+	}
+}
+
+func TestClearProjectSessions(t *testing.T) {
+	store := newSessionsTestStore(t, "myapp")
+	if err := store.ClearProjectSessions("myapp"); err != nil {
+		t.Fatalf("ClearProjectSessions() error = %v", err)
+	}
+	err := store.UpdateProjectSessions("myapp", "abc", func(sessions *ProjectSessions) error {
+		sessions.KeepAlive = false
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateProjectSessions() error = %v", err)
+	}
+	if err := store.ClearProjectSessions("myapp"); err != nil {
+		t.Fatalf("ClearProjectSessions() error = %v", err)
+	}
+	if _, err := os.Stat(store.getSessionsFilePathFor("myapp")); !os.IsNotExist(err) {
+		t.Error("sessions file should be removed")
+	}
+}
@@ -0,0 +1,404 @@
+// Package manifest reads the `.paul-envs.toml` file which may be committed at
+// the root of a project, describing the container it needs (languages, tools,
+// packages, ports...), so `create` does not have to be given the same flags by
+// every contributor.
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Name of the manifest file, at the root of a project.
+const Filename = ".paul-envs.toml"
+
+// Configuration read from a manifest. `nil` fields have not been set in it.
+//
+// Example of a manifest:
+//
+//	name = "myapp"
+//	shell = "zsh"
+//	sudo = true
+//	cache = "private"
+//	packages = ["ripgrep", "fzf"]
+//	ports = [3000, 5432]
+//	volumes = ["~/.aws:/home/dev/.aws:ro"]
+//
+//	[languages]
+//	nodejs = "20.10.0"
+//	rust = "latest"
+//	wasm = true
+//
+//	[tools]
+//	mise = true
+//	neovim = true
+type Manifest struct {
+	Name     *string
+	Username *string
+	Shell    *config.Shell
+	Sudo     *bool
+	Ssh      *bool
+	Cache    *string
+
+	Node   *string
+	Rust   *string
+	Python *string
+	Go     *string
+	Wasm   *bool
+
+	Neovim   *bool
+	Starship *bool
+	Atuin    *bool
+	Mise     *bool
+	Zellij   *bool
+	Jujutsu  *bool
+
+	Packages []string
+	Ports    []uint16
+	Volumes  []string
+}
+
+// Read the manifest at the root of the given project directory.
+//
+// Returns `nil` without error if there's no manifest.
+func Load(projectPath string) (*Manifest, error) {
+	path := filepath.Join(projectPath, Filename)
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+	defer file.Close()
+	manifest, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// Parse and validate the content of a manifest.
+//
+// Errors caused by its content are `*ParseError`, which indicate the
+// corresponding line.
+func Parse(rd io.Reader) (*Manifest, error) {
+	entries, err := parseTOML(rd)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	for _, entry := range entries {
+		if err := m.setEntry(entry); err != nil {
+			return nil, newParseError(entry.line, "%s", err)
+		}
+	}
+	return m, nil
+}
+
+func (m *Manifest) setEntry(entry tomlEntry) error {
+	switch entry.table {
+	case "":
+		switch entry.key {
+		case "name":
+			return setString(&m.Name, entry, utils.ValidateProjectName)
+		case "username":
+			return setString(&m.Username, entry, utils.ValidateUsername)
+		case "shell":
+			var value *string
+			if err := setString(&value, entry, nil); err != nil {
+				return err
+			}
+			var shell config.Shell
+			if err := shell.Set(*value); err != nil {
+				return fmt.Errorf("invalid 'shell': must be one of: bash, zsh, fish")
+			}
+			m.Shell = &shell
+			return nil
+		case "sudo":
+			return setBool(&m.Sudo, entry)
+		case "ssh":
+			return setBool(&m.Ssh, entry)
+		case "cache":
+			return setString(&m.Cache, entry, config.ValidateCache)
+		case "packages":
+			return setStrings(&m.Packages, entry, func(pkg string) error {
+				if !utils.IsValidUbuntuPackageName(pkg) {
+					return fmt.Errorf("invalid package name '%s'", pkg)
+				}
+				return nil
+			})
+		case "ports":
+			return m.setPorts(entry)
+		case "volumes":
+			return setStrings(&m.Volumes, entry, nil)
+		}
+	case "languages":
+		switch entry.key {
+		case "nodejs":
+			return setString(&m.Node, entry, utils.ValidateVersionArg)
+		case "rust":
+			return setString(&m.Rust, entry, utils.ValidateVersionArg)
+		case "python":
+			return setString(&m.Python, entry, utils.ValidateVersionArg)
+		case "go":
+			return setString(&m.Go, entry, utils.ValidateVersionArg)
+		case "wasm":
+			return setBool(&m.Wasm, entry)
+		}
+	case "tools":
+		switch entry.key {
+		case "neovim":
+			return setBool(&m.Neovim, entry)
+		case "starship":
+			return setBool(&m.Starship, entry)
+		case "atuin":
+			return setBool(&m.Atuin, entry)
+		case "mise":
+			return setBool(&m.Mise, entry)
+		case "zellij":
+			return setBool(&m.Zellij, entry)
+		case "jujutsu":
+			return setBool(&m.Jujutsu, entry)
+		}
+	default:
+		return fmt.Errorf("unknown table '[%s]', expected '[languages]' or '[tools]'", entry.table)
+	}
+	if entry.table == "" {
+		return fmt.Errorf("unknown key '%s'", entry.key)
+	}
+	return fmt.Errorf("unknown key '%s' in table '[%s]'", entry.key, entry.table)
+}
+
+func (m *Manifest) setPorts(entry tomlEntry) error {
+	values, ok := entry.value.([]any)
+	if !ok {
+		return fmt.Errorf("'%s' should be an array of ports", entry.key)
+	}
+	m.Ports = make([]uint16, 0, len(values))
+	for _, value := range values {
+		port, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("'%s' should be an array of ports, got %v", entry.key, value)
+		}
+		if err := utils.ValidatePort(int(port)); err != nil {
+			return fmt.Errorf("invalid port %d: %w", port, err)
+		}
+		m.Ports = append(m.Ports, uint16(port))
+	}
+	return nil
+}
+
+func setString(field **string, entry tomlEntry, validate func(string) error) error {
+	value, ok := entry.value.(string)
+	if !ok {
+		return fmt.Errorf("'%s' should be a string", entry.key)
+	}
+	if validate != nil {
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid '%s': %w", entry.key, err)
+		}
+	}
+	*field = &value
+	return nil
+}
+
+func setBool(field **bool, entry tomlEntry) error {
+	value, ok := entry.value.(bool)
+	if !ok {
+		return fmt.Errorf("'%s' should be either true or false", entry.key)
+	}
+	*field = &value
+	return nil
+}
+
+func setStrings(field *[]string, entry tomlEntry, validate func(string) error) error {
+	values, ok := entry.value.([]any)
+	if !ok {
+		return fmt.Errorf("'%s' should be an array of strings", entry.key)
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("'%s' should be an array of strings, got %v", entry.key, value)
+		}
+		if validate != nil {
+			if err := validate(str); err != nil {
+				return err
+			}
+		}
+		result = append(result, str)
+	}
+	*field = result
+	return nil
+}
+
+// Apply the values set in this manifest to `cfg`.
+//
+// The project name is not applied, as it also depends on the project's path.
+func (m *Manifest) Apply(cfg *config.Config) {
+	applyValue(&cfg.Username, m.Username)
+	applyValue(&cfg.Shell, m.Shell)
+	applyValue(&cfg.EnableSudo, m.Sudo)
+	applyValue(&cfg.EnableSsh, m.Ssh)
+	if m.Cache != nil {
+		cfg.Cache = *m.Cache
+		if cfg.Cache == config.CacheShared {
+			cfg.Cache = ""
+		}
+	}
+	applyValue(&cfg.InstallNode, m.Node)
+	applyValue(&cfg.InstallRust, m.Rust)
+	applyValue(&cfg.InstallPython, m.Python)
+	applyValue(&cfg.InstallGo, m.Go)
+	applyValue(&cfg.EnableWasm, m.Wasm)
+	applyValue(&cfg.InstallNeovim, m.Neovim)
+	applyValue(&cfg.InstallStarship, m.Starship)
+	applyValue(&cfg.InstallAtuin, m.Atuin)
+	applyValue(&cfg.InstallMise, m.Mise)
+	applyValue(&cfg.InstallZellij, m.Zellij)
+	applyValue(&cfg.InstallJujutsu, m.Jujutsu)
+	if m.Packages != nil {
+		cfg.Packages = m.Packages
+	}
+	if m.Ports != nil {
+		cfg.Ports = m.Ports
+	}
+	if m.Volumes != nil {
+		cfg.Volumes = m.Volumes
+	}
+}
+
+// Apply the values set in this manifest to `cfg`, like `Apply` does, except
+// that its packages, ports and volumes are added to the ones already in `cfg`
+// instead of replacing them.
+//
+// This allows to layer several manifests, e.g. presets, on top of each other.
+func (m *Manifest) Merge(cfg *config.Config) {
+	packages, ports, volumes := cfg.Packages, cfg.Ports, cfg.Volumes
+	m.Apply(cfg)
+	cfg.Packages = appendMissing(packages, m.Packages)
+	cfg.Ports = appendMissing(ports, m.Ports)
+	cfg.Volumes = appendMissing(volumes, m.Volumes)
+}
+
+// Create a manifest describing the given configuration.
+//
+// Only what is enabled is part of it: options set to `false`, languages not
+// installed and empty lists are left unset, so the resulting manifest can be
+// layered with others through `Merge` without disabling what they enable.
+// The project name and what is specific to the host (UID, GID, git identity,
+// SSH key) are also left unset.
+func FromConfig(cfg *config.Config) *Manifest {
+	m := &Manifest{}
+	if cfg.Username != "" {
+		m.Username = &cfg.Username
+	}
+	if cfg.Shell != "" {
+		m.Shell = &cfg.Shell
+	}
+	m.Sudo = enabledValue(cfg.EnableSudo)
+	m.Ssh = enabledValue(cfg.EnableSsh)
+	if cfg.Cache != "" {
+		m.Cache = &cfg.Cache
+	}
+	m.Node = installedVersion(cfg.InstallNode)
+	m.Rust = installedVersion(cfg.InstallRust)
+	m.Python = installedVersion(cfg.InstallPython)
+	m.Go = installedVersion(cfg.InstallGo)
+	m.Wasm = enabledValue(cfg.EnableWasm)
+	m.Neovim = enabledValue(cfg.InstallNeovim)
+	m.Starship = enabledValue(cfg.InstallStarship)
+	m.Atuin = enabledValue(cfg.InstallAtuin)
+	m.Mise = enabledValue(cfg.InstallMise)
+	m.Zellij = enabledValue(cfg.InstallZellij)
+	m.Jujutsu = enabledValue(cfg.InstallJujutsu)
+	if len(cfg.Packages) > 0 {
+		m.Packages = slices.Clone(cfg.Packages)
+	}
+	if len(cfg.Ports) > 0 {
+		m.Ports = slices.Clone(cfg.Ports)
+	}
+	if len(cfg.Volumes) > 0 {
+		m.Volumes = slices.Clone(cfg.Volumes)
+	}
+	return m
+}
+
+// Write this manifest in the TOML format read by `Parse`.
+func (m *Manifest) Encode() []byte {
+	var enc tomlEncoder
+	enc.writeString("name", m.Name)
+	enc.writeString("username", m.Username)
+	if m.Shell != nil {
+		shell := string(*m.Shell)
+		enc.writeString("shell", &shell)
+	}
+	enc.writeBool("sudo", m.Sudo)
+	enc.writeBool("ssh", m.Ssh)
+	enc.writeString("cache", m.Cache)
+	enc.writeStrings("packages", m.Packages)
+	if m.Ports != nil {
+		ports := make([]int64, 0, len(m.Ports))
+		for _, port := range m.Ports {
+			ports = append(ports, int64(port))
+		}
+		enc.writeInts("ports", ports)
+	}
+	enc.writeStrings("volumes", m.Volumes)
+
+	enc.startTable("languages", m.Node != nil || m.Rust != nil || m.Python != nil || m.Go != nil || m.Wasm != nil)
+	enc.writeString("nodejs", m.Node)
+	enc.writeString("rust", m.Rust)
+	enc.writeString("python", m.Python)
+	enc.writeString("go", m.Go)
+	enc.writeBool("wasm", m.Wasm)
+
+	enc.startTable("tools", m.Neovim != nil || m.Starship != nil || m.Atuin != nil ||
+		m.Mise != nil || m.Zellij != nil || m.Jujutsu != nil)
+	enc.writeBool("neovim", m.Neovim)
+	enc.writeBool("starship", m.Starship)
+	enc.writeBool("atuin", m.Atuin)
+	enc.writeBool("mise", m.Mise)
+	enc.writeBool("zellij", m.Zellij)
+	enc.writeBool("jujutsu", m.Jujutsu)
+	return enc.buf.Bytes()
+}
+
+func enabledValue(enabled bool) *bool {
+	if !enabled {
+		return nil
+	}
+	return &enabled
+}
+
+func installedVersion(version string) *string {
+	if version == "" || version == config.VersionNone {
+		return nil
+	}
+	return &version
+}
+
+// Append to `values` the elements of `added` it does not contain yet.
+func appendMissing[T comparable](values []T, added []T) []T {
+	for _, value := range added {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func applyValue[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func TestParse(t *testing.T) {
+	m, err := Parse(strings.NewReader(`# Manifest of my project
+name = "myapp"
+shell = 'zsh' # trailing comment
+sudo = true
+cache = "clients"
+packages = [
+  "ripgrep", # search
+  "fzf",
+]
+ports = [3000, 5_432]
+volumes = ["~/.aws:/home/dev/.aws:ro"]
+
+[languages]
+nodejs = "20.10.0"
+rust = "latest"
+wasm = false
+
+[tools]
+mise = true
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if *m.Name != "myapp" || *m.Shell != config.ShellZsh || !*m.Sudo || m.Ssh != nil || *m.Cache != "clients" {
+		t.Errorf("unexpected root values: %+v", m)
+	}
+	if !slices.Equal(m.Packages, []string{"ripgrep", "fzf"}) || !slices.Equal(m.Ports, []uint16{3000, 5432}) {
+		t.Errorf("unexpected lists: %v %v", m.Packages, m.Ports)
+	}
+	if *m.Node != "20.10.0" || *m.Rust != "latest" || m.Python != nil || *m.Wasm {
+		t.Errorf("unexpected languages: %+v", m)
+	}
+	if !*m.Mise || m.Neovim != nil {
+		t.Errorf("unexpected tools: %+v", m)
+	}
+
+	cfg := config.Config{Shell: config.ShellBash, InstallNeovim: true, Ports: []uint16{}}
+	m.Apply(&cfg)
+	if cfg.Shell != config.ShellZsh || cfg.InstallNode != "20.10.0" || !cfg.InstallMise || !cfg.InstallNeovim {
+		t.Errorf("manifest not applied as expected: %+v", cfg)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		line    int
+		message string
+	}{
+		{"name = \"ok\"\nshell = \"tcsh\"", 2, "shell"},
+		{"\n\n[languages]\nnodejs = \"v20\"", 4, "nodejs"},
+		{"ports = [3000, 70000]", 1, "invalid port"},
+		{"packages = [\"ok\",\n  \"Not Valid\"]", 1, "invalid package name"},
+		{"sudo = \"yes\"", 1, "true or false"},
+		{"unknown = 1", 1, "unknown key"},
+		{"[tools]\nemacs = true", 2, "unknown key 'emacs'"},
+		{"[other]\nkey = 1", 2, "unknown table"},
+		{"name = \"a\"\nname = \"b\"", 2, "more than once"},
+		{"name = \"unterminated", 1, "unterminated"},
+		{"ports = [3000,\n5432", 1, "unterminated array"},
+		{"just a line", 1, "key = value"},
+		{"username = \"Invalid User\"", 1, "username"},
+		{"cache = \"Not Valid\"", 1, "cache"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input))
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Parse(%q): expected a *ParseError, got %v", tt.input, err)
+			continue
+		}
+		if parseErr.Line != tt.line || !strings.Contains(parseErr.Error(), tt.message) {
+			t.Errorf("Parse(%q) error = %q, want line %d containing %q", tt.input, parseErr, tt.line, tt.message)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(dir)
+	if err != nil || m != nil {
+		t.Fatalf("Load() without manifest = %v, %v", m, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, Filename), []byte("[tools]\nneovim = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err = Load(dir)
+	if err != nil || m == nil || !*m.Neovim {
+		t.Fatalf("Load() = %+v, %v", m, err)
+	}
+}
+
+func TestFromConfig_EncodeRoundTrip(t *testing.T) {
+	cfg := config.Config{
+		Username:      "dev",
+		Shell:         config.ShellFish,
+		InstallNode:   "latest",
+		InstallRust:   config.VersionNone,
+		EnableWasm:    true,
+		InstallMise:   true,
+		Cache:         config.CachePrivate,
+		Packages:      []string{"ripgrep"},
+		Ports:         []uint16{3000, 8080},
+		Volumes:       []string{`/path with "quotes":/data:ro`},
+		GitName:       "John Doe",
+		EnableSudo:    false,
+		InstallNeovim: false,
+	}
+	m, err := Parse(bytes.NewReader(FromConfig(&cfg).Encode()))
+	if err != nil {
+		t.Fatalf("Parse() of an encoded manifest error = %v", err)
+	}
+	if m.Rust != nil || m.Sudo != nil || m.Neovim != nil || m.Name != nil {
+		t.Errorf("disabled options should not be part of the manifest: %+v", m)
+	}
+
+	var loaded config.Config
+	m.Apply(&loaded)
+	if loaded.Shell != cfg.Shell || loaded.InstallNode != "latest" || !loaded.EnableWasm || !loaded.InstallMise || loaded.Cache != cfg.Cache ||
+		!slices.Equal(loaded.Ports, cfg.Ports) || !slices.Equal(loaded.Volumes, cfg.Volumes) {
+		t.Errorf("unexpected configuration after a round trip: %+v", loaded)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	frontend, err := Parse(strings.NewReader("packages = [\"jq\"]\n[languages]\nnodejs = \"latest\"\n[tools]\nneovim = true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rustWasm, err := Parse(strings.NewReader("packages = [\"jq\", \"binaryen\"]\n[languages]\nrust = \"latest\"\nwasm = true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg config.Config
+	frontend.Merge(&cfg)
+	rustWasm.Merge(&cfg)
+	if cfg.InstallNode != "latest" || cfg.InstallRust != "latest" || !cfg.EnableWasm || !cfg.InstallNeovim {
+		t.Errorf("both manifests should be applied, got %+v", cfg)
+	}
+	if !slices.Equal(cfg.Packages, []string{"jq", "binaryen"}) {
+		t.Errorf("packages should be merged, got %v", cfg.Packages)
+	}
+}
@@ -0,0 +1,392 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parsing of the subset of TOML used by manifests.
+//
+// Only what a manifest needs is supported:
+//   - comments, starting with `#`
+//   - `[table]` headers, without nesting
+//   - `key = value` pairs, with bare keys
+//   - basic ("...") and literal ('...') strings, integers, booleans
+//   - arrays of those values, which may span multiple lines
+//
+// Anything else (dotted keys, inline tables, dates, floats, multi-line
+// strings...) is reported as an error.
+
+// A `key = value` pair read from a TOML document.
+type tomlEntry struct {
+	// Name of the table it is in, empty for the root table
+	table string
+	key   string
+	// Either a `string`, an `int64`, a `bool` or a `[]any` of those
+	value any
+	// Line at which that entry begins, starting at 1
+	line int
+}
+
+// Error in a manifest, tied to a line.
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+func newParseError(line int, format string, args ...any) *ParseError {
+	return &ParseError{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// Parse a TOML document into its entries, in order.
+func parseTOML(rd io.Reader) ([]tomlEntry, error) {
+	var entries []tomlEntry
+	seen := make(map[string]bool)
+	seenTables := make(map[string]bool)
+	currentTable := ""
+	scanner := bufio.NewScanner(rd)
+	lineNb := 0
+	for scanner.Scan() {
+		lineNb++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			header := stripComment(line)
+			if !strings.HasSuffix(header, "]") || strings.HasPrefix(header, "[[") {
+				return nil, newParseError(lineNb, "invalid table header '%s'", line)
+			}
+			name := strings.TrimSpace(header[1 : len(header)-1])
+			if !isBareKey(name) {
+				return nil, newParseError(lineNb, "invalid table name '%s'", name)
+			}
+			if seenTables[name] {
+				return nil, newParseError(lineNb, "table '%s' defined more than once", name)
+			}
+			seenTables[name] = true
+			currentTable = name
+			continue
+		}
+
+		key, rawValue, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, newParseError(lineNb, "expected 'key = value', got '%s'", line)
+		}
+		key = strings.TrimSpace(key)
+		if !isBareKey(key) {
+			return nil, newParseError(lineNb, "invalid key '%s'", key)
+		}
+		fullKey := currentTable + "." + key
+		if seen[fullKey] {
+			return nil, newParseError(lineNb, "key '%s' defined more than once", key)
+		}
+		seen[fullKey] = true
+
+		// Arrays may span multiple lines: read until their closing bracket
+		entryLine := lineNb
+		rawValue = strings.TrimSpace(rawValue)
+		for strings.HasPrefix(rawValue, "[") && !isArrayComplete(rawValue) {
+			if !scanner.Scan() {
+				return nil, newParseError(entryLine, "unterminated array for key '%s'", key)
+			}
+			lineNb++
+			rawValue += "\n" + scanner.Text()
+		}
+
+		value, rest, err := parseTOMLValue(rawValue)
+		if err != nil {
+			return nil, newParseError(entryLine, "invalid value for key '%s': %s", key, err)
+		}
+		if rest = stripComment(rest); rest != "" {
+			return nil, newParseError(entryLine, "unexpected content after the value of key '%s': '%s'", key, rest)
+		}
+		entries = append(entries, tomlEntry{table: currentTable, key: key, value: value, line: entryLine})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Parse the value at the start of `str`, returning it and what follows it.
+func parseTOMLValue(str string) (any, string, error) {
+	str = strings.TrimLeft(str, " \t")
+	switch {
+	case str == "":
+		return nil, "", fmt.Errorf("missing value")
+	case str[0] == '"':
+		return parseBasicString(str)
+	case str[0] == '\'':
+		end := strings.IndexAny(str[1:], "'\n")
+		if end < 0 || str[1+end] != '\'' {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		return str[1 : 1+end], str[2+end:], nil
+	case str[0] == '[':
+		return parseArray(str)
+	}
+
+	end := strings.IndexAny(str, " \t\n,]#")
+	if end < 0 {
+		end = len(str)
+	}
+	token, rest := str[:end], str[end:]
+	switch token {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	num, err := strconv.ParseInt(strings.ReplaceAll(token, "_", ""), 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported value '%s'", token)
+	}
+	return num, rest, nil
+}
+
+// Parse the double-quoted string at the start of `str`, returning it
+// unescaped and what follows it.
+func parseBasicString(str string) (string, string, error) {
+	var sb strings.Builder
+	for i := 1; i < len(str); i++ {
+		c := str[i]
+		switch c {
+		case '"':
+			return sb.String(), str[i+1:], nil
+		case '\n':
+			return "", "", fmt.Errorf("unterminated string")
+		case '\\':
+			if i+1 >= len(str) {
+				return "", "", fmt.Errorf("unterminated string")
+			}
+			i++
+			switch str[i] {
+			case '"':
+				sb.WriteByte('"')
+			case '\\':
+				sb.WriteByte('\\')
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'u':
+				if i+4 >= len(str) {
+					return "", "", fmt.Errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(str[i+1:i+5], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(code)) {
+					return "", "", fmt.Errorf("invalid unicode escape")
+				}
+				sb.WriteRune(rune(code))
+				i += 4
+			default:
+				return "", "", fmt.Errorf("unsupported escape sequence '\\%c'", str[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+// Parse the array at the start of `str`, returning it and what follows it.
+func parseArray(str string) ([]any, string, error) {
+	values := []any{}
+	rest := str[1:]
+	for {
+		rest = skipArrayBlanks(rest)
+		if rest == "" {
+			return nil, "", fmt.Errorf("unterminated array")
+		}
+		if rest[0] == ']' {
+			return values, rest[1:], nil
+		}
+		value, after, err := parseTOMLValue(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, isArray := value.([]any); isArray {
+			return nil, "", fmt.Errorf("nested arrays are not supported")
+		}
+		values = append(values, value)
+		rest = skipArrayBlanks(after)
+		if rest == "" {
+			return nil, "", fmt.Errorf("unterminated array")
+		}
+		switch rest[0] {
+		case ',':
+			rest = rest[1:]
+		case ']':
+			return values, rest[1:], nil
+		default:
+			return nil, "", fmt.Errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// Skip whitespaces, newlines and comments inside an array.
+func skipArrayBlanks(str string) string {
+	for {
+		str = strings.TrimLeft(str, " \t\r\n")
+		if !strings.HasPrefix(str, "#") {
+			return str
+		}
+		end := strings.IndexByte(str, '\n')
+		if end < 0 {
+			return ""
+		}
+		str = str[end:]
+	}
+}
+
+// Returns `true` if the array beginning `str` is closed in it, ignoring
+// brackets in strings and comments.
+func isArrayComplete(str string) bool {
+	depth := 0
+	inString := byte(0)
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case inString != 0:
+			if c == '\\' && inString == '"' {
+				i++
+			} else if c == inString || c == '\n' {
+				inString = 0
+			}
+		case c == '"' || c == '\'':
+			inString = c
+		case c == '#':
+			for i < len(str) && str[i] != '\n' {
+				i++
+			}
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Remove a trailing comment and surrounding whitespaces from what follows a
+// value.
+func stripComment(str string) string {
+	str = strings.TrimSpace(str)
+	if strings.HasPrefix(str, "#") {
+		return ""
+	}
+	if idx := strings.Index(str, " #"); idx >= 0 && !strings.ContainsAny(str[:idx], `"'`) {
+		return strings.TrimSpace(str[:idx])
+	}
+	return str
+}
+
+func isBareKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Writes a TOML document, in the subset of TOML read by `parseTOML`.
+type tomlEncoder struct {
+	buf bytes.Buffer
+}
+
+// Begin the given table, only if `hasContent` is `true`.
+func (e *tomlEncoder) startTable(name string, hasContent bool) {
+	if !hasContent {
+		return
+	}
+	if e.buf.Len() > 0 {
+		e.buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&e.buf, "[%s]\n", name)
+}
+
+// Write a `key = "value"` line, if `value` is not `nil`.
+func (e *tomlEncoder) writeString(key string, value *string) {
+	if value != nil {
+		fmt.Fprintf(&e.buf, "%s = %s\n", key, quoteTOMLString(*value))
+	}
+}
+
+// Write a `key = true|false` line, if `value` is not `nil`.
+func (e *tomlEncoder) writeBool(key string, value *bool) {
+	if value != nil {
+		fmt.Fprintf(&e.buf, "%s = %t\n", key, *value)
+	}
+}
+
+// Write a `key = ["a", "b"]` line, if `values` is not `nil`.
+func (e *tomlEncoder) writeStrings(key string, values []string) {
+	if values == nil {
+		return
+	}
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, quoteTOMLString(value))
+	}
+	fmt.Fprintf(&e.buf, "%s = [%s]\n", key, strings.Join(quoted, ", "))
+}
+
+// Write a `key = [1, 2]` line, if `values` is not `nil`.
+func (e *tomlEncoder) writeInts(key string, values []int64) {
+	if values == nil {
+		return
+	}
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		formatted = append(formatted, strconv.FormatInt(value, 10))
+	}
+	fmt.Fprintf(&e.buf, "%s = [%s]\n", key, strings.Join(formatted, ", "))
+}
+
+// Format `str` as a TOML basic string, which `parseBasicString` reads back.
+func quoteTOMLString(str string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range str {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
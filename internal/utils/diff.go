@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of unchanged lines displayed around each change by `UnifiedDiff`.
+const diffContextLines = 3
+
+type diffLine struct {
+	// ' ' for a line present in both texts, '-' for a removed line and '+'
+	// for an added one
+	kind byte
+	text string
+	// Number of lines of respectively the old and new texts before this one
+	oldPos int
+	newPos int
+}
+
+// UnifiedDiff returns the line differences between `oldContent` and
+// `newContent`, in the unified format of `diff -u` with `oldName` and
+// `newName` as file names.
+//
+// Returns an empty string if both contents are identical.
+func UnifiedDiff(oldName string, newName string, oldContent []byte, newContent []byte) string {
+	lines := diffLines(splitLines(oldContent), splitLines(newContent))
+	var buf strings.Builder
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		start := max(0, i-diffContextLines)
+		end := i
+		for end < len(lines) {
+			if lines[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].kind == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diffContextLines {
+				end = min(len(lines), end+diffContextLines)
+				break
+			}
+			end = next
+		}
+		writeHunk(&buf, lines[start:end])
+		i = end
+	}
+	return buf.String()
+}
+
+// Write a hunk of a unified diff, with its "@@" header, for the given lines.
+func writeHunk(buf *strings.Builder, lines []diffLine) {
+	oldCount, newCount := 0, 0
+	for _, line := range lines {
+		if line.kind != '+' {
+			oldCount++
+		}
+		if line.kind != '-' {
+			newCount++
+		}
+	}
+	// Like `diff`, an empty range starts at the line before it
+	oldStart, newStart := lines[0].oldPos, lines[0].newPos
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, line := range lines {
+		fmt.Fprintf(buf, "%c%s\n", line.kind, line.text)
+	}
+}
+
+// Compute the changes needed to go from the `old` lines to the `new` ones,
+// through their longest common subsequence.
+func diffLines(old []string, new []string) []diffLine {
+	// common[i][j] is the length of the longest common subsequence of
+	// old[i:] and new[j:]
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]diffLine, 0, len(old)+len(new))
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			lines = append(lines, diffLine{kind: ' ', text: old[i], oldPos: i, newPos: j})
+			i++
+			j++
+		case i < len(old) && (j == len(new) || common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, diffLine{kind: '-', text: old[i], oldPos: i, newPos: j})
+			i++
+		default:
+			lines = append(lines, diffLine{kind: '+', text: new[j], oldPos: i, newPos: j})
+			j++
+		}
+	}
+	return lines
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
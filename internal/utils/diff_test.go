@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := UnifiedDiff("a", "b", []byte("same\n"), []byte("same\n")); diff != "" {
+		t.Errorf("expected no diff for identical contents, got:\n%s", diff)
+	}
+
+	oldLines := []string{}
+	for i := 1; i <= 20; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line %d", i))
+	}
+	newLines := append([]string{}, oldLines...)
+	newLines[1] = "line two"
+	newLines = append(newLines[:15], newLines[16:]...)
+	newLines = append(newLines, "line 21")
+
+	diff := UnifiedDiff("old/file", "new/file",
+		[]byte(strings.Join(oldLines, "\n")+"\n"), []byte(strings.Join(newLines, "\n")+"\n"))
+	expected := `--- old/file
++++ new/file
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++line two
+ line 3
+ line 4
+ line 5
+@@ -13,8 +13,8 @@
+ line 13
+ line 14
+ line 15
+-line 16
+ line 17
+ line 18
+ line 19
+ line 20
++line 21
+`
+	if diff != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+
+	diff = UnifiedDiff("old", "new", nil, []byte("first\n"))
+	if diff != "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+first\n" {
+		t.Errorf("unexpected diff for a new file:\n%s", diff)
+	}
+}
@@ -3,8 +3,12 @@ package utils
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 )
 
 // FileHash calculates the SHA256 hash of a given file and returns it as a hex string
@@ -29,3 +33,57 @@ func BufferHash(data []byte) string {
 	hashString := hex.EncodeToString(hashBytes)
 	return hashString
 }
+
+// DirHash calculates a SHA256 hash of the whole tree of a given directory and
+// returns it as a hex string.
+//
+// The relative path and permissions of each entry are part of it, as well as
+// the content of files and the target of symbolic links, which are not
+// followed. A directory which does not exist has the same hash than an empty
+// one.
+func DirHash(dirPath string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(dirPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dirPath && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if path == dirPath {
+			return nil
+		}
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00", filepath.ToSlash(relPath), info.Mode())
+		switch {
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(hash, "%s\x00", target)
+		case entry.Type().IsRegular():
+			fmt.Fprintf(hash, "%d\x00", info.Size())
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			if _, err := io.Copy(hash, file); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to hash directory '%s': %v", dirPath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
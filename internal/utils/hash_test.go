@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirHash(t *testing.T) {
+	hashOf := func(dir string) string {
+		t.Helper()
+		hash, err := DirHash(dir)
+		if err != nil {
+			t.Fatalf("DirHash() error = %v", err)
+		}
+		return hash
+	}
+	writeFile := func(path string, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	if hashOf(dir) != hashOf(filepath.Join(dir, "missing")) {
+		t.Error("an empty directory should have the same hash than a missing one")
+	}
+
+	writeFile(filepath.Join(dir, ".bashrc"), "alias ll='ls -l'")
+	writeFile(filepath.Join(dir, ".config", "nvim", "init.lua"), "vim.o.number = true")
+	initial := hashOf(dir)
+	other := t.TempDir()
+	writeFile(filepath.Join(other, ".bashrc"), "alias ll='ls -l'")
+	writeFile(filepath.Join(other, ".config", "nvim", "init.lua"), "vim.o.number = true")
+	if hashOf(other) != initial {
+		t.Error("identical trees should have the same hash")
+	}
+
+	writeFile(filepath.Join(dir, ".config", "nvim", "init.lua"), "vim.o.number = false")
+	if hashOf(dir) == initial {
+		t.Error("the hash should change with the content of a file")
+	}
+	if err := os.Rename(filepath.Join(other, ".bashrc"), filepath.Join(other, ".zshrc")); err != nil {
+		t.Fatal(err)
+	}
+	if hashOf(other) == initial {
+		t.Error("the hash should change when a file is renamed")
+	}
+}
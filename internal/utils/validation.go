@@ -206,6 +206,26 @@ func EscapeEnvValue(str string) string {
 	return str
 }
 
+// UnescapeEnvValue reverts what `EscapeEnvValue` does.
+func UnescapeEnvValue(str string) string {
+	var sb strings.Builder
+	escaped := false
+	for _, r := range str {
+		if escaped {
+			sb.WriteRune(r)
+			escaped = false
+		} else if r == '\\' {
+			escaped = true
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	if escaped {
+		sb.WriteRune('\\')
+	}
+	return sb.String()
+}
+
 // IsValidUbuntuPackageName returns true if the name complies with Ubuntu/Debian package rules.
 func IsValidUbuntuPackageName(name string) bool {
 	return pkgNameRe.MatchString(name)
@@ -169,6 +169,15 @@ func TestEscapeEnvValue(t *testing.T) {
 	}
 }
 
+func TestUnescapeEnvValue(t *testing.T) {
+	for _, in := range []string{"abc", `a"b$c\d`, `C:\Users\me`, ""} {
+		got := UnescapeEnvValue(EscapeEnvValue(in))
+		if got != in {
+			t.Errorf("expected %q after round-trip, got %q", in, got)
+		}
+	}
+}
+
 func TestIsValidUbuntuPackageName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -110,3 +110,24 @@ func TestIsCompatibleVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestIsOlderThan(t *testing.T) {
+	tests := []struct {
+		version string
+		other   string
+		want    bool
+	}{
+		{"1.0.0", "1.1.0", true},
+		{"1.1.9", "2.0.0", true},
+		{"1.1.0", "1.1.1", true},
+		{"1.1.0", "1.1.0", false},
+		{"2.0.0", "1.9.9", false},
+	}
+	for _, tt := range tests {
+		v, _ := ParseVersion(tt.version)
+		other, _ := ParseVersion(tt.other)
+		if got := v.IsOlderThan(other); got != tt.want {
+			t.Errorf("%s.IsOlderThan(%s) = %v, want %v", tt.version, tt.other, got, tt.want)
+		}
+	}
+}
@@ -17,7 +17,7 @@ var Version = utils.Version{
 // vice-versa.
 var DockerfileVersion = utils.Version{
 	Major: 1,
-	Minor: 0,
+	Minor: 1,
 	Patch: 0,
 }
 
@@ -30,6 +30,36 @@ var ProjectLockVersion = utils.Version{
 
 // Format of the "project.buildinfo" files: Information on the last build performed for a project
 var BuildInfoVersion = utils.Version{
+	Major: 1,
+	Minor: 1,
+	Patch: 0,
+}
+
+// Format of the "base.lock" file: hashes of the base Dockerfile and entrypoint
+// written by paul-envs.
+var BaseLockVersion = utils.Version{
+	Major: 1,
+	Minor: 0,
+	Patch: 0,
+}
+
+// Format of the bundles written by the `export` command.
+var BundleVersion = utils.Version{
+	Major: 1,
+	Minor: 0,
+	Patch: 0,
+}
+
+// Format of the archives written by the `save` command.
+var SavedImageVersion = utils.Version{
+	Major: 1,
+	Minor: 0,
+	Patch: 0,
+}
+
+// Format of the machine-readable documents output by commands when the
+// `--output` flag is set to a structured format (e.g. JSON).
+var OutputSchemaVersion = utils.Version{
 	Major: 1,
 	Minor: 0,
 	Patch: 0,
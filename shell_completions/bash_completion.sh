@@ -6,10 +6,13 @@ _paulenvs()
     prev="${COMP_WORDS[COMP_CWORD-1]}"
 
     # Main commands
-    local commands="create list build run remove version interactive help clean"
+    local commands="create edit list status build run exec up down remove stop kill version interactive help clean config preset clone rename export import save load backup restore cache upgrade-base"
 
     # Options for create command
-    local create_flags="--name --uid --gid --username --shell --nodejs --rust --python --go --git-name --git-email --package --enable-ssh --enable-sudo --neovim --starship --atuin --mise --zellij --jujutsu --port --volume"
+    local create_flags="--name --no-manifest --preset --uid --gid --username --shell --nodejs --rust --python --go --git-name --git-email --cache --package --enable-ssh --enable-sudo --neovim --starship --atuin --mise --zellij --jujutsu --port --volume --no-wasm --no-ssh --no-sudo --no-neovim --no-starship --no-atuin --no-mise --no-zellij --no-jujutsu"
+
+    # Options for edit command
+    local edit_flags="--uid --gid --username --shell --nodejs --rust --python --go --git-name --git-email --cache --package --enable-ssh --enable-sudo --neovim --starship --atuin --mise --zellij --jujutsu --port --volume --no-wasm --no-ssh --no-sudo --no-neovim --no-starship --no-atuin --no-mise --no-zellij --no-jujutsu --remove-package --remove-port --remove-volume --force"
 
     # Options for list command
     local list_flags="--names"
@@ -19,6 +22,21 @@ _paulenvs()
         paul-envs list --names 2>/dev/null
     }
 
+    # Get list of saved presets
+    _get_presets() {
+        paul-envs preset list --names 2>/dev/null
+    }
+
+    # Global options
+    if [[ "${prev}" == "--engine" ]]; then
+        COMPREPLY=( $(compgen -W "docker docker-api podman auto" -- ${cur}) )
+        return 0
+    fi
+    if [[ "${prev}" == "--output" ]]; then
+        COMPREPLY=( $(compgen -W "text json yaml" -- ${cur}) )
+        return 0
+    fi
+
     # First argument (command)
     if [[ $COMP_CWORD -eq 1 ]]; then
         COMPREPLY=( $(compgen -W "${commands}" -- ${cur}) )
@@ -48,6 +66,14 @@ _paulenvs()
                     COMPREPLY=( $(compgen -W "bash zsh fish" -- ${cur}) )
                     return 0
                     ;;
+                --cache)
+                    COMPREPLY=( $(compgen -W "shared private" -- ${cur}) )
+                    return 0
+                    ;;
+                --preset)
+                    COMPREPLY=( $(compgen -W "$(_get_presets)" -- ${cur}) )
+                    return 0
+                    ;;
                 --volume)
                     # Complete file paths
                     COMPREPLY=( $(compgen -f -- ${cur}) )
@@ -72,18 +98,182 @@ _paulenvs()
                     ;;
             esac
             ;;
+        edit|update)
+            case "${prev}" in
+                --shell)
+                    COMPREPLY=( $(compgen -W "bash zsh fish" -- ${cur}) )
+                    ;;
+                --cache)
+                    COMPREPLY=( $(compgen -W "shared private" -- ${cur}) )
+                    ;;
+                --volume|--remove-volume)
+                    COMPREPLY=( $(compgen -f -- ${cur}) )
+                    ;;
+                --uid|--gid|--username|--git-name|--git-email|--package|--nodejs|--rust|--python|--go|--port|--remove-package|--remove-port)
+                    COMPREPLY=()
+                    ;;
+                *)
+                    if [[ $COMP_CWORD -eq 2 ]]; then
+                        COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+                    else
+                        COMPREPLY=( $(compgen -W "${edit_flags}" -- ${cur}) )
+                    fi
+                    ;;
+            esac
+            return 0
+            ;;
+        export)
+            if [[ "${prev}" == "-o" ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            elif [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "-o --dotfiles --force" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        import)
+            if [[ "${prev}" == "--ssh-key" ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            elif [[ "${prev}" == "--name" || "${prev}" == "--uid" || "${prev}" == "--gid" || "${prev}" == "--git-name" || "${prev}" == "--git-email" ]]; then
+                COMPREPLY=()
+            elif [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--name --uid --gid --ssh-key --git-name --git-email --dotfiles" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 ]]; then
+                COMPREPLY=( $(compgen -d -- ${cur}) )
+            fi
+            return 0
+            ;;
+        save)
+            if [[ "${prev}" == "-o" ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            elif [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "-o --no-volume --force" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        load)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--force" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            fi
+            return 0
+            ;;
+        backup)
+            if [[ "${prev}" == "-o" ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            elif [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "-o --force" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        restore)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--force" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            fi
+            return 0
+            ;;
+        clone)
+            if [[ "${prev}" == "--name" ]]; then
+                COMPREPLY=()
+            elif [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--name --reuse-image" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 ]]; then
+                COMPREPLY=( $(compgen -d -- ${cur}) )
+            fi
+            return 0
+            ;;
         list)
             # Suggest list flags
             COMPREPLY=( $(compgen -W "${list_flags}" -- ${cur}) )
             return 0
             ;;
-        build|run|remove)
+        status|build|run|up|down|remove|rename)
             # Complete with container names
             if [[ $COMP_CWORD -eq 2 ]]; then
                 COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
             fi
             return 0
             ;;
+        exec)
+            if [[ "${prev}" == "--workdir" || "${prev}" == "--env" || "${prev}" == "--user" ]]; then
+                COMPREPLY=()
+            elif [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--workdir --env --user" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        stop|kill)
+            if [[ "${prev}" == "--timeout" ]]; then
+                COMPREPLY=()
+            elif [[ "${cur}" == -* ]]; then
+                if [[ "${command}" == "stop" ]]; then
+                    COMPREPLY=( $(compgen -W "--all --timeout" -- ${cur}) )
+                else
+                    COMPREPLY=( $(compgen -W "--all" -- ${cur}) )
+                fi
+            elif [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        config)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "get set unset list" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 ]]; then
+                COMPREPLY=( $(compgen -W "engine default.uid default.gid default.username default.shell default.nodejs default.rust default.python default.go default.wasm default.ssh default.ssh-key default.sudo default.git-name default.git-email default.cache default.neovim default.starship default.atuin default.mise default.zellij default.jujutsu default.packages default.ports default.volumes" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        preset)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "save list show remove" -- ${cur}) )
+            elif [[ "${prev}" == "--from" ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 && ( "${COMP_WORDS[2]}" == "show" || "${COMP_WORDS[2]}" == "remove" ) ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_presets)" -- ${cur}) )
+            elif [[ "${COMP_WORDS[2]}" == "save" && "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--from --force" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        cache)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "du prune reset" -- ${cur}) )
+            elif [[ "${prev}" == "--only" ]]; then
+                COMPREPLY=( $(compgen -W "npm yarn pip go xdg" -- ${cur}) )
+            elif [[ "${prev}" == "--project" ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            elif [[ "${prev}" == "--older-than" ]]; then
+                COMPREPLY=()
+            elif [[ "${COMP_WORDS[2]}" == "du" && "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--project" -- ${cur}) )
+            elif [[ "${COMP_WORDS[2]}" == "prune" && "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--project --older-than --only --force" -- ${cur}) )
+            elif [[ "${COMP_WORDS[2]}" == "reset" && "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--project --force" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        upgrade-base)
+            COMPREPLY=( $(compgen -W "--force" -- ${cur}) )
+            return 0
+            ;;
         help|version|clean)
             # No further completion
             return 0
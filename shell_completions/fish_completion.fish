@@ -5,19 +5,49 @@ function __paul_envs_containers
     paul-envs list --names 2>/dev/null
 end
 
+# Helper function to get the names of saved presets
+function __paul_envs_presets
+    paul-envs preset list --names 2>/dev/null
+end
+
 # Main commands
 complete -c paul-envs -f -n __fish_use_subcommand -a interactive -d 'Start interactive mode'
 complete -c paul-envs -f -n __fish_use_subcommand -a create -d 'Create a container configuration'
+complete -c paul-envs -f -n __fish_use_subcommand -a edit -d 'Change the configuration of a project'
 complete -c paul-envs -f -n __fish_use_subcommand -a list -d 'List all available containers'
+complete -c paul-envs -f -n __fish_use_subcommand -a status -d 'Show the current state of projects'
 complete -c paul-envs -f -n __fish_use_subcommand -a build -d 'Build a container'
 complete -c paul-envs -f -n __fish_use_subcommand -a run -d 'Start a container'
+complete -c paul-envs -f -n __fish_use_subcommand -a exec -d 'Run a single command in a container'
+complete -c paul-envs -f -n __fish_use_subcommand -a up -d 'Start a container in the background'
+complete -c paul-envs -f -n __fish_use_subcommand -a down -d 'Stop and remove the container of a project'
 complete -c paul-envs -f -n __fish_use_subcommand -a remove -d 'Remove a container'
+complete -c paul-envs -f -n __fish_use_subcommand -a stop -d 'Stop the running container of a project'
+complete -c paul-envs -f -n __fish_use_subcommand -a kill -d 'Kill the running container of a project'
 complete -c paul-envs -f -n __fish_use_subcommand -a help -d 'Show help'
 complete -c paul-envs -f -n __fish_use_subcommand -a version -d 'Show version'
 complete -c paul-envs -f -n __fish_use_subcommand -a clean -d 'Remove all stored paul-envs data from your computer'
+complete -c paul-envs -f -n __fish_use_subcommand -a config -d 'Read or update the global configuration'
+complete -c paul-envs -f -n __fish_use_subcommand -a preset -d 'Save and manage presets for new projects'
+complete -c paul-envs -f -n __fish_use_subcommand -a clone -d 'Copy the configuration of a project onto another path'
+complete -c paul-envs -f -n __fish_use_subcommand -a rename -d 'Rename a project, with its image and volume'
+complete -c paul-envs -f -n __fish_use_subcommand -a export -d 'Export the configuration of a project as a bundle'
+complete -c paul-envs -f -n __fish_use_subcommand -a import -d 'Create a project from an exported bundle'
+complete -c paul-envs -f -n __fish_use_subcommand -a save -d 'Save the built image and local volume of a project'
+complete -c paul-envs -f -n __fish_use_subcommand -a load -d 'Load an image and volume written by save'
+complete -c paul-envs -f -n __fish_use_subcommand -a backup -d 'Back up the local volume of a project'
+complete -c paul-envs -f -n __fish_use_subcommand -a restore -d 'Restore the local volume of a project from a backup'
+complete -c paul-envs -f -n __fish_use_subcommand -a cache -d 'Inspect, prune or reset a package cache'
+complete -c paul-envs -f -n __fish_use_subcommand -a upgrade-base -d 'Upgrade the base Dockerfile and entrypoint'
+
+# Global options
+complete -c paul-envs -l engine -d 'Container engine to use' -xa 'docker docker-api podman auto'
+complete -c paul-envs -l output -d 'Output format of list, status, version and build' -xa 'text json yaml'
 
 # Create command options
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l name -d "Specific a container name" -x
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-manifest -d "Ignore the project manifest" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l preset -d "Apply a saved preset" -xa '(__paul_envs_presets)'
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l uid -d 'Host UID' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l gid -d 'Host GID' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l username -d 'Container username' -x
@@ -28,6 +58,7 @@ complete -c paul-envs -n "__fish_seen_subcommand_from create" -l python -d 'Pyth
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l go -d 'Go installation' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l git-name -d 'Git author name' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l git-email -d 'Git author email' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l cache -d 'Package cache volume' -xa 'shared private'
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l package -d 'Additional Ubuntu package' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l enable-ssh -d "Enable ssh access" -f
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l enable-sudo -d "Enable sudo access (password: \"dev\")" -f
@@ -39,10 +70,118 @@ complete -c paul-envs -n "__fish_seen_subcommand_from create" -l zellij -d "Inst
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l jujutsu -d "Install Jujutsu" -f
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l port -d 'Expose port' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l volume -d 'Add volume' -r
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-wasm -d "Remove WebAssembly tools" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-ssh -d "Disable ssh access" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-sudo -d "Disable sudo access" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-neovim -d "Do not install Neovim" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-starship -d "Do not install Starship" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-atuin -d "Do not install Atuin" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-mise -d "Do not install Mise" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-zellij -d "Do not install Zellij" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-jujutsu -d "Do not install Jujutsu" -f
+
+# Edit command options
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l uid -d 'Host UID' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l gid -d 'Host GID' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l username -d 'Container username' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l shell -d 'User shell' -xa 'bash zsh fish'
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l nodejs -d 'Node.js installation' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l rust -d 'Rust installation' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l python -d 'Python installation' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l go -d 'Go installation' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l git-name -d 'Git author name' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l git-email -d 'Git author email' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l cache -d 'Package cache volume' -xa 'shared private'
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l package -d 'Additional Ubuntu package' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l enable-ssh -d "Enable ssh access" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l enable-sudo -d "Enable sudo access (password: \"dev\")" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l neovim -d "Install Neovim" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l starship -d "Install Starship" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l atuin -d "Install Atuin" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l mise -d "Install Mise" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l zellij -d "Install Zellij" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l jujutsu -d "Install Jujutsu" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l port -d 'Expose port' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l volume -d 'Add volume' -r
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-wasm -d "Remove WebAssembly tools" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-ssh -d "Disable ssh access" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-sudo -d "Disable sudo access" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-neovim -d "Do not install Neovim" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-starship -d "Do not install Starship" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-atuin -d "Do not install Atuin" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-mise -d "Do not install Mise" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-zellij -d "Do not install Zellij" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l no-jujutsu -d "Do not install Jujutsu" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l remove-package -d 'Remove an Ubuntu package' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l remove-port -d 'Stop exposing a port' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l remove-volume -d 'Stop mounting a volume' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from edit update" -l force -d "Re-generate manually edited files without asking" -f
+
+complete -c paul-envs -n "__fish_seen_subcommand_from export" -s o -d "Path of the written bundle" -r
+complete -c paul-envs -n "__fish_seen_subcommand_from export" -l dotfiles -d "Include paul-envs' dotfiles" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from export" -l force -d "Replace an existing file without asking" -f
+
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l name -d "Name of the new project" -x
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l uid -d 'Container UID' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l gid -d 'Container GID' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l ssh-key -d "SSH public key to mount" -r
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l git-name -d 'Git author name' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l git-email -d 'Git author email' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l dotfiles -d "Add the bundle's dotfiles to yours" -f
+
+complete -c paul-envs -n "__fish_seen_subcommand_from save" -s o -d "Path of the written archive" -r
+complete -c paul-envs -n "__fish_seen_subcommand_from save" -l no-volume -d "Only save the image" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from save" -l force -d "Replace an existing file without asking" -f
+
+complete -c paul-envs -n "__fish_seen_subcommand_from load" -l force -d "Replace the existing volume without asking" -f
+
+complete -c paul-envs -n "__fish_seen_subcommand_from backup" -s o -d "Path of the written backup" -r
+complete -c paul-envs -n "__fish_seen_subcommand_from backup" -l force -d "Replace an existing file without asking" -f
+
+complete -c paul-envs -n "__fish_seen_subcommand_from restore" -l force -d "Replace the existing volume without asking" -f
+
+complete -c paul-envs -n "__fish_seen_subcommand_from clone" -l name -d "Name of the new project" -x
+complete -c paul-envs -n "__fish_seen_subcommand_from clone" -l reuse-image -d "Reuse the image of the existing project" -f
 
 complete -c paul-envs -n "__fish_seen_subcommand_from list" -l names -d "Only display names" -f
 
-# Container name completion for build, run, remove
+complete -c paul-envs -n "__fish_seen_subcommand_from exec" -l workdir -d "Working directory of the command" -x
+complete -c paul-envs -n "__fish_seen_subcommand_from exec" -l env -d "Environment variable, as KEY=VALUE" -x
+complete -c paul-envs -n "__fish_seen_subcommand_from exec" -l user -d "User running the command" -x
+
+complete -c paul-envs -n "__fish_seen_subcommand_from stop kill" -l all -d "Apply to the containers of all projects" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from stop" -l timeout -d "Seconds before killing the container" -x
+
+complete -c paul-envs -f -n "__fish_seen_subcommand_from config; and not __fish_seen_subcommand_from get set unset list" -a 'get set unset list'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from get set unset" -a 'engine default.uid default.gid default.username default.shell default.nodejs default.rust default.python default.go default.wasm default.ssh default.ssh-key default.sudo default.git-name default.git-email default.cache default.neovim default.starship default.atuin default.mise default.zellij default.jujutsu default.packages default.ports default.volumes'
+
+complete -c paul-envs -f -n "__fish_seen_subcommand_from preset; and not __fish_seen_subcommand_from save list show remove" -a 'save list show remove'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from preset; and __fish_seen_subcommand_from show remove" -a '(__paul_envs_presets)'
+complete -c paul-envs -n "__fish_seen_subcommand_from preset; and __fish_seen_subcommand_from save" -l from -d "Project whose configuration is saved" -xa '(__paul_envs_containers)'
+complete -c paul-envs -n "__fish_seen_subcommand_from preset; and __fish_seen_subcommand_from save" -l force -d "Replace an existing preset without asking" -f
+
+complete -c paul-envs -f -n "__fish_seen_subcommand_from cache; and not __fish_seen_subcommand_from du prune reset" -a 'du prune reset'
+complete -c paul-envs -n "__fish_seen_subcommand_from cache; and __fish_seen_subcommand_from du prune reset" -l project -d "Use the cache of that project" -xa '(__paul_envs_containers)'
+complete -c paul-envs -n "__fish_seen_subcommand_from cache; and __fish_seen_subcommand_from prune" -l older-than -d "Only prune files not accessed for that long" -x
+complete -c paul-envs -n "__fish_seen_subcommand_from cache; and __fish_seen_subcommand_from prune" -l only -d "Comma-separated directories to prune" -xa 'npm yarn pip go xdg'
+complete -c paul-envs -n "__fish_seen_subcommand_from cache; and __fish_seen_subcommand_from prune reset" -l force -d "Do not ask for confirmation" -f
+
+complete -c paul-envs -n "__fish_seen_subcommand_from upgrade-base" -l force -d "Replace the base files without asking" -f
+
+# Container name completion for status, build, edit, run, exec, up, down, remove, stop, kill
+complete -c paul-envs -f -n "__fish_seen_subcommand_from status" -a '(__paul_envs_containers)'
 complete -c paul-envs -f -n "__fish_seen_subcommand_from build" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from edit update" -a '(__paul_envs_containers)'
 complete -c paul-envs -f -n "__fish_seen_subcommand_from run" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from exec" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from up" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from down" -a '(__paul_envs_containers)'
 complete -c paul-envs -f -n "__fish_seen_subcommand_from remove" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from stop" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from kill" -a '(__paul_envs_containers)'
+complete -c paul-envs -n "__fish_seen_subcommand_from clone" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from rename" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from export" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from save" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from backup" -a '(__paul_envs_containers)'
+complete -c paul-envs -n "__fish_seen_subcommand_from restore" -a '(__paul_envs_containers)'
@@ -5,21 +5,46 @@ _paulenvs() {
     commands=(
         'interactive:Start interactive mode'
         'create:Create a container configuration'
+        'edit:Change the configuration of a project'
         'list:List all available containers'
+        'status:Show the current state of projects'
         'build:Build a container'
         'run:Start a container'
+        'exec:Run a single command in a container'
+        'up:Start a container in the background'
+        'down:Stop and remove the container of a project'
         'remove:Remove a container'
+        'stop:Stop the running container of a project'
+        'kill:Kill the running container of a project'
         'help:Show help'
         'version:Show version'
         'clean:Remove all stored paul-envs data from your computer'
+        'config:Read or update the global configuration'
+        'preset:Save and manage presets for new projects'
+        'clone:Copy the configuration of a project onto another path'
+        'rename:Rename a project, with its image and volume'
+        'export:Export the configuration of a project as a bundle'
+        'import:Create a project from an exported bundle'
+        'save:Save the built image and local volume of a project'
+        'load:Load an image and volume written by save'
+        'backup:Back up the local volume of a project'
+        'restore:Restore the local volume of a project from a backup'
+        'cache:Inspect, prune or reset a package cache'
+        'upgrade-base:Upgrade the base Dockerfile and entrypoint'
     )
 
     # Get list of existing containers from paul-envs ls
     local -a containers
     containers=(${(f)"$(paul-envs list --names 2>/dev/null)"})
 
+    # Get list of saved presets
+    local -a presets
+    presets=(${(f)"$(paul-envs preset list --names 2>/dev/null)"})
+
 
     _arguments -C \
+        '--engine[Container engine to use]:engine:(docker docker-api podman auto)' \
+        '--output[Output format of list, status, version and build]:format:(text json yaml)' \
         '1: :->command' \
         '*: :->args' && return 0
 
@@ -36,6 +61,8 @@ _paulenvs() {
                     _arguments \
                         '2:project path:_directories' \
                         '--name[Specify container name]:name:' \
+                        '--no-manifest[Ignore the project manifest]' \
+                        '*--preset[Apply a saved preset]:preset:($presets)' \
                         '--uid[Host UID]:uid:($(id -u))' \
                         '--gid[Host GID]:gid:($(id -g))' \
                         '--username[Container username]:username:' \
@@ -46,6 +73,7 @@ _paulenvs() {
                         '--go[Go installation]:version:' \
                         '--git-name[Git author name]:name:' \
                         '--git-email[Git author email]:email:' \
+                        '--cache[Package cache volume]:cache:(shared private)' \
                         '--enable-ssh[Enable ssh access]' \
                         '--enable-sudo[Enable sudo access (password: \"dev\")]' \
                         '--neovim[Install latest Neovim]' \
@@ -54,15 +82,113 @@ _paulenvs() {
                         '--mise[Install latest Mise]' \
                         '--zellij[Install latest Zellij]' \
                         '--jujutsu[Install latest Jujutsu]' \
+                        '--no-wasm[Remove WebAssembly tools]' \
+                        '--no-ssh[Disable ssh access]' \
+                        '--no-sudo[Disable sudo access]' \
+                        '--no-neovim[Do not install Neovim]' \
+                        '--no-starship[Do not install Starship]' \
+                        '--no-atuin[Do not install Atuin]' \
+                        '--no-mise[Do not install Mise]' \
+                        '--no-zellij[Do not install Zellij]' \
+                        '--no-jujutsu[Do not install Jujutsu]' \
                         '*--package[Additional package from Ubuntu repo]:package:' \
                         '*--port[Expose port]:port:' \
                         '*--volume[Add volume]:volume:_files'
                     ;;
+                edit|update)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '--uid[Host UID]:uid:($(id -u))' \
+                        '--gid[Host GID]:gid:($(id -g))' \
+                        '--username[Container username]:username:' \
+                        '--shell[User shell]:shell:(bash zsh fish)' \
+                        '--nodejs[Node.js installation]:version:' \
+                        '--rust[Rust installation]:version:' \
+                        '--python[Python installation]:version:' \
+                        '--go[Go installation]:version:' \
+                        '--git-name[Git author name]:name:' \
+                        '--git-email[Git author email]:email:' \
+                        '--cache[Package cache volume]:cache:(shared private)' \
+                        '--enable-ssh[Enable ssh access]' \
+                        '--enable-sudo[Enable sudo access (password: \"dev\")]' \
+                        '--neovim[Install latest Neovim]' \
+                        '--starship[Install latest Starship]' \
+                        '--atuin[Install latest Atuin]' \
+                        '--mise[Install latest Mise]' \
+                        '--zellij[Install latest Zellij]' \
+                        '--jujutsu[Install latest Jujutsu]' \
+                        '--no-wasm[Remove WebAssembly tools]' \
+                        '--no-ssh[Disable ssh access]' \
+                        '--no-sudo[Disable sudo access]' \
+                        '--no-neovim[Do not install Neovim]' \
+                        '--no-starship[Do not install Starship]' \
+                        '--no-atuin[Do not install Atuin]' \
+                        '--no-mise[Do not install Mise]' \
+                        '--no-zellij[Do not install Zellij]' \
+                        '--no-jujutsu[Do not install Jujutsu]' \
+                        '*--package[Additional package from Ubuntu repo]:package:' \
+                        '*--port[Expose port]:port:' \
+                        '*--volume[Add volume]:volume:_files' \
+                        '*--remove-package[Remove an Ubuntu package]:package:' \
+                        '*--remove-port[Stop exposing a port]:port:' \
+                        '*--remove-volume[Stop mounting a volume]:volume:' \
+                        '--force[Re-generate manually edited files without asking]'
+                    ;;
+                export)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '-o[Path of the written bundle]:file:_files' \
+                        "--dotfiles[Include paul-envs' dotfiles]" \
+                        '--force[Replace an existing file without asking]'
+                    ;;
+                import)
+                    _arguments \
+                        '2:bundle:_files' \
+                        '3:project path:_directories' \
+                        '--name[Name of the new project]:name:' \
+                        '--uid[Container UID]:uid:($(id -u))' \
+                        '--gid[Container GID]:gid:($(id -g))' \
+                        '--ssh-key[SSH public key to mount]:key:_files' \
+                        '--git-name[Git author name]:name:' \
+                        '--git-email[Git author email]:email:' \
+                        "--dotfiles[Add the bundle's dotfiles to yours]"
+                    ;;
+                save)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '-o[Path of the written archive]:file:_files' \
+                        "--no-volume[Only save the image]" \
+                        '--force[Replace an existing file without asking]'
+                    ;;
+                load)
+                    _arguments \
+                        '2:archive:_files' \
+                        '--force[Replace the existing volume without asking]'
+                    ;;
+                backup)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '-o[Path of the written backup]:file:_files' \
+                        '--force[Replace an existing file without asking]'
+                    ;;
+                restore)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '3:backup:_files' \
+                        '--force[Replace the existing volume without asking]'
+                    ;;
+                clone)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '3:new project path:_directories' \
+                        '--name[Name of the new project]:name:' \
+                        '--reuse-image[Reuse the image of the existing project]'
+                    ;;
                 list)
                     _arguments \
                         '--names[Only display names]' \
                     ;;
-                build)
+                status|build|rename)
                     _arguments \
                         "2:container name:(${containers[@]})"
                     ;;
@@ -71,10 +197,29 @@ _paulenvs() {
                         "2:container name:(${containers[@]})" \
                         '*:command:'
                     ;;
-                remove)
+                exec)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '--workdir[Working directory of the command]:directory:' \
+                        '*--env[Environment variable, as KEY=VALUE]:variable:' \
+                        '--user[User running the command]:user:' \
+                        '*:command:'
+                    ;;
+                up|down|remove)
                     _arguments \
                         "2:container name:(${containers[@]})"
                     ;;
+                stop)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '--all[Stop the containers of all projects]' \
+                        '--timeout[Seconds before killing the container]:seconds:'
+                    ;;
+                kill)
+                    _arguments \
+                        "2:container name:(${containers[@]})" \
+                        '--all[Kill the containers of all projects]'
+                    ;;
                 help)
                     # No additional arguments
                     ;;
@@ -84,6 +229,30 @@ _paulenvs() {
                 clean)
                     # No additional arguments
                     ;;
+                config)
+                    _arguments \
+                        '2:action:(get set unset list)' \
+                        '3:key:(engine default.uid default.gid default.username default.shell default.nodejs default.rust default.python default.go default.wasm default.ssh default.ssh-key default.sudo default.git-name default.git-email default.cache default.neovim default.starship default.atuin default.mise default.zellij default.jujutsu default.packages default.ports default.volumes)'
+                    ;;
+                cache)
+                    _arguments \
+                        '2:action:(du prune reset)' \
+                        '--project[Use the cache of that project]:project:($containers)' \
+                        '--older-than[Only prune files not accessed for that long]:age:' \
+                        '--only[Comma-separated directories to prune]:dirs:(npm yarn pip go xdg)' \
+                        '--force[Do not ask for confirmation]'
+                    ;;
+                upgrade-base)
+                    _arguments \
+                        '--force[Replace the base files without asking]'
+                    ;;
+                preset)
+                    _arguments \
+                        '2:action:(save list show remove)' \
+                        '3:preset:($presets)' \
+                        '--from[Project whose configuration is saved]:project:($containers)' \
+                        '--force[Replace an existing preset without asking]'
+                    ;;
             esac
             ;;
     esac